@@ -0,0 +1,369 @@
+// Package portfolio derives positions and P&L from a run's fill events.
+//
+// Positions are never logged: a Ledger folds order.filled events into
+// them, so the same events always yield the same positions, whether they
+// are applied as they are appended or replayed from the log. All money is
+// fixed-point (see pkg/decimal); nothing here is a float.
+package portfolio
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"aiplatform/internals/runtime"
+	"aiplatform/pkg/assert"
+	"aiplatform/pkg/decimal"
+)
+
+// pnlPlaces is the precision P&L is reported in: cents (T21).
+const pnlPlaces = 2
+
+// InvariantPositionQuantity identifies T20 in the violations Replay
+// reports.
+const InvariantPositionQuantity = "T20" // numbered in TRADING.md
+
+// ErrPositionLimit is returned for a fill that would leave a position
+// breaking T20.
+var ErrPositionLimit = errors.New("position limit")
+
+// Key identifies a position. A strategy holds at most one position per
+// symbol (T23), and strategies never share one (T72).
+type Key struct {
+	StrategyID string
+	Symbol     string
+}
+
+// Limits are the T20 bounds a ledger holds every position to.
+type Limits struct {
+	// MaxPositionSize bounds a position's quantity, long or short.
+	MaxPositionSize int64
+
+	// ShortSelling allows sell_short orders to open short positions.
+	ShortSelling bool
+}
+
+// Position is one strategy's holding in one symbol.
+type Position struct {
+	Key
+
+	// Quantity is positive for a long position and negative for a short.
+	Quantity int64
+
+	// CostBasis is what the open quantity cost, signed like Quantity: a
+	// short's basis is the negated proceeds of opening it. Closing part of
+	// a position removes a proportional part of it, rounded to micros, and
+	// closing all of it removes the rest, so rounding never outlives the
+	// position.
+	CostBasis decimal.Decimal
+
+	// Realized is the P&L of closed quantity, in micros, before
+	// commissions. RealizedPnL reports it at T21 precision.
+	Realized decimal.Decimal
+
+	// Commissions is the sum of the commissions paid on the position's fills.
+	Commissions decimal.Decimal
+}
+
+// AvgCost returns the average price of the open quantity, or zero for a
+// flat position.
+func (p Position) AvgCost() decimal.Decimal {
+	if p.Quantity == 0 {
+		return decimal.Zero
+	}
+	return p.CostBasis.DivInt(p.Quantity)
+}
+
+// RealizedPnL returns the P&L of closed quantity, rounded to cents (T21).
+func (p Position) RealizedPnL() (decimal.Decimal, error) {
+	pnl, err := p.Realized.Round(pnlPlaces)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("realized P&L of %s %s: %w", p.StrategyID, p.Symbol, err)
+	}
+	return pnl, nil
+}
+
+// UnrealizedPnL returns what closing the open quantity at mark would
+// realize, (mark - avg_cost) × quantity, rounded to cents (T21).
+func (p Position) UnrealizedPnL(mark decimal.Decimal) (decimal.Decimal, error) {
+	value, err := mark.MulInt(p.Quantity)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("value %s %s at %s: %w", p.StrategyID, p.Symbol, mark, err)
+	}
+	pnl, err := value.Sub(p.CostBasis)
+	if err == nil {
+		pnl, err = pnl.Round(pnlPlaces)
+	}
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("unrealized P&L of %s %s at %s: %w",
+			p.StrategyID, p.Symbol, mark, err)
+	}
+	return pnl, nil
+}
+
+// Ledger folds a run's order events into positions. Fills carry only an
+// order ID, so the ledger follows orders with its own runtime.OrderTracker
+// to learn each fill's strategy, symbol, and side.
+//
+// A ledger is pure, like the tracker: it does no I/O, and a fill it
+// refuses leaves it unchanged. It is not safe for concurrent use.
+type Ledger struct {
+	limits    Limits
+	orders    *runtime.OrderTracker
+	positions map[Key]Position
+}
+
+// NewLedger returns a ledger with no positions.
+func NewLedger(limits Limits) *Ledger {
+	assert.Gt(limits.MaxPositionSize, 0, "max position size must be positive")
+	return &Ledger{
+		limits:    limits,
+		orders:    runtime.NewOrderTracker(),
+		positions: make(map[Key]Position),
+	}
+}
+
+// Replay folds events, in log order, into a new ledger. It returns the
+// same ledger that applying the events one by one as they were appended
+// would have built.
+//
+// The log records what the broker executed, so a fill that breaks T20 is
+// still applied, taking the position through zero or past its limit if
+// it must, and is reported as a violation. Replay fails only for an order
+// event T11 does not allow, or on overflow.
+func Replay(events []runtime.Event, limits Limits) (*Ledger, []runtime.Violation, error) {
+	ledger := NewLedger(limits)
+	var violations []runtime.Violation
+	for _, event := range events {
+		err := ledger.Apply(event)
+		if errors.Is(err, ErrPositionLimit) {
+			filled := event.(runtime.OrderFilledEvent)
+			violations = append(violations, runtime.Violation{
+				Invariant: InvariantPositionQuantity,
+				Seq:       filled.Seq,
+				RunID:     filled.RunID,
+				StepID:    filled.StepID,
+				Message:   err.Error(),
+			})
+			err = ledger.apply(event, false)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return ledger, violations, nil
+}
+
+// Check reports whether Apply would accept the event, without applying
+// it. A strategy checks a fill here before appending it, so T20 holds
+// for every fill it appends.
+func (l *Ledger) Check(event runtime.Event) error {
+	_, err := l.next(event, true)
+	return err
+}
+
+// Apply folds an event into the ledger. Order events move the ledger's
+// order tracker, and fills also move a position; other events are
+// ignored. An error wraps runtime.ErrIllegalOrderTransition for an
+// order event T11 does not allow, ErrPositionLimit for a fill that would
+// break T20, or decimal.ErrOverflow. On error the ledger is unchanged.
+func (l *Ledger) Apply(event runtime.Event) error {
+	return l.apply(event, true)
+}
+
+// apply is Apply, with T20 enforced only if enforceLimits is set.
+func (l *Ledger) apply(event runtime.Event, enforceLimits bool) error {
+	position, err := l.next(event, enforceLimits)
+	if err != nil {
+		return err
+	}
+	err = l.orders.Apply(event)
+	assert.No_err(err, "checked order event must apply")
+	if position != nil {
+		l.positions[position.Key] = *position
+	}
+	return nil
+}
+
+// Position returns the position a strategy holds in a symbol, and false
+// if it has never traded the symbol. A position closed to zero is kept,
+// with its realized P&L.
+func (l *Ledger) Position(key Key) (Position, bool) {
+	position, ok := l.positions[key]
+	return position, ok
+}
+
+// Positions returns every position, sorted by strategy and then symbol.
+func (l *Ledger) Positions() []Position {
+	positions := make([]Position, 0, len(l.positions))
+	for _, position := range l.positions {
+		positions = append(positions, position)
+	}
+	slices.SortFunc(positions, func(a, b Position) int {
+		if c := strings.Compare(a.StrategyID, b.StrategyID); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Symbol, b.Symbol)
+	})
+	return positions
+}
+
+// StrategyPositions returns one strategy's positions, sorted by symbol.
+// It never includes another strategy's positions (T72).
+func (l *Ledger) StrategyPositions(strategyID string) []Position {
+	var positions []Position
+	for _, position := range l.Positions() {
+		if position.StrategyID == strategyID {
+			positions = append(positions, position)
+		}
+	}
+	return positions
+}
+
+// next checks an event and returns the position it moves into, or nil
+// for an event that moves no position. T20 is checked only if
+// enforceLimits is set.
+func (l *Ledger) next(event runtime.Event, enforceLimits bool) (*Position, error) {
+	if err := l.orders.Check(event); err != nil {
+		return nil, err
+	}
+	filled, ok := event.(runtime.OrderFilledEvent)
+	if !ok {
+		return nil, nil
+	}
+	state, ok := l.orders.Order(filled.OrderID)
+	assert.Is_true(ok, "checked fill must be for a submitted order")
+
+	order := state.Order
+	key := Key{StrategyID: order.StrategyID, Symbol: order.Symbol}
+	position := l.positions[key]
+	position.Key = key
+
+	delta := fillDelta(order.Side, filled.Quantity)
+	if enforceLimits {
+		if err := l.checkFill(position, order, filled.Quantity, delta); err != nil {
+			return nil, err
+		}
+	}
+	if err := position.fill(delta, filled.Fill); err != nil {
+		return nil, fmt.Errorf("fill %s of order %s: %w", filled.FillID, filled.OrderID, err)
+	}
+	return &position, nil
+}
+
+// fillDelta returns the signed change a fill of quantity shares on side
+// makes to a position's quantity.
+func fillDelta(side runtime.OrderSide, quantity int64) int64 {
+	switch side {
+	case runtime.OrderSideBuy, runtime.OrderSideBuyToCover:
+		return quantity
+	case runtime.OrderSideSell, runtime.OrderSideSellShort:
+		return -quantity
+	default:
+		assert.Is_true(false, fmt.Sprintf("order side must be valid, got %q", side))
+		return 0
+	}
+}
+
+// checkFill enforces T20 for a fill of quantity shares of order, moving
+// position by delta.
+func (l *Ledger) checkFill(position Position, order runtime.Order, quantity int64,
+	delta int64) error {
+	held := position.Quantity
+	var allowed bool
+	switch order.Side {
+	case runtime.OrderSideBuy:
+		allowed = held >= 0
+	case runtime.OrderSideSell:
+		allowed = held >= quantity
+	case runtime.OrderSideSellShort:
+		allowed = l.limits.ShortSelling && held <= 0
+	case runtime.OrderSideBuyToCover:
+		allowed = -held >= quantity
+	}
+	if !allowed {
+		return fmt.Errorf("%w: %s fill of %d for order %s against %s %s position of %d (T20)",
+			ErrPositionLimit, order.Side, quantity, order.OrderID, order.StrategyID, order.Symbol,
+			held)
+	}
+	limit := l.limits.MaxPositionSize
+	if after := held + delta; after > limit || -after > limit {
+		return fmt.Errorf("%w: fill for order %s takes %s %s position to %d, limit is %d (T20)",
+			ErrPositionLimit, order.OrderID, order.StrategyID, order.Symbol, after, limit)
+	}
+	return nil
+}
+
+// fill moves the position by delta shares at the fill's price and adds
+// its commission. A fill that takes the position through zero, which
+// only Replay applies, closes it and opens the rest on the other side.
+func (p *Position) fill(delta int64, fill runtime.Fill) error {
+	commissions, err := p.Commissions.Add(decimal.FromMicros(fill.CommissionMicros))
+	if err != nil {
+		return err
+	}
+	next := *p
+	price := decimal.FromMicros(fill.PriceMicros)
+	if next.Quantity != 0 && (next.Quantity > 0) != (delta > 0) && abs(delta) > abs(next.Quantity) {
+		closing := -next.Quantity
+		if err := next.move(closing, price); err != nil {
+			return err
+		}
+		delta -= closing
+	}
+	if err := next.move(delta, price); err != nil {
+		return err
+	}
+	next.Commissions = commissions
+	*p = next
+	return nil
+}
+
+// move moves the position by delta shares at price, without taking it
+// through zero: it either adds to the position or closes part or all of
+// it.
+func (p *Position) move(delta int64, price decimal.Decimal) error {
+	value, err := price.MulInt(delta)
+	if err != nil {
+		return err
+	}
+
+	basis, realized := p.CostBasis, p.Realized
+	if p.Quantity == 0 || (p.Quantity > 0) == (delta > 0) {
+		if basis, err = basis.Add(value); err != nil {
+			return err
+		}
+	} else {
+		removed := basis
+		if p.Quantity+delta != 0 {
+			if removed, err = basis.MulDiv(-delta, p.Quantity); err != nil {
+				return err
+			}
+		}
+		// Closing a long realizes its proceeds, -value, less its cost;
+		// covering a short realizes the same with both signs flipped.
+		pnl, err := value.Neg().Sub(removed)
+		if err != nil {
+			return err
+		}
+		if realized, err = realized.Add(pnl); err != nil {
+			return err
+		}
+		if basis, err = basis.Sub(removed); err != nil {
+			return err
+		}
+	}
+
+	p.Quantity += delta
+	p.CostBasis, p.Realized = basis, realized
+	return nil
+}
+
+// abs returns |n| for a quantity, which is never math.MinInt64.
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,362 @@
+package portfolio
+
+import (
+	"testing"
+	"time"
+
+	"aiplatform/internals/runtime"
+	"aiplatform/pkg/decimal"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testRunID     = runtime.RunID("run-portfolio")
+	strategyA     = "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+	strategyB     = "16fd2706-8baf-433b-82eb-8c7fada847da"
+	testLimitSize = 1000
+)
+
+var testTime = time.Date(2025, 1, 15, 14, 30, 0, 0, time.UTC)
+
+// testLimits allows positions of up to 1000 shares, long or short.
+var testLimits = Limits{MaxPositionSize: testLimitSize, ShortSelling: true}
+
+// testOrder returns a market order for quantity shares of symbol.
+func testOrder(orderID string, strategyID string, symbol string, side runtime.OrderSide,
+	quantity int64) runtime.Order {
+	return runtime.Order{
+		OrderID:     orderID,
+		StrategyID:  strategyID,
+		Symbol:      symbol,
+		Side:        side,
+		OrderType:   runtime.OrderTypeMarket,
+		Quantity:    quantity,
+		TimeInForce: runtime.TimeInForceDay,
+	}
+}
+
+// testFill returns a fill of quantity shares at price with a $1 commission.
+func testFill(orderID string, fillID string, quantity int64, price string) runtime.Fill {
+	return runtime.Fill{
+		OrderID:          orderID,
+		FillID:           fillID,
+		Quantity:         quantity,
+		PriceMicros:      dec(price).Micros(),
+		CommissionMicros: 1_000_000,
+		FillTime:         testTime,
+	}
+}
+
+// dec parses a decimal literal known to be valid.
+func dec(s string) decimal.Decimal {
+	d, err := decimal.Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// trade returns the events of an order for strategy A that fills
+// completely in one fill at price.
+func trade(orderID string, symbol string, side runtime.OrderSide, quantity int64,
+	price string) []runtime.Event {
+	return tradeFor(strategyA, orderID, symbol, side, quantity, price)
+}
+
+// tradeFor is trade for any strategy.
+func tradeFor(strategyID string, orderID string, symbol string, side runtime.OrderSide,
+	quantity int64, price string) []runtime.Event {
+	return []runtime.Event{
+		runtime.FormatOrderSubmitted(1, testTime, testRunID, "s1",
+			testOrder(orderID, strategyID, symbol, side, quantity)),
+		runtime.FormatOrderFilled(2, testTime, testRunID, "s1",
+			testFill(orderID, "f-"+orderID, quantity, price)),
+	}
+}
+
+// applyAll applies every event, failing the test on the first error.
+func applyAll(t *testing.T, ledger *Ledger, events ...[]runtime.Event) {
+	t.Helper()
+	for _, batch := range events {
+		for _, event := range batch {
+			require.NoError(t, ledger.Apply(event))
+		}
+	}
+}
+
+// position returns strategy A's position in symbol.
+func position(t *testing.T, ledger *Ledger, symbol string) Position {
+	t.Helper()
+	p, ok := ledger.Position(Key{StrategyID: strategyA, Symbol: symbol})
+	require.True(t, ok, "no position in %s", symbol)
+	return p
+}
+
+// realizedPnL returns a position's realized P&L in cents.
+func realizedPnL(t *testing.T, p Position) decimal.Decimal {
+	t.Helper()
+	pnl, err := p.RealizedPnL()
+	require.NoError(t, err)
+	return pnl
+}
+
+// TestLedger_LongPosition verifies average cost, realized, and unrealized
+// P&L as a long position is built up and partly sold.
+func TestLedger_LongPosition(t *testing.T) {
+	ledger := NewLedger(testLimits)
+	applyAll(t, ledger,
+		trade("o1", "AAPL", runtime.OrderSideBuy, 10, "100"),
+		trade("o2", "AAPL", runtime.OrderSideBuy, 10, "110"))
+
+	p := position(t, ledger, "AAPL")
+	assert.Equal(t, int64(20), p.Quantity)
+	assert.Equal(t, dec("105"), p.AvgCost())
+	assert.True(t, realizedPnL(t, p).IsZero())
+
+	applyAll(t, ledger, trade("o3", "AAPL", runtime.OrderSideSell, 5, "120"))
+	p = position(t, ledger, "AAPL")
+	assert.Equal(t, int64(15), p.Quantity)
+	assert.Equal(t, dec("105"), p.AvgCost())
+	assert.Equal(t, dec("75"), realizedPnL(t, p))
+	assert.Equal(t, dec("3"), p.Commissions)
+
+	unrealized, err := p.UnrealizedPnL(dec("100"))
+	require.NoError(t, err)
+	assert.Equal(t, dec("-75"), unrealized)
+	unrealized, err = p.UnrealizedPnL(dec("106.5"))
+	require.NoError(t, err)
+	assert.Equal(t, dec("22.5"), unrealized)
+}
+
+// TestLedger_ShortPosition verifies P&L on a short position is made as
+// the price falls.
+func TestLedger_ShortPosition(t *testing.T) {
+	ledger := NewLedger(testLimits)
+	applyAll(t, ledger,
+		trade("o1", "TSLA", runtime.OrderSideSellShort, 10, "50"),
+		trade("o2", "TSLA", runtime.OrderSideBuyToCover, 4, "45"))
+
+	p := position(t, ledger, "TSLA")
+	assert.Equal(t, int64(-6), p.Quantity)
+	assert.Equal(t, dec("50"), p.AvgCost())
+	assert.Equal(t, dec("20"), realizedPnL(t, p))
+
+	unrealized, err := p.UnrealizedPnL(dec("40"))
+	require.NoError(t, err)
+	assert.Equal(t, dec("60"), unrealized)
+
+	applyAll(t, ledger, trade("o3", "TSLA", runtime.OrderSideBuyToCover, 6, "55"))
+	p = position(t, ledger, "TSLA")
+	assert.Zero(t, p.Quantity)
+	assert.True(t, p.CostBasis.IsZero())
+	assert.Equal(t, dec("-10"), realizedPnL(t, p))
+	assert.True(t, p.AvgCost().IsZero())
+}
+
+// TestLedger_PnLPrecision verifies P&L is reported in cents while the
+// ledger keeps it in micros, and closing a position leaves no rounding
+// residue (T21).
+func TestLedger_PnLPrecision(t *testing.T) {
+	ledger := NewLedger(testLimits)
+	applyAll(t, ledger,
+		trade("o1", "AAPL", runtime.OrderSideBuy, 2, "10"),
+		trade("o2", "AAPL", runtime.OrderSideBuy, 1, "10.01"),
+		trade("o3", "AAPL", runtime.OrderSideSell, 1, "10.01"))
+
+	p := position(t, ledger, "AAPL")
+	assert.Equal(t, dec("10.003334"), p.AvgCost())
+	assert.Equal(t, dec("0.006667"), p.Realized)
+	assert.Equal(t, dec("0.01"), realizedPnL(t, p))
+	unrealized, err := p.UnrealizedPnL(dec("10.0025"))
+	require.NoError(t, err)
+	assert.True(t, unrealized.IsZero(), "unrealized %s", unrealized)
+
+	// Selling the rest at the first price gives back the whole gain.
+	applyAll(t, ledger, trade("o4", "AAPL", runtime.OrderSideSell, 2, "10.00"))
+	p = position(t, ledger, "AAPL")
+	assert.True(t, p.Realized.IsZero(), "realized %s", p.Realized)
+	assert.True(t, p.CostBasis.IsZero(), "cost basis %s", p.CostBasis)
+}
+
+// TestLedger_PartialCloseOfLargeBasis verifies closing part of a position
+// whose cost basis times the closed quantity overflows int64 still
+// removes the proportional basis.
+func TestLedger_PartialCloseOfLargeBasis(t *testing.T) {
+	ledger := NewLedger(Limits{MaxPositionSize: 1_000_000})
+	applyAll(t, ledger,
+		trade("o1", "BRK", runtime.OrderSideBuy, 1_000_000, "100000"),
+		trade("o2", "BRK", runtime.OrderSideSell, 999_999, "100000.01"))
+
+	p := position(t, ledger, "BRK")
+	assert.Equal(t, int64(1), p.Quantity)
+	assert.Equal(t, dec("100000"), p.CostBasis)
+	assert.Equal(t, dec("9999.99"), realizedPnL(t, p))
+}
+
+// TestLedger_EnforcesPositionLimits verifies a fill that would break T20
+// is refused and leaves the ledger unchanged.
+func TestLedger_EnforcesPositionLimits(t *testing.T) {
+	long := [][]runtime.Event{trade("long", "AAPL", runtime.OrderSideBuy, 10, "100")}
+	short := [][]runtime.Event{trade("short", "AAPL", runtime.OrderSideSellShort, 10, "100")}
+	noShorts := Limits{MaxPositionSize: testLimitSize}
+	tests := []struct {
+		name   string
+		limits Limits
+		before [][]runtime.Event
+		side   runtime.OrderSide
+		qty    int64
+	}{
+		{"sell_without_position", testLimits, nil, runtime.OrderSideSell, 1},
+		{"sell_more_than_held", testLimits, long, runtime.OrderSideSell, 11},
+		{"buy_against_short", testLimits, short, runtime.OrderSideBuy, 1},
+		{"short_against_long", testLimits, long, runtime.OrderSideSellShort, 1},
+		{"short_selling_disabled", noShorts, nil, runtime.OrderSideSellShort, 1},
+		{"cover_without_short", testLimits, long, runtime.OrderSideBuyToCover, 1},
+		{"cover_more_than_short", testLimits, short, runtime.OrderSideBuyToCover, 11},
+		{"long_over_max", testLimits, long, runtime.OrderSideBuy, testLimitSize - 9},
+		{"short_over_max", testLimits, short, runtime.OrderSideSellShort, testLimitSize - 9},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ledger := NewLedger(tt.limits)
+			applyAll(t, ledger, tt.before...)
+			before := ledger.Positions()
+
+			events := trade("o1", "AAPL", tt.side, tt.qty, "100")
+			require.NoError(t, ledger.Apply(events[0]))
+			assert.ErrorIs(t, ledger.Check(events[1]), ErrPositionLimit)
+			assert.ErrorIs(t, ledger.Apply(events[1]), ErrPositionLimit)
+			assert.Equal(t, before, ledger.Positions())
+
+			// The refused fill did not move the order either.
+			state, ok := ledger.orders.Order("o1")
+			require.True(t, ok)
+			assert.Equal(t, runtime.OrderStatusSubmitted, state.Status)
+		})
+	}
+}
+
+// TestLedger_RefusesIllegalOrderTransitions verifies the ledger holds
+// fills to T11, so a fill is never counted twice.
+func TestLedger_RefusesIllegalOrderTransitions(t *testing.T) {
+	ledger := NewLedger(testLimits)
+	events := trade("o1", "AAPL", runtime.OrderSideBuy, 10, "100")
+	assert.ErrorIs(t, ledger.Apply(events[1]), runtime.ErrIllegalOrderTransition)
+	assert.Empty(t, ledger.Positions())
+
+	applyAll(t, ledger, events)
+	assert.ErrorIs(t, ledger.Apply(events[1]), runtime.ErrIllegalOrderTransition)
+	assert.Equal(t, int64(10), position(t, ledger, "AAPL").Quantity)
+
+	// Events that are not order events do not concern the ledger.
+	assert.NoError(t, ledger.Apply(runtime.FormatRunFinished(3, testTime, testRunID)))
+}
+
+// TestLedger_IsolatesStrategies verifies each strategy holds one position
+// per symbol (T23), and strategies trading the same symbol do not share
+// one (T72).
+func TestLedger_IsolatesStrategies(t *testing.T) {
+	ledger := NewLedger(testLimits)
+	applyAll(t, ledger,
+		trade("o1", "MSFT", runtime.OrderSideBuy, 10, "400"),
+		trade("o2", "AAPL", runtime.OrderSideBuy, 5, "100"),
+		trade("o3", "AAPL", runtime.OrderSideBuy, 5, "110"),
+		tradeFor(strategyB, "o4", "AAPL", runtime.OrderSideSellShort, 3, "120"))
+
+	positions := ledger.Positions()
+	require.Len(t, positions, 3)
+	assert.Equal(t, Key{StrategyID: strategyB, Symbol: "AAPL"}, positions[0].Key)
+	assert.Equal(t, Key{StrategyID: strategyA, Symbol: "AAPL"}, positions[1].Key)
+	assert.Equal(t, Key{StrategyID: strategyA, Symbol: "MSFT"}, positions[2].Key)
+
+	assert.Equal(t, int64(10), positions[1].Quantity)
+	assert.Equal(t, dec("105"), positions[1].AvgCost())
+	assert.Equal(t, int64(-3), positions[0].Quantity)
+
+	// Strategy B's short does not count against strategy A's long.
+	applyAll(t, ledger, trade("o5", "AAPL", runtime.OrderSideSell, 10, "100"))
+	assert.Zero(t, position(t, ledger, "AAPL").Quantity)
+	assert.Equal(t, positions[:1], ledger.StrategyPositions(strategyB))
+	assert.Len(t, ledger.StrategyPositions(strategyA), 2)
+}
+
+// TestReplay_MatchesLiveLedger verifies a ledger replayed from the log
+// equals the one built as the events were appended.
+func TestReplay_MatchesLiveLedger(t *testing.T) {
+	workspaceRoot := t.TempDir()
+	log, err := runtime.OpenEventLog(testRunID, workspaceRoot, runtime.DefaultLogOptions())
+	require.NoError(t, err)
+	require.NoError(t, log.AppendRunStarted(testRunID, workspaceRoot))
+	require.NoError(t, log.AppendStepStarted(testRunID, "s1", runtime.PhaseDataIngestion))
+
+	live := NewLedger(testLimits)
+	trades := [][]runtime.Event{
+		trade("o1", "AAPL", runtime.OrderSideBuy, 7, "189.24"),
+		trade("o2", "AAPL", runtime.OrderSideBuy, 3, "190.333333"),
+		trade("o3", "AAPL", runtime.OrderSideSell, 4, "191.07"),
+		tradeFor(strategyB, "o4", "AAPL", runtime.OrderSideSellShort, 9, "188.5"),
+		tradeFor(strategyB, "o5", "AAPL", runtime.OrderSideBuyToCover, 2, "187.999999"),
+	}
+	for _, events := range trades {
+		submitted := events[0].(runtime.OrderSubmittedEvent)
+		filled := events[1].(runtime.OrderFilledEvent)
+		require.NoError(t, log.AppendOrderSubmitted(testRunID, "s1", submitted.Order))
+		require.NoError(t, log.AppendOrderFilled(testRunID, "s1", filled.Fill))
+		applyAll(t, live, events)
+	}
+	require.NoError(t, log.Close())
+
+	events, err := runtime.ReadEvents(testRunID, workspaceRoot)
+	require.NoError(t, err)
+	replayed, violations, err := Replay(events, testLimits)
+	require.NoError(t, err)
+	assert.Empty(t, violations)
+	assert.Equal(t, live.Positions(), replayed.Positions())
+
+	again, _, err := Replay(events, testLimits)
+	require.NoError(t, err)
+	assert.Equal(t, replayed, again)
+}
+
+// TestReplay_ReportsPositionLimitViolations verifies replay still folds a
+// logged fill that breaks T20 into the positions, and reports it.
+func TestReplay_ReportsPositionLimitViolations(t *testing.T) {
+	var events []runtime.Event
+	for _, batch := range [][]runtime.Event{
+		trade("o1", "AAPL", runtime.OrderSideBuy, 10, "100"),
+		trade("o2", "AAPL", runtime.OrderSideSell, 15, "110"),
+		trade("o3", "MSFT", runtime.OrderSideBuy, testLimitSize+1, "400"),
+	} {
+		events = append(events, batch...)
+	}
+	for i, event := range events {
+		filled, ok := event.(runtime.OrderFilledEvent)
+		if ok {
+			filled.Seq = int64(i + 1)
+			events[i] = filled
+		}
+	}
+
+	ledger, violations, err := Replay(events, testLimits)
+	require.NoError(t, err)
+	require.Len(t, violations, 2)
+	assert.Equal(t, InvariantPositionQuantity, violations[0].Invariant)
+	assert.Equal(t, int64(4), violations[0].Seq)
+	assert.Equal(t, "s1", violations[0].StepID)
+	assert.Contains(t, violations[0].Message, "sell fill of 15")
+	assert.Equal(t, int64(6), violations[1].Seq)
+
+	// The sell closed the long and opened a short with the rest.
+	aapl := position(t, ledger, "AAPL")
+	assert.Equal(t, int64(-5), aapl.Quantity)
+	assert.Equal(t, dec("-550"), aapl.CostBasis)
+	assert.Equal(t, dec("100"), realizedPnL(t, aapl))
+	assert.Equal(t, dec("2"), aapl.Commissions)
+	assert.Equal(t, int64(testLimitSize+1), position(t, ledger, "MSFT").Quantity)
+
+	// A fill T11 does not allow still stops the replay.
+	_, _, err = Replay(append(events, events[1]), testLimits)
+	assert.ErrorIs(t, err, runtime.ErrIllegalOrderTransition)
+}
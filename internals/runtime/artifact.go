@@ -0,0 +1,268 @@
+package runtime
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path/filepath"
+
+	"aiplatform/pkg/assert"
+)
+
+const (
+	// artifactBytesMax bounds a single artifact. Hashing reads the whole
+	// file, so the bound also bounds the work one verification does.
+	artifactBytesMax = 1024 * 1024 * 1024
+
+	// artifactPathBytesMax bounds an artifact's relative path.
+	artifactPathBytesMax = 1024
+)
+
+var (
+	// ErrArtifactEscapesWorkspace reports a path that resolves outside the
+	// workspace root, by "..", an absolute path, or a symlink.
+	ErrArtifactEscapesWorkspace = errors.New("artifact path escapes workspace")
+
+	// ErrArtifactMismatch reports an artifact whose content no longer
+	// matches the size and hash recorded for it.
+	ErrArtifactMismatch = errors.New("artifact does not match its recorded hash")
+)
+
+// Artifact describes a file a run produced, as recorded in artifact.created.
+type Artifact struct {
+	// Path is relative to the workspace root and slash-separated.
+	Path string `json:"path"`
+
+	// SHA256 is the hex SHA-256 of the content.
+	SHA256 string `json:"sha256"`
+
+	// Size is the content length in bytes.
+	Size int64 `json:"size"`
+
+	// MediaType is the content's MIME type, e.g. "application/json".
+	MediaType string `json:"media_type"`
+}
+
+// ArtifactStore writes and verifies one run's artifacts. Content it
+// writes is stored under .aiplatform/artifacts/<run_id>/, named by its
+// SHA-256, so identical content is stored once.
+type ArtifactStore struct {
+	workspaceRoot string
+	runID         RunID
+}
+
+// NewArtifactStore returns the artifact store of a run.
+func NewArtifactStore(workspaceRoot string, runID RunID) *ArtifactStore {
+	assert.Is_true(filepath.IsAbs(workspaceRoot), "workspace root must be absolute path")
+	assert.Is_true(runID != RunID(""), "runID must not be empty")
+	return &ArtifactStore{workspaceRoot: workspaceRoot, runID: runID}
+}
+
+// artifactDirPath returns the directory holding a run's stored artifacts.
+func artifactDirPath(workspaceRoot string, runID RunID) string {
+	assert.Not_empty(workspaceRoot, "workspaceRoot must not be empty")
+	assert.Is_true(runID != RunID(""), "runID must not be empty")
+	return filepath.Join(workspaceRoot, ".aiplatform", "artifacts", string(runID))
+}
+
+// Put stores content atomically under its hash and describes it.
+// Content that is already stored is not written again.
+func (s *ArtifactStore) Put(content []byte, mediaType string) (Artifact, error) {
+	if err := validateMediaType(mediaType); err != nil {
+		return Artifact{}, err
+	}
+	if len(content) > artifactBytesMax {
+		return Artifact{}, fmt.Errorf("artifact is %d bytes, limit is %d",
+			len(content), artifactBytesMax)
+	}
+
+	sum := sha256.Sum256(content)
+	hash := hex.EncodeToString(sum[:])
+	dir := artifactDirPath(s.workspaceRoot, s.runID)
+	path := filepath.Join(dir, hash)
+	// Content already stored is trusted only if it still hashes to its
+	// name; a missing or damaged copy is written again.
+	stored, size, err := hashFile(path)
+	if err != nil || stored != hash || size != int64(len(content)) {
+		if err := writeFileAtomic(dir, path, content); err != nil {
+			return Artifact{}, err
+		}
+	}
+
+	relative, err := filepath.Rel(s.workspaceRoot, path)
+	assert.No_err(err, "artifact path must be inside the workspace")
+	return Artifact{
+		Path:      filepath.ToSlash(relative),
+		SHA256:    hash,
+		Size:      int64(len(content)),
+		MediaType: mediaType,
+	}, nil
+}
+
+// Describe hashes a file the run wrote itself and describes it.
+// The path is relative to the workspace root and must stay inside it.
+func (s *ArtifactStore) Describe(path string, mediaType string) (Artifact, error) {
+	if err := validateMediaType(mediaType); err != nil {
+		return Artifact{}, err
+	}
+	full, err := s.resolve(path)
+	if err != nil {
+		return Artifact{}, err
+	}
+	hash, size, err := hashFile(full)
+	if err != nil {
+		return Artifact{}, err
+	}
+	return Artifact{Path: path, SHA256: hash, Size: size, MediaType: mediaType}, nil
+}
+
+// Verify checks that an artifact still exists inside the workspace and
+// matches its recorded size and hash.
+func (s *ArtifactStore) Verify(artifact Artifact) error {
+	full, err := s.resolve(artifact.Path)
+	if err != nil {
+		return err
+	}
+	hash, size, err := hashFile(full)
+	if err != nil {
+		return err
+	}
+	if size != artifact.Size || hash != artifact.SHA256 {
+		return fmt.Errorf("%w: %s", ErrArtifactMismatch, artifact.Path)
+	}
+	return nil
+}
+
+// resolve maps a relative artifact path to an absolute one, rejecting
+// any path that leaves the workspace, including through a symlink.
+func (s *ArtifactStore) resolve(path string) (string, error) {
+	if path == "" || len(path) > artifactPathBytesMax {
+		return "", fmt.Errorf("artifact path must be 1 to %d bytes", artifactPathBytesMax)
+	}
+	if !filepath.IsLocal(filepath.FromSlash(path)) {
+		return "", fmt.Errorf("%w: %s", ErrArtifactEscapesWorkspace, path)
+	}
+
+	root, err := filepath.EvalSymlinks(s.workspaceRoot)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve workspace root: %w", err)
+	}
+	full, err := filepath.EvalSymlinks(filepath.Join(root, filepath.FromSlash(path)))
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve artifact %s: %w", path, err)
+	}
+	relative, err := filepath.Rel(root, full)
+	if err != nil || !filepath.IsLocal(relative) {
+		return "", fmt.Errorf("%w: %s", ErrArtifactEscapesWorkspace, path)
+	}
+	return full, nil
+}
+
+// hashFile returns the hex SHA-256 and size of a regular file.
+func hashFile(path string) (string, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to open artifact: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to stat artifact: %w", err)
+	}
+	if !info.Mode().IsRegular() {
+		return "", 0, fmt.Errorf("artifact %s is not a regular file", path)
+	}
+	if info.Size() > artifactBytesMax {
+		return "", 0, fmt.Errorf("artifact is %d bytes, limit is %d", info.Size(), artifactBytesMax)
+	}
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, io.LimitReader(file, artifactBytesMax+1))
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to read artifact: %w", err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), size, nil
+}
+
+// validateMediaType checks a MIME type such as "text/csv; charset=utf-8".
+func validateMediaType(mediaType string) error {
+	if _, _, err := mime.ParseMediaType(mediaType); err != nil {
+		return fmt.Errorf("invalid media type %q: %w", mediaType, err)
+	}
+	return nil
+}
+
+// validate checks that an artifact description is complete.
+func (a Artifact) validate() error {
+	if a.Path == "" {
+		return fmt.Errorf("artifact path must not be empty")
+	}
+	if sum, err := hex.DecodeString(a.SHA256); err != nil || len(sum) != sha256.Size {
+		return fmt.Errorf("artifact hash must be a hex SHA-256, got %q", a.SHA256)
+	}
+	if a.Size < 0 || a.Size > artifactBytesMax {
+		return fmt.Errorf("artifact size must be in [0, %d], got %d", artifactBytesMax, a.Size)
+	}
+	return validateMediaType(a.MediaType)
+}
+
+// writeFileAtomic writes data to path through a synced temp file in dir,
+// then renames it into place and syncs dir. Directories it has to create
+// are synced into their parents. Readers never see a partial file, and
+// the file survives a crash once this returns.
+func writeFileAtomic(dir string, path string, data []byte) error {
+	assert.Is_true(filepath.Dir(path) == dir, "path must be in dir")
+
+	if err := makeDirsDurable(dir); err != nil {
+		return err
+	}
+
+	temp, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file in %s: %w", dir, err)
+	}
+	tempPath := temp.Name()
+
+	_, err = temp.Write(data)
+	if err == nil {
+		err = temp.Sync()
+	}
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tempPath, path)
+	}
+	if err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return syncDirs(dir)
+}
+
+// makeDirsDurable creates dir and any missing parents, then syncs the
+// parent of each directory it created, so the new entries survive a crash.
+func makeDirsDurable(dir string) error {
+	var parents []string
+	for d := filepath.Clean(dir); filepath.Dir(d) != d; d = filepath.Dir(d) {
+		if _, err := os.Stat(d); err == nil {
+			break
+		} else if !os.IsNotExist(err) {
+			return fmt.Errorf("failed to stat directory %s: %w", d, err)
+		}
+		parents = append(parents, filepath.Dir(d))
+	}
+	if len(parents) == 0 {
+		return nil
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+	return syncDirs(parents...)
+}
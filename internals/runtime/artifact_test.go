@@ -0,0 +1,147 @@
+package runtime
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testArtifact describes an artifact without writing it, for tests that
+// only format or validate events.
+func testArtifact(path string) Artifact {
+	content := []byte(`{"orders":[]}`)
+	sum := sha256.Sum256(content)
+	return Artifact{
+		Path:      path,
+		SHA256:    hex.EncodeToString(sum[:]),
+		Size:      int64(len(content)),
+		MediaType: "application/json",
+	}
+}
+
+// TestArtifactStore_PutIsContentAddressed verifies stored content is named
+// by its hash, stored once, and verified against it.
+func TestArtifactStore_PutIsContentAddressed(t *testing.T) {
+	workspaceRoot := t.TempDir()
+	store := NewArtifactStore(workspaceRoot, "run-artifacts")
+	content := []byte("symbol,qty\nAAPL,10\n")
+
+	first, err := store.Put(content, "text/csv")
+	require.NoError(t, err)
+	second, err := store.Put(content, "text/csv")
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+
+	sum := sha256.Sum256(content)
+	assert.Equal(t, hex.EncodeToString(sum[:]), first.SHA256)
+	assert.Equal(t, ".aiplatform/artifacts/run-artifacts/"+first.SHA256, first.Path)
+	assert.Equal(t, int64(len(content)), first.Size)
+	assert.Equal(t, "text/csv", first.MediaType)
+	require.NoError(t, store.Verify(first))
+
+	path := filepath.Join(workspaceRoot, filepath.FromSlash(first.Path))
+	require.NoError(t, os.WriteFile(path, []byte("symbol,qty\nAAPL,99\n"), 0644))
+	assert.ErrorIs(t, store.Verify(first), ErrArtifactMismatch)
+
+	// Putting the content again replaces the damaged copy.
+	_, err = store.Put(content, "text/csv")
+	require.NoError(t, err)
+	require.NoError(t, store.Verify(first))
+
+	require.NoError(t, os.Remove(path))
+	assert.Error(t, store.Verify(first))
+
+	_, err = store.Put(content, "not a media type")
+	assert.Error(t, err)
+}
+
+// TestArtifactStore_RejectsEscapingPaths verifies the Artifact Path
+// Validity invariant: paths are relative and stay inside the workspace.
+func TestArtifactStore_RejectsEscapingPaths(t *testing.T) {
+	parent := t.TempDir()
+	workspaceRoot := filepath.Join(parent, "workspace")
+	require.NoError(t, os.MkdirAll(filepath.Join(workspaceRoot, "out"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(workspaceRoot, "out", "report.md"), []byte("# Report\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(parent, "secret.txt"), []byte("secret"), 0644))
+	require.NoError(t, os.Symlink(filepath.Join(parent, "secret.txt"), filepath.Join(workspaceRoot, "out", "link.txt")))
+	store := NewArtifactStore(workspaceRoot, "run-artifacts")
+
+	artifact, err := store.Describe("out/report.md", "text/markdown")
+	require.NoError(t, err)
+	assert.Equal(t, int64(len("# Report\n")), artifact.Size)
+	require.NoError(t, store.Verify(artifact))
+
+	escaping := []string{
+		"../secret.txt",
+		"out/../../secret.txt",
+		filepath.Join(parent, "secret.txt"),
+		"out/link.txt",
+	}
+	for _, path := range escaping {
+		_, err := store.Describe(path, "text/plain")
+		assert.ErrorIs(t, err, ErrArtifactEscapesWorkspace, path)
+
+		// A body stored at a logged path is held to the same rule.
+		body := Body{SHA256: strings.Repeat("0", 64), Size: int64(len("secret")), Path: path}
+		_, err = store.readBody(body)
+		assert.ErrorIs(t, err, ErrArtifactEscapesWorkspace, path)
+		assert.ErrorIs(t, store.verifyBody(body), ErrArtifactEscapesWorkspace, path)
+	}
+}
+
+// TestEventLog_ArtifactCreated verifies artifact.created records the
+// artifact's size, media type, and hash, refuses artifacts that do not
+// match the disk, and that ValidateLog re-checks them on replay.
+func TestEventLog_ArtifactCreated(t *testing.T) {
+	workspaceRoot := t.TempDir()
+	runID := RunID("run-artifact-created")
+	store := NewArtifactStore(workspaceRoot, runID)
+	orders, err := store.Put([]byte(`{"orders":[]}`), "application/json")
+	require.NoError(t, err)
+
+	log, err := OpenEventLog(runID, workspaceRoot, testLogOptions())
+	require.NoError(t, err)
+	require.NoError(t, log.AppendRunStarted(runID, workspaceRoot))
+	require.NoError(t, log.AppendStepStarted(runID, "s1", PhaseDataIngestion))
+
+	missing := orders
+	missing.Path = "out/missing.json"
+	wrongSize := orders
+	wrongSize.Size++
+	noMediaType := orders
+	noMediaType.MediaType = ""
+	noHash := orders
+	noHash.SHA256 = ""
+	for name, artifact := range map[string]Artifact{
+		"missing": missing, "wrong_size": wrongSize, "no_media_type": noMediaType, "no_hash": noHash,
+	} {
+		assert.Error(t, log.AppendArtifactCreated(runID, "s1", artifact), name)
+	}
+
+	require.NoError(t, log.AppendArtifactCreated(runID, "s1", orders))
+	require.NoError(t, log.Close())
+
+	events, err := ReadEvents(runID, workspaceRoot)
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	assert.Equal(t, FormatArtifactCreated(3, testTime, runID, "s1", orders), events[2])
+
+	violations, err := ValidateLog(runID, workspaceRoot, ReplayModeInProgress)
+	require.NoError(t, err)
+	assert.Empty(t, violations)
+
+	// An artifact that changes after it was recorded is caught on replay.
+	require.NoError(t, os.WriteFile(
+		filepath.Join(workspaceRoot, filepath.FromSlash(orders.Path)), []byte(`{"orders":[1]}`), 0644))
+	violations, err = ValidateLog(runID, workspaceRoot, ReplayModeInProgress)
+	require.NoError(t, err)
+	v := findViolation(t, violations, InvariantArtifactIntegrity)
+	assert.Equal(t, int64(3), v.Seq)
+	assert.Contains(t, v.Message, "does not match")
+}
@@ -0,0 +1,118 @@
+package runtime
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+)
+
+const (
+	// bodyInlineBytesMax is the largest body kept inline in its event.
+	// Larger bodies are stored as artifacts, so log lines stay small and
+	// well under eventLineBytesMax.
+	bodyInlineBytesMax = 4 * 1024
+
+	// bodyBytesMax bounds a single body, inline or stored.
+	bodyBytesMax = 64 * 1024 * 1024
+)
+
+// Body is content carried by an event, such as an LLM prompt or response.
+// A small body is kept inline; a large one is stored as an artifact and
+// referenced by Path. SHA256 identifies the content either way.
+type Body struct {
+	// SHA256 is the hex SHA-256 of the content.
+	SHA256 string `json:"sha256"`
+
+	// Size is the content length in bytes.
+	Size int64 `json:"size"`
+
+	// Inline holds the content when it is at most bodyInlineBytesMax bytes.
+	Inline string `json:"inline,omitempty"`
+
+	// Path is the stored content's path relative to the workspace root,
+	// set when the content was too large to inline.
+	Path string `json:"path,omitempty"`
+}
+
+// IsSet reports whether the body was recorded. Events written before a
+// body field existed decode with an unset body.
+func (b Body) IsSet() bool {
+	return b.SHA256 != ""
+}
+
+// newBody records content as a Body, storing it in the run's artifact
+// store if it is too large to inline.
+func newBody(workspaceRoot string, runID RunID, content string) (Body, error) {
+	if len(content) > bodyBytesMax {
+		return Body{}, fmt.Errorf("body is %d bytes, limit is %d", len(content), bodyBytesMax)
+	}
+
+	if len(content) > bodyInlineBytesMax {
+		store := NewArtifactStore(workspaceRoot, runID)
+		artifact, err := store.Put([]byte(content), "application/octet-stream")
+		if err != nil {
+			return Body{}, err
+		}
+		return Body{SHA256: artifact.SHA256, Size: artifact.Size, Path: artifact.Path}, nil
+	}
+
+	sum := sha256.Sum256([]byte(content))
+	return Body{
+		SHA256: hex.EncodeToString(sum[:]),
+		Size:   int64(len(content)),
+		Inline: content,
+	}, nil
+}
+
+// matches reports whether content is what the body recorded.
+func (b Body) matches(content []byte) bool {
+	sum := sha256.Sum256(content)
+	return int64(len(content)) == b.Size && hex.EncodeToString(sum[:]) == b.SHA256
+}
+
+// verifyBody checks a body against its recorded hash and size. A stored
+// body is hashed through the store, so its path cannot leave the
+// workspace. A body an older event never recorded has nothing to check.
+func (s *ArtifactStore) verifyBody(body Body) error {
+	if !body.IsSet() {
+		return nil
+	}
+	if body.Path != "" {
+		return s.Verify(Artifact{Path: body.Path, SHA256: body.SHA256, Size: body.Size})
+	}
+	if !body.matches([]byte(body.Inline)) {
+		return fmt.Errorf("%w: inline body %s", ErrArtifactMismatch, body.SHA256)
+	}
+	return nil
+}
+
+// readBody returns a body's content, loading it through the store if it
+// was stored, and checks it against the recorded hash and size.
+func (s *ArtifactStore) readBody(body Body) (string, error) {
+	content := []byte(body.Inline)
+	if body.Path != "" {
+		if body.Size < 0 || body.Size > bodyBytesMax {
+			return "", fmt.Errorf("stored body is %d bytes, limit is %d", body.Size, bodyBytesMax)
+		}
+		full, err := s.resolve(body.Path)
+		if err != nil {
+			return "", err
+		}
+		file, err := os.Open(full)
+		if err != nil {
+			return "", fmt.Errorf("failed to read stored body: %w", err)
+		}
+		defer file.Close()
+		content, err = io.ReadAll(io.LimitReader(file, bodyBytesMax+1))
+		if err != nil {
+			return "", fmt.Errorf("failed to read stored body: %w", err)
+		}
+	}
+
+	if !body.matches(content) {
+		return "", fmt.Errorf("%w: body %s", ErrArtifactMismatch, body.SHA256)
+	}
+	return string(content), nil
+}
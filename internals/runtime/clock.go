@@ -0,0 +1,73 @@
+package runtime
+
+import (
+	"sync/atomic"
+	"time"
+
+	"aiplatform/pkg/assert"
+)
+
+// Clock supplies the wall-clock time the writer stamps on each event.
+// Tests and deterministic replay inject a ManualClock; everything else
+// uses SystemClock.
+type Clock interface {
+	// Now returns the current time. It must never return the zero time.
+	Now() time.Time
+}
+
+// SystemClock reads the system wall clock, in UTC.
+type SystemClock struct{}
+
+// Now returns the current time in UTC. UTC also strips the monotonic
+// reading, so the time compares equal to itself after a round trip
+// through JSON.
+func (SystemClock) Now() time.Time {
+	return time.Now().UTC()
+}
+
+// ManualClock is a Clock that only moves when told to.
+// It is safe for concurrent use: the time is swapped atomically, so the
+// writer goroutine reading it never waits on a test moving it.
+type ManualClock struct {
+	// now holds a time.Time rather than unix nanos, which cannot reach
+	// the far-future times tests use to make encoding fail.
+	now atomic.Pointer[time.Time]
+}
+
+// NewManualClock returns a clock stopped at start.
+func NewManualClock(start time.Time) *ManualClock {
+	assert.Is_true(!start.IsZero(), "start must not be the zero time")
+	c := &ManualClock{}
+	start = start.UTC()
+	c.now.Store(&start)
+	return c
+}
+
+// Now returns the clock's current time.
+func (c *ManualClock) Now() time.Time {
+	return *c.now.Load()
+}
+
+// Advance moves the clock forward by d.
+func (c *ManualClock) Advance(d time.Duration) {
+	assert.Is_true(d >= 0, "clock must not move backwards")
+	for {
+		now := c.now.Load()
+		next := now.Add(d)
+		if c.now.CompareAndSwap(now, &next) {
+			return
+		}
+	}
+}
+
+// Set moves the clock to t, which must not be before the current time.
+func (c *ManualClock) Set(t time.Time) {
+	t = t.UTC()
+	for {
+		now := c.now.Load()
+		assert.Is_true(!t.Before(*now), "clock must not move backwards")
+		if c.now.CompareAndSwap(now, &t) {
+			return
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package runtime
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testTime is the time every test log is stamped with, so events read back
+// from disk compare equal to events built with the formatter.
+var testTime = time.Date(2026, time.January, 2, 15, 4, 5, 0, time.UTC)
+
+// testLogOptions returns DefaultLogOptions with a clock stopped at testTime.
+func testLogOptions() LogOptions {
+	options := DefaultLogOptions()
+	options.Clock = NewManualClock(testTime)
+	return options
+}
+
+// TestManualClock_MovesOnlyWhenTold verifies a manual clock stays put until
+// advanced or set, and never moves backwards.
+func TestManualClock_MovesOnlyWhenTold(t *testing.T) {
+	clock := NewManualClock(testTime)
+	assert.Equal(t, testTime, clock.Now())
+	assert.Equal(t, testTime, clock.Now())
+
+	clock.Advance(1500 * time.Millisecond)
+	assert.Equal(t, testTime.Add(1500*time.Millisecond), clock.Now())
+
+	later := testTime.Add(time.Hour)
+	clock.Set(later)
+	assert.Equal(t, later, clock.Now())
+	assert.Panics(t, func() { clock.Set(testTime) })
+	assert.Panics(t, func() { clock.Advance(-time.Second) })
+}
+
+// TestSystemClock_ReturnsUTC verifies system time is stamped in UTC and
+// survives a round trip through the log unchanged.
+func TestSystemClock_ReturnsUTC(t *testing.T) {
+	now := SystemClock{}.Now()
+	assert.Equal(t, time.UTC, now.Location())
+	assert.False(t, now.IsZero())
+
+	workspaceRoot := t.TempDir()
+	runID := RunID("run-system-clock")
+	log, err := OpenEventLog(runID, workspaceRoot, DefaultLogOptions())
+	require.NoError(t, err)
+	require.NoError(t, log.AppendRunStarted(runID, workspaceRoot))
+	require.NoError(t, log.Close())
+
+	events, err := ReadEvents(runID, workspaceRoot)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	stamped := events[0].(RunStartedEvent).Timestamp
+	assert.False(t, stamped.Before(now))
+	assert.Equal(t, time.UTC, stamped.Location())
+}
+
+// TestEventLog_StampsEventsFromClock verifies the writer stamps each event
+// with the log clock's time when the event is written, which is what lets
+// the log answer how long a call took.
+func TestEventLog_StampsEventsFromClock(t *testing.T) {
+	workspaceRoot := t.TempDir()
+	runID := RunID("run-clock")
+	clock := NewManualClock(testTime)
+	options := DefaultLogOptions()
+	options.Clock = clock
+
+	log, err := OpenEventLog(runID, workspaceRoot, options)
+	require.NoError(t, err)
+	require.NoError(t, log.AppendRunStarted(runID, workspaceRoot))
+	require.NoError(t, log.AppendStepStarted(runID, "s1", PhaseSignalGeneration))
+	require.NoError(t, log.AppendLLMRequested(runID, "s1", testLLMRequest("call-s1")))
+	clock.Advance(2300 * time.Millisecond)
+	require.NoError(t, log.AppendLLMResponded(runID, "s1", testLLMResponse("call-s1")))
+	require.NoError(t, log.Close())
+
+	events, err := ReadEvents(runID, workspaceRoot)
+	require.NoError(t, err)
+	require.Len(t, events, 4)
+	requested := events[2].(LLMRequestedEvent)
+	responded := events[3].(LLMRespondedEvent)
+	assert.Equal(t, FormatLLMRequested(3, testTime, runID, "s1", testLLMRequestPayload("call-s1")), requested)
+	assert.Equal(t, 2300*time.Millisecond, responded.Timestamp.Sub(requested.Timestamp))
+}
+
+// TestFormatter_RequiresTimestamp verifies no event can be formed without
+// a timestamp.
+func TestFormatter_RequiresTimestamp(t *testing.T) {
+	assert.Panics(t, func() { FormatRunFinished(1, time.Time{}, RunID("run-no-time")) })
+}
@@ -0,0 +1,152 @@
+package runtime
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"aiplatform/pkg/assert"
+)
+
+// SyncPolicy decides when an EventLog calls fsync. Flushing only hands
+// data to the OS; an event is durable across power loss once it is synced.
+// The zero value is invalid.
+type SyncPolicy int
+
+const (
+	// SyncAlways fsyncs after every append, before the append returns.
+	// An acknowledged event is never lost.
+	SyncAlways SyncPolicy = 1
+
+	// SyncEveryN fsyncs after every LogOptions.SyncEveryEvents appends.
+	// The fsync comes before the append that completes the count is
+	// acknowledged, so up to SyncEveryEvents-1 acknowledged events can be
+	// lost.
+	SyncEveryN SyncPolicy = 2
+
+	// SyncInterval fsyncs pending appends every LogOptions.SyncInterval.
+	// Events acknowledged within the last interval can be lost.
+	SyncInterval SyncPolicy = 3
+
+	// SyncNone never fsyncs and leaves durability to the OS page cache.
+	SyncNone SyncPolicy = 4
+)
+
+const (
+	// syncEveryEventsMax bounds SyncEveryEvents, and so how many
+	// acknowledged events SyncEveryN may leave unsynced.
+	syncEveryEventsMax = 1 << 20
+
+	// syncIntervalMin keeps SyncInterval from degenerating into a busy loop.
+	syncIntervalMin = time.Millisecond
+
+	// syncIntervalMax bounds how long acknowledged events may stay unsynced.
+	syncIntervalMax = time.Minute
+
+	// segmentBytesDefault and segmentEventsDefault are where
+	// DefaultLogOptions rolls a log over to a new segment. A restart scans
+	// at most one segment, so they bound recovery time.
+	segmentBytesDefault  = 64 * 1024 * 1024
+	segmentEventsDefault = 1 << 20
+
+	// segmentBytesLimit bounds SegmentBytesMax.
+	segmentBytesLimit = 1 << 30
+
+	// snapshotEveryEventsDefault is how often DefaultLogOptions snapshots a
+	// run's state. Recovery replays at most this many events per run.
+	snapshotEveryEventsDefault = 10_000
+)
+
+// LogOptions configures an EventLog.
+type LogOptions struct {
+	Sync SyncPolicy
+
+	// Clock stamps each event as it is written. Must not be nil.
+	Clock Clock
+
+	// SyncEveryEvents is the batch size for SyncEveryN. Unused otherwise.
+	SyncEveryEvents int
+
+	// SyncInterval is the period for SyncInterval. Unused otherwise.
+	SyncInterval time.Duration
+
+	// SegmentBytesMax rolls the log over to a new segment once the tail
+	// segment holds this many bytes. The batch that crosses it is written
+	// whole, so a segment may end up slightly larger.
+	SegmentBytesMax int64
+
+	// SegmentEventsMax rolls the log over to a new segment once the tail
+	// segment holds this many events.
+	SegmentEventsMax int
+
+	// SnapshotEveryEvents is how many events the engine appends to a run
+	// between snapshots of its state. A run is also snapshotted as it ends.
+	SnapshotEveryEvents int
+}
+
+// DefaultLogOptions returns the options the engine uses unless configured
+// otherwise. Trading events must survive power loss, so every append syncs.
+func DefaultLogOptions() LogOptions {
+	return LogOptions{
+		Sync:                SyncAlways,
+		Clock:               SystemClock{},
+		SegmentBytesMax:     segmentBytesDefault,
+		SegmentEventsMax:    segmentEventsDefault,
+		SnapshotEveryEvents: snapshotEveryEventsDefault,
+	}
+}
+
+// validate checks the options are complete and within bounds.
+func (o LogOptions) validate() error {
+	if o.Clock == nil {
+		return fmt.Errorf("log clock must be set")
+	}
+	if o.SegmentBytesMax < 1 || o.SegmentBytesMax > segmentBytesLimit {
+		return fmt.Errorf("segment bytes must be in [1, %d], got %d",
+			segmentBytesLimit, o.SegmentBytesMax)
+	}
+	if o.SegmentEventsMax < 1 || o.SegmentEventsMax > eventsPerLogMax {
+		return fmt.Errorf("segment events must be in [1, %d], got %d",
+			eventsPerLogMax, o.SegmentEventsMax)
+	}
+	if o.SnapshotEveryEvents < 1 || o.SnapshotEveryEvents > eventsPerLogMax {
+		return fmt.Errorf("snapshot every events must be in [1, %d], got %d",
+			eventsPerLogMax, o.SnapshotEveryEvents)
+	}
+	switch o.Sync {
+	case SyncAlways, SyncNone:
+		return nil
+	case SyncEveryN:
+		if o.SyncEveryEvents < 1 || o.SyncEveryEvents > syncEveryEventsMax {
+			return fmt.Errorf("sync every events must be in [1, %d], got %d",
+				syncEveryEventsMax, o.SyncEveryEvents)
+		}
+		return nil
+	case SyncInterval:
+		if o.SyncInterval < syncIntervalMin || o.SyncInterval > syncIntervalMax {
+			return fmt.Errorf("sync interval must be in [%s, %s], got %s",
+				syncIntervalMin, syncIntervalMax, o.SyncInterval)
+		}
+		return nil
+	}
+	return fmt.Errorf("invalid sync policy: %d", o.Sync)
+}
+
+// syncDirs fsyncs each directory, so entries created in it survive a
+// crash. A new file is not durable until its directory entry is.
+func syncDirs(paths ...string) error {
+	for _, path := range paths {
+		assert.Not_empty(path, "path must not be empty")
+
+		dir, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("failed to open directory %s for sync: %w", path, err)
+		}
+		err = dir.Sync()
+		dir.Close()
+		if err != nil {
+			return fmt.Errorf("failed to sync directory %s: %w", path, err)
+		}
+	}
+	return nil
+}
@@ -1,15 +1,30 @@
 package runtime
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
+	"sync"
 
 	"aiplatform/pkg/assert"
 	"aiplatform/pkg/validate"
 )
 
+// ErrRunNotFound is returned for commands naming a run the engine does not know.
+var ErrRunNotFound = errors.New("run not found")
+
+// ErrRunTerminal is returned for commands against a run that has already
+// finished or failed. A terminal run accepts no further events (Invariant 2a).
+var ErrRunTerminal = errors.New("run is terminal")
+
+// ErrEngineClosed is returned for commands sent after Shutdown has begun,
+// and for commands still queued when the run loop stopped.
+var ErrEngineClosed = errors.New("engine is closed")
+
 // Command is the interface for all engine commands.
 // Commands are processed sequentially by the run loop.
 type Command interface {
@@ -34,10 +49,20 @@ type StartRunResult struct {
 // All operations are processed sequentially via the command channel.
 type Engine struct {
 	cmdCh chan Command
+
+	// closing is closed when Shutdown begins; no new command is accepted.
+	closing   chan struct{}
+	closeOnce sync.Once
+
+	// done is closed when the run loop has drained and exited.
+	// closeErr is written before done is closed and only read after.
+	done     chan struct{}
+	closeErr error
 }
 
 // RunHandle tracks the state of a run.
 // This is a cache of events; the event log is the source of truth.
+// Replay rebuilds a RunHandle from the log after a restart.
 type RunHandle struct {
 	ID            RunID
 	LastSeq       int64
@@ -46,39 +71,280 @@ type RunHandle struct {
 	WorkspaceRoot string // normalized, absolute path (symlinks resolved)
 	Attempts      map[Phase]int
 	PhaseDone     map[Phase]bool
+	Steps         map[string]StepState // keyed by step ID
+
+	// Incomplete is set by crash recovery for a run whose log has no
+	// terminal event. Such a run was interrupted, not finished. It is
+	// cleared if recovery fails the run, so it is never set on a
+	// Terminal run.
+	Incomplete bool
+
+	// TailRepair is set by crash recovery when it cut a torn final line
+	// from the run's log. Nil if the log ended cleanly.
+	TailRepair *TailRepair
+
+	// SnapshotFailures counts the snapshots of the run that could not be
+	// written since the engine opened its log, and SnapshotErr is the
+	// latest such failure. Appends go on regardless, but recovery has to
+	// replay more of the log.
+	SnapshotFailures int
+	SnapshotErr      error
 }
 
 // RunID uniquely identifies a run.
 type RunID string
 
-// NewEngine creates a new engine and starts its run loop.
+// registry is the engine state owned by the run loop goroutine.
+// Only runLoop (and recovery, before runLoop starts) may touch it.
+type registry struct {
+	runs map[RunID]*RunHandle
+
+	// logs holds the open EventLog of every non-terminal run.
+	// A log is closed and removed as soon as its run becomes terminal.
+	logs map[RunID]*EventLog
+
+	// logOptions is used for every log the engine opens.
+	logOptions LogOptions
+
+	// snapshotSeqs holds the seq of each run's latest snapshot, or where
+	// its count towards the next one started.
+	snapshotSeqs map[RunID]int64
+}
+
+// newRegistry creates an empty registry whose logs use logOptions.
+func newRegistry(logOptions LogOptions) *registry {
+	assert.No_err(logOptions.validate(), "log options must be valid")
+	return &registry{
+		runs:         make(map[RunID]*RunHandle),
+		logs:         make(map[RunID]*EventLog),
+		logOptions:   logOptions,
+		snapshotSeqs: make(map[RunID]int64),
+	}
+}
+
+// appendEvent writes one event through the run's log, then updates the
+// handle. Events before state: if write fails, the handle is untouched.
+// apply mutates the handle to reflect the event; if it leaves the run
+// terminal, the log is snapshotted and closed.
+func (r *registry) appendEvent(handle *RunHandle, write func(*EventLog) error,
+	apply func(*RunHandle)) error {
+	assert.Not_nil(handle, "handle must not be nil")
+	assert.Is_true(!handle.Terminal, "cannot append to a terminal run")
+
+	log, exists := r.logs[handle.ID]
+	assert.Is_true(exists, fmt.Sprintf("run %s must have an open log", handle.ID))
+
+	if err := write(log); err != nil {
+		return err
+	}
+
+	handle.LastSeq++
+	apply(handle)
+	if err := r.snapshotIfDue(handle, log); err != nil {
+		handle.SnapshotFailures++
+		handle.SnapshotErr = fmt.Errorf("snapshot of run %s at seq %d: %w", handle.ID, handle.LastSeq, err)
+	}
+
+	if handle.Terminal {
+		delete(r.logs, handle.ID)
+		if err := log.Close(); err != nil {
+			return fmt.Errorf("failed to close log of terminal run %s: %w", handle.ID, err)
+		}
+	}
+	return nil
+}
+
+// snapshotIfDue writes a snapshot of the run's state once
+// SnapshotEveryEvents events have been appended since the last one, and
+// when the run ends.
+//
+// A snapshot only speeds up recovery, and the event it would cover is
+// already acknowledged, so appendEvent counts a failed snapshot on the
+// handle rather than failing the append. Recovery falls back to an older
+// snapshot or a full replay.
+func (r *registry) snapshotIfDue(handle *RunHandle, log *EventLog) error {
+	assert.Not_nil(handle, "handle must not be nil")
+	assert.Not_nil(log, "log must not be nil")
+
+	due := handle.LastSeq-r.snapshotSeqs[handle.ID] >= int64(r.logOptions.SnapshotEveryEvents)
+	if !due && !handle.Terminal {
+		return nil
+	}
+	r.snapshotSeqs[handle.ID] = handle.LastSeq
+
+	head, err := log.head()
+	if err != nil {
+		return err
+	}
+	return writeSnapshot(log.runDir, newRunSnapshot(handle, head))
+}
+
+// NewEngine creates a new engine with no prior runs and starts its run loop.
+// Its logs use DefaultLogOptions.
 func NewEngine() *Engine {
+	return startEngine(newRegistry(DefaultLogOptions()))
+}
+
+// OpenEngine creates an engine that first recovers every run logged under
+// config.WorkspaceRoot, then starts its run loop.
+//
+// Recovery happens before the run loop exists, so no command can observe
+// a partially recovered registry.
+func OpenEngine(config EngineConfig) (*Engine, error) {
+	reg, err := recoverRuns(config)
+	if err != nil {
+		return nil, err
+	}
+	return startEngine(reg), nil
+}
+
+// startEngine hands the registry to a new run loop goroutine.
+func startEngine(reg *registry) *Engine {
+	assert.Not_nil(reg, "registry must not be nil")
+	assert.Not_nil(reg.runs, "runs map must not be nil")
+
 	e := &Engine{
-		cmdCh: make(chan Command, 64),
+		cmdCh:   make(chan Command, 64),
+		closing: make(chan struct{}),
+		done:    make(chan struct{}),
 	}
-	go e.runLoop()
+	go e.runLoop(reg)
 	return e
 }
 
 // runLoop processes all commands sequentially.
 // This is the only goroutine that mutates engine state.
-func (e *Engine) runLoop() {
-	runs := make(map[RunID]*RunHandle)
+//
+// Once Shutdown begins, the loop handles every command already queued,
+// closes every open log, and exits.
+func (e *Engine) runLoop(reg *registry) {
+	assert.Not_nil(reg, "registry must not be nil")
 
-	for cmd := range e.cmdCh {
-		switch c := cmd.(type) {
-		case StartRunCmd:
-			e.handleStartRun(runs, c)
+	for {
+		select {
+		case cmd := <-e.cmdCh:
+			e.handle(reg, cmd)
+		case <-e.closing:
+			e.drain(reg)
+			e.closeErr = closeRegistryLogs(reg)
+			close(e.done)
+			return
+		}
+	}
+}
+
+// drain handles the commands queued before Shutdown closed the engine.
+// Commands are only accepted while closing is open, so the queue cannot
+// grow without bound here.
+func (e *Engine) drain(reg *registry) {
+	for {
+		select {
+		case cmd := <-e.cmdCh:
+			e.handle(reg, cmd)
 		default:
-			panic(fmt.Sprintf("unknown command type: %T", cmd))
+			return
 		}
 	}
 }
 
+// handle dispatches one command to its handler.
+func (e *Engine) handle(reg *registry, cmd Command) {
+	switch c := cmd.(type) {
+	case StartRunCmd:
+		e.handleStartRun(reg, c)
+	case StartStepCmd:
+		e.handleStartStep(reg, c)
+	case FinishStepCmd:
+		e.handleFinishStep(reg, c)
+	case FailStepCmd:
+		e.handleFailStep(reg, c)
+	case FinishRunCmd:
+		e.handleFinishRun(reg, c)
+	case FailRunCmd:
+		e.handleFailRun(reg, c)
+	case CancelRunCmd:
+		e.handleCancelRun(reg, c)
+	case GetRunCmd:
+		e.handleGetRun(reg, c)
+	case ListRunsCmd:
+		e.handleListRuns(reg, c)
+	case SubscribeCmd:
+		e.handleSubscribe(reg, c)
+	default:
+		panic(fmt.Sprintf("unknown command type: %T", cmd))
+	}
+}
+
+// submit queues a command for the run loop.
+// It fails fast once Shutdown has begun, and gives up if ctx ends first.
+func (e *Engine) submit(ctx context.Context, cmd Command) error {
+	assert.Not_nil(ctx, "ctx must not be nil")
+
+	select {
+	case <-e.closing:
+		return ErrEngineClosed
+	default:
+	}
+
+	select {
+	case e.cmdCh <- cmd:
+		return nil
+	case <-e.closing:
+		return ErrEngineClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// await waits for the result of a submitted command.
+//
+// If ctx ends first, the command may still run; the caller only stops
+// waiting for it. If the run loop exits first, the command was never
+// handled, unless its result is already buffered.
+func await[T any](ctx context.Context, e *Engine, resultCh <-chan T) (T, error) {
+	var zero T
+	select {
+	case result := <-resultCh:
+		return result, nil
+	case <-e.done:
+		select {
+		case result := <-resultCh:
+			return result, nil
+		default:
+			return zero, ErrEngineClosed
+		}
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	}
+}
+
+// Shutdown stops the engine. New commands are rejected with ErrEngineClosed,
+// commands already queued are handled, and every open event log is closed.
+//
+// Open runs stay non-terminal on disk, so a later OpenEngine recovers them
+// as incomplete. If ctx ends before the drain completes, Shutdown returns
+// ctx.Err() and the drain continues in the background. Calling Shutdown
+// again waits for the same drain and returns the same result.
+func (e *Engine) Shutdown(ctx context.Context) error {
+	assert.Not_nil(ctx, "ctx must not be nil")
+
+	e.closeOnce.Do(func() { close(e.closing) })
+
+	select {
+	case <-e.done:
+		return e.closeErr
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // handleStartRun processes a StartRunCmd.
-func (e *Engine) handleStartRun(runs map[RunID]*RunHandle, cmd StartRunCmd) {
+//
+// The run's log is opened and run.started is written before the handle is
+// inserted, so the registry never holds a run the log does not know about.
+func (e *Engine) handleStartRun(reg *registry, cmd StartRunCmd) {
 	// Precondition assertions (internal invariants)
-	assert.Not_nil(runs, "runs map must not be nil")
+	assert.Not_nil(reg, "registry must not be nil")
 	assert.Not_nil(cmd.ResultCh, "result channel must not be nil")
 
 	// Validate user input before processing
@@ -96,31 +362,82 @@ func (e *Engine) handleStartRun(runs map[RunID]*RunHandle, cmd StartRunCmd) {
 	id, err := generateRunID()
 	assert.No_err(err, "failed to generate run ID")
 
-	if _, exists := runs[id]; exists {
+	if _, exists := reg.runs[id]; exists {
 		// This should be impossible with proper UUID generation
 		panic(fmt.Sprintf("run ID collision: %s", id))
 	}
 
-	runs[id] = &RunHandle{
+	log, err := openStartedRunLog(id, normalizedPath, reg.logOptions)
+	if err != nil {
+		cmd.ResultCh <- StartRunResult{Err: err}
+		return
+	}
+
+	reg.logs[id] = log
+	reg.runs[id] = &RunHandle{
 		ID:            id,
+		LastSeq:       1,
 		Phase:         PhaseDataIngestion,
 		WorkspaceRoot: normalizedPath,
 		Attempts:      make(map[Phase]int),
 		PhaseDone:     make(map[Phase]bool),
+		Steps:         make(map[string]StepState),
 	}
 
 	cmd.ResultCh <- StartRunResult{ID: id}
 }
 
+// openStartedRunLog creates a run's log and writes run.started as its
+// first event (Invariant 2a). If the write fails, the log's segment and
+// directory are removed: they hold no acknowledged event, and an empty log
+// would fail recovery.
+//
+// The lock file is removed too when the run fails to start. Its ID was
+// just generated, so no other process can be waiting on the lock.
+func openStartedRunLog(id RunID, workspaceRoot string, options LogOptions) (*EventLog, error) {
+	assert.Is_true(id != RunID(""), "run ID must not be empty")
+	assert.Not_empty(workspaceRoot, "workspaceRoot must not be empty")
+
+	log, err := OpenEventLog(id, workspaceRoot, options)
+	if err != nil {
+		return nil, errors.Join(err, removeIfExists(lockFilePath(id, workspaceRoot)))
+	}
+
+	if err := log.AppendRunStarted(id, workspaceRoot); err != nil {
+		err = fmt.Errorf("failed to write %s for run %s: %w", EventTypeRunStarted, id, err)
+		// Anything left behind would make the next recovery fail, so
+		// cleanup errors are reported along with the append's.
+		path := log.Path()
+		return nil, errors.Join(err, log.Close(), removeIfExists(path),
+			removeIfExists(filepath.Dir(path)), removeIfExists(lockFilePath(id, workspaceRoot)))
+	}
+	return log, nil
+}
+
+// removeIfExists removes a file or empty directory. A path that does not
+// exist is already removed.
+func removeIfExists(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove %s: %w", path, err)
+	}
+	return nil
+}
+
 // StartRun creates a new run with the given workspace root.
 // Returns an error if the workspace root is not an absolute path.
-func (e *Engine) StartRun(workspaceRoot string) (RunID, error) {
+func (e *Engine) StartRun(ctx context.Context, workspaceRoot string) (RunID, error) {
 	resultCh := make(chan StartRunResult, 1)
-	e.cmdCh <- StartRunCmd{
+	err := e.submit(ctx, StartRunCmd{
 		WorkspaceRoot: workspaceRoot,
 		ResultCh:      resultCh,
+	})
+	if err != nil {
+		return "", err
+	}
+	result, err := await(ctx, e, resultCh)
+	if err != nil {
+		return "", err
 	}
-	result := <-resultCh
 	return result.ID, result.Err
 }
 
@@ -151,6 +468,16 @@ func normalizeWorkspaceRoot(path string) (string, error) {
 // Format: "run-xxxxxxxx-xxxx-4xxx-yxxx-xxxxxxxxxxxx"
 // This replaces the external github.com/google/uuid dependency.
 func generateRunID() (RunID, error) {
+	uuid, err := generateUUID()
+	if err != nil {
+		return "", err
+	}
+	return RunID("run-" + uuid), nil
+}
+
+// generateUUID creates a UUID v4 string using crypto/rand.
+// Format: "xxxxxxxx-xxxx-4xxx-yxxx-xxxxxxxxxxxx"
+func generateUUID() (string, error) {
 	b := make([]byte, 16)
 	_, err := rand.Read(b)
 	if err != nil {
@@ -162,5 +489,5 @@ func generateRunID() (RunID, error) {
 	b[8] = (b[8] & 0x3f) | 0x80 // Variant is 10
 
 	uuid := hex.EncodeToString(b)
-	return RunID("run-" + uuid[:8] + "-" + uuid[8:12] + "-" + uuid[12:16] + "-" + uuid[16:20] + "-" + uuid[20:]), nil
+	return uuid[:8] + "-" + uuid[8:12] + "-" + uuid[12:16] + "-" + uuid[16:20] + "-" + uuid[20:], nil
 }
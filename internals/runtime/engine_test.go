@@ -1,6 +1,7 @@
 package runtime
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"strings"
@@ -27,7 +28,7 @@ func TestInvariant_1_RunIDUniqueness(t *testing.T) {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			id, err := e.StartRun(tempDir)
+			id, err := e.StartRun(context.Background(), tempDir)
 			if err != nil {
 				t.Errorf("Failed to create run: %v", err)
 				return
@@ -127,7 +128,7 @@ func TestInvariant_4a_WorkspaceRootAbsolute(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			id, err := e.StartRun(tt.workspaceRoot)
+			id, err := e.StartRun(context.Background(), tt.workspaceRoot)
 
 			if tt.shouldFail {
 				if err == nil {
@@ -161,7 +162,7 @@ func TestInvariant_4a_WorkspaceRootNormalized(t *testing.T) {
 
 	// Test with redundant slashes - should be normalized to the clean path
 	pathWithSlashes := tempDir + "//"
-	id, err := e.StartRun(pathWithSlashes)
+	id, err := e.StartRun(context.Background(), pathWithSlashes)
 	if err != nil {
 		t.Fatalf("Path with redundant slashes should be normalized: %v", err)
 	}
@@ -182,3 +183,41 @@ func TestNewEngine_CreatesValidEngine(t *testing.T) {
 		t.Fatal("Engine cmdCh is nil")
 	}
 }
+
+// TestStartRun_WritesRunStartedBeforeState verifies StartRun opens the run's
+// event log and writes run.started as its first event, so replaying the log
+// yields the same handle the engine holds.
+func TestStartRun_WritesRunStartedBeforeState(t *testing.T) {
+	e := startEngine(newRegistry(testLogOptions()))
+	workspaceRoot := resolvedTempDir(t)
+
+	id, err := e.StartRun(context.Background(), workspaceRoot)
+	if err != nil {
+		t.Fatalf("StartRun failed: %v", err)
+	}
+
+	events, err := ReadEvents(id, workspaceRoot)
+	if err != nil {
+		t.Fatalf("ReadEvents failed: %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("Expected exactly one event, got %d", len(events))
+	}
+	if events[0] != Event(FormatRunStarted(1, testTime, id, workspaceRoot)) {
+		t.Errorf("Expected run.started with seq 1, got %+v", events[0])
+	}
+}
+
+// TestStartRun_InvalidWorkspaceWritesNoLog verifies a rejected run leaves
+// nothing on disk for recovery to trip over.
+func TestStartRun_InvalidWorkspaceWritesNoLog(t *testing.T) {
+	e := NewEngine()
+	missing := filepath.Join(t.TempDir(), "missing")
+
+	if _, err := e.StartRun(context.Background(), missing); err == nil {
+		t.Fatal("Expected error for nonexistent workspace")
+	}
+	if _, err := os.Stat(logDirPath(missing)); !os.IsNotExist(err) {
+		t.Errorf("Expected no log directory for rejected run, got err=%v", err)
+	}
+}
@@ -1,9 +1,17 @@
 package runtime
 
+import "time"
+
 // Event is the interface for all events that can be written to the event log.
 // The event() method is a marker to ensure only valid event types are used.
 // This is a common Go pattern for creating "sealed" interfaces - only types
 // in this package can implement Event by implementing the event() method.
+//
+// Every event carries a Seq and a Timestamp, both assigned by the log's
+// writer goroutine. Timestamp is wall-clock time in UTC from the log's
+// Clock; events written before timestamps existed decode with the zero time.
+// SchemaVersion is the format the event was written in. Decoding upcasts
+// older events, so a decoded event is always at SchemaVersionCurrent.
 type Event interface {
 	event() // Marker method - unexported so only this package can implement
 }
@@ -35,6 +43,14 @@ const (
 
 	// Artifact events
 	EventTypeArtifactCreated EventType = "artifact.created"
+
+	// Trading events
+	EventTypeSignalGenerated   EventType = "signal.generated"
+	EventTypeOrderSubmitted    EventType = "order.submitted"
+	EventTypeOrderAcknowledged EventType = "order.acknowledged"
+	EventTypeOrderFilled       EventType = "order.filled"
+	EventTypeOrderCancelled    EventType = "order.cancelled"
+	EventTypeOrderRejected     EventType = "order.rejected"
 )
 
 // RunStartedEvent is emitted when a new run begins.
@@ -43,125 +59,238 @@ type RunStartedEvent struct {
 	RunID         RunID     `json:"run_id"`
 	WorkspaceRoot string    `json:"workspace_root"`
 	Seq           int64     `json:"seq"`
+	Timestamp     time.Time `json:"timestamp"`
 	Type          EventType `json:"type"`
+	SchemaVersion int       `json:"schema_version"`
 }
 
 func (RunStartedEvent) event() {}
 
 // RunFinishedEvent is emitted when a run completes successfully.
 type RunFinishedEvent struct {
-	RunID RunID     `json:"run_id"`
-	Seq   int64     `json:"seq"`
-	Type  EventType `json:"type"`
+	RunID         RunID     `json:"run_id"`
+	Seq           int64     `json:"seq"`
+	Timestamp     time.Time `json:"timestamp"`
+	Type          EventType `json:"type"`
+	SchemaVersion int       `json:"schema_version"`
 }
 
 func (RunFinishedEvent) event() {}
 
 // RunFailedEvent is emitted when a run fails.
 type RunFailedEvent struct {
-	RunID  RunID     `json:"run_id"`
-	Reason string    `json:"reason"`
-	Seq    int64     `json:"seq"`
-	Type   EventType `json:"type"`
+	RunID         RunID     `json:"run_id"`
+	Reason        string    `json:"reason"`
+	Seq           int64     `json:"seq"`
+	Timestamp     time.Time `json:"timestamp"`
+	Type          EventType `json:"type"`
+	SchemaVersion int       `json:"schema_version"`
 }
 
 func (RunFailedEvent) event() {}
 
 // StepStartedEvent is emitted when a step begins.
 type StepStartedEvent struct {
-	RunID  RunID     `json:"run_id"`
-	StepID string    `json:"step_id"`
-	Phase  Phase     `json:"phase"`
-	Seq    int64     `json:"seq"`
-	Type   EventType `json:"type"`
+	RunID         RunID     `json:"run_id"`
+	StepID        string    `json:"step_id"`
+	Phase         Phase     `json:"phase"`
+	Seq           int64     `json:"seq"`
+	Timestamp     time.Time `json:"timestamp"`
+	Type          EventType `json:"type"`
+	SchemaVersion int       `json:"schema_version"`
 }
 
 func (StepStartedEvent) event() {}
 
 // StepFinishedEvent is emitted when a step completes successfully.
 type StepFinishedEvent struct {
-	RunID  RunID     `json:"run_id"`
-	StepID string    `json:"step_id"`
-	Phase  Phase     `json:"phase"`
-	Seq    int64     `json:"seq"`
-	Type   EventType `json:"type"`
+	RunID         RunID     `json:"run_id"`
+	StepID        string    `json:"step_id"`
+	Phase         Phase     `json:"phase"`
+	Seq           int64     `json:"seq"`
+	Timestamp     time.Time `json:"timestamp"`
+	Type          EventType `json:"type"`
+	SchemaVersion int       `json:"schema_version"`
 }
 
 func (StepFinishedEvent) event() {}
 
 // StepFailedEvent is emitted when a step fails.
 type StepFailedEvent struct {
-	RunID  RunID     `json:"run_id"`
-	StepID string    `json:"step_id"`
-	Phase  Phase     `json:"phase"`
-	Reason string    `json:"reason"`
-	Seq    int64     `json:"seq"`
-	Type   EventType `json:"type"`
+	RunID         RunID     `json:"run_id"`
+	StepID        string    `json:"step_id"`
+	Phase         Phase     `json:"phase"`
+	Reason        string    `json:"reason"`
+	Seq           int64     `json:"seq"`
+	Timestamp     time.Time `json:"timestamp"`
+	Type          EventType `json:"type"`
+	SchemaVersion int       `json:"schema_version"`
 }
 
 func (StepFailedEvent) event() {}
 
 // LLMRequestedEvent is emitted when an LLM call is requested.
+// The embedded payload's fields are logged at the top level of the event.
 type LLMRequestedEvent struct {
-	RunID  RunID     `json:"run_id"`
-	StepID string    `json:"step_id"`
-	Seq    int64     `json:"seq"`
-	Type   EventType `json:"type"`
+	RunID  RunID  `json:"run_id"`
+	StepID string `json:"step_id"`
+	LLMRequestPayload
+	Seq           int64     `json:"seq"`
+	Timestamp     time.Time `json:"timestamp"`
+	Type          EventType `json:"type"`
+	SchemaVersion int       `json:"schema_version"`
 }
 
 func (LLMRequestedEvent) event() {}
 
 // LLMRespondedEvent is emitted when an LLM call completes.
+// RequestID links it to the llm.requested event of the same call.
 type LLMRespondedEvent struct {
-	RunID  RunID     `json:"run_id"`
-	StepID string    `json:"step_id"`
-	Seq    int64     `json:"seq"`
-	Type   EventType `json:"type"`
+	RunID  RunID  `json:"run_id"`
+	StepID string `json:"step_id"`
+	LLMResponsePayload
+	Seq           int64     `json:"seq"`
+	Timestamp     time.Time `json:"timestamp"`
+	Type          EventType `json:"type"`
+	SchemaVersion int       `json:"schema_version"`
 }
 
 func (LLMRespondedEvent) event() {}
 
 // ToolCalledEvent is emitted when a tool is invoked.
+// The embedded payload's fields are logged at the top level of the event.
 type ToolCalledEvent struct {
-	RunID    RunID     `json:"run_id"`
-	StepID   string    `json:"step_id"`
-	ToolName string    `json:"tool_name"`
-	Seq      int64     `json:"seq"`
-	Type     EventType `json:"type"`
+	RunID  RunID  `json:"run_id"`
+	StepID string `json:"step_id"`
+	ToolCallPayload
+	Seq           int64     `json:"seq"`
+	Timestamp     time.Time `json:"timestamp"`
+	Type          EventType `json:"type"`
+	SchemaVersion int       `json:"schema_version"`
 }
 
 func (ToolCalledEvent) event() {}
 
 // ToolReturnedEvent is emitted when a tool call completes successfully.
+// CallID links it to the tool.called event of the same call.
 type ToolReturnedEvent struct {
-	RunID    RunID     `json:"run_id"`
-	StepID   string    `json:"step_id"`
-	ToolName string    `json:"tool_name"`
-	Seq      int64     `json:"seq"`
-	Type     EventType `json:"type"`
+	RunID  RunID  `json:"run_id"`
+	StepID string `json:"step_id"`
+	ToolResultPayload
+	Seq           int64     `json:"seq"`
+	Timestamp     time.Time `json:"timestamp"`
+	Type          EventType `json:"type"`
+	SchemaVersion int       `json:"schema_version"`
 }
 
 func (ToolReturnedEvent) event() {}
 
 // ToolFailedEvent is emitted when a tool call fails.
+// CallID links it to the tool.called event of the same call.
 type ToolFailedEvent struct {
-	RunID    RunID     `json:"run_id"`
-	StepID   string    `json:"step_id"`
-	ToolName string    `json:"tool_name"`
-	Reason   string    `json:"reason"`
-	Seq      int64     `json:"seq"`
-	Type     EventType `json:"type"`
+	RunID  RunID  `json:"run_id"`
+	StepID string `json:"step_id"`
+	ToolFailurePayload
+	Seq           int64     `json:"seq"`
+	Timestamp     time.Time `json:"timestamp"`
+	Type          EventType `json:"type"`
+	SchemaVersion int       `json:"schema_version"`
 }
 
 func (ToolFailedEvent) event() {}
 
 // ArtifactCreatedEvent is emitted when an artifact is created.
+// The embedded artifact's fields are logged at the top level of the event.
 type ArtifactCreatedEvent struct {
-	RunID  RunID     `json:"run_id"`
-	StepID string    `json:"step_id"`
-	Path   string    `json:"path"`
-	Seq    int64     `json:"seq"`
-	Type   EventType `json:"type"`
+	RunID  RunID  `json:"run_id"`
+	StepID string `json:"step_id"`
+	Artifact
+	Seq           int64     `json:"seq"`
+	Timestamp     time.Time `json:"timestamp"`
+	Type          EventType `json:"type"`
+	SchemaVersion int       `json:"schema_version"`
 }
 
 func (ArtifactCreatedEvent) event() {}
+
+// SignalGeneratedEvent is emitted when a strategy generates a signal.
+// The embedded signal's fields are logged at the top level of the event.
+type SignalGeneratedEvent struct {
+	RunID  RunID  `json:"run_id"`
+	StepID string `json:"step_id"`
+	Signal
+	Seq           int64     `json:"seq"`
+	Timestamp     time.Time `json:"timestamp"`
+	Type          EventType `json:"type"`
+	SchemaVersion int       `json:"schema_version"`
+}
+
+func (SignalGeneratedEvent) event() {}
+
+// OrderSubmittedEvent is emitted when an order is submitted to the broker.
+// The embedded order's fields are logged at the top level of the event.
+type OrderSubmittedEvent struct {
+	RunID  RunID  `json:"run_id"`
+	StepID string `json:"step_id"`
+	Order
+	Seq           int64     `json:"seq"`
+	Timestamp     time.Time `json:"timestamp"`
+	Type          EventType `json:"type"`
+	SchemaVersion int       `json:"schema_version"`
+}
+
+func (OrderSubmittedEvent) event() {}
+
+// OrderAcknowledgedEvent is emitted when the broker accepts an order.
+// OrderID links it, and every later order event, to order.submitted.
+type OrderAcknowledgedEvent struct {
+	RunID  RunID  `json:"run_id"`
+	StepID string `json:"step_id"`
+	OrderAck
+	Seq           int64     `json:"seq"`
+	Timestamp     time.Time `json:"timestamp"`
+	Type          EventType `json:"type"`
+	SchemaVersion int       `json:"schema_version"`
+}
+
+func (OrderAcknowledgedEvent) event() {}
+
+// OrderFilledEvent is emitted when an order is filled, in whole or in part.
+// An order filled in parts has one event per execution.
+type OrderFilledEvent struct {
+	RunID  RunID  `json:"run_id"`
+	StepID string `json:"step_id"`
+	Fill
+	Seq           int64     `json:"seq"`
+	Timestamp     time.Time `json:"timestamp"`
+	Type          EventType `json:"type"`
+	SchemaVersion int       `json:"schema_version"`
+}
+
+func (OrderFilledEvent) event() {}
+
+// OrderCancelledEvent is emitted when an order is cancelled.
+type OrderCancelledEvent struct {
+	RunID  RunID  `json:"run_id"`
+	StepID string `json:"step_id"`
+	OrderCancel
+	Seq           int64     `json:"seq"`
+	Timestamp     time.Time `json:"timestamp"`
+	Type          EventType `json:"type"`
+	SchemaVersion int       `json:"schema_version"`
+}
+
+func (OrderCancelledEvent) event() {}
+
+// OrderRejectedEvent is emitted when an order is rejected.
+type OrderRejectedEvent struct {
+	RunID  RunID  `json:"run_id"`
+	StepID string `json:"step_id"`
+	OrderReject
+	Seq           int64     `json:"seq"`
+	Timestamp     time.Time `json:"timestamp"`
+	Type          EventType `json:"type"`
+	SchemaVersion int       `json:"schema_version"`
+}
+
+func (OrderRejectedEvent) event() {}
@@ -1,17 +1,24 @@
 package runtime
 
-import "aiplatform/pkg/assert"
+import (
+	"time"
+
+	"aiplatform/pkg/assert"
+)
 
 // Formatter is the single, authoritative source for creating fully-formed events.
 // It is the only place allowed to set event Type fields.
-// The writer goroutine assigns Seq before calling formatter functions.
+// The writer goroutine assigns Seq and Timestamp before calling formatter
+// functions.
 //
 // Tiger Beetle Principle: Single source of truth for event formation.
 
 // FormatRunStarted creates a fully-formed RunStartedEvent.
-// The caller (writer goroutine) must provide the seq.
-func FormatRunStarted(seq int64, runID RunID, workspaceRoot string) RunStartedEvent {
+// The caller (writer goroutine) must provide the seq and timestamp.
+func FormatRunStarted(seq int64, timestamp time.Time, runID RunID,
+	workspaceRoot string) RunStartedEvent {
 	assert.Gt(seq, int64(0), "seq must be positive")
+	assert.Is_true(!timestamp.IsZero(), "timestamp must be set")
 	assert.Is_true(runID != RunID(""), "runID must not be empty")
 	assert.Not_empty(workspaceRoot, "workspaceRoot must not be empty")
 
@@ -19,176 +26,373 @@ func FormatRunStarted(seq int64, runID RunID, workspaceRoot string) RunStartedEv
 		RunID:         runID,
 		WorkspaceRoot: workspaceRoot,
 		Seq:           seq,
+		Timestamp:     timestamp,
 		Type:          EventTypeRunStarted,
+		SchemaVersion: SchemaVersionCurrent,
 	}
 }
 
 // FormatRunFinished creates a fully-formed RunFinishedEvent.
-func FormatRunFinished(seq int64, runID RunID) RunFinishedEvent {
+func FormatRunFinished(seq int64, timestamp time.Time, runID RunID) RunFinishedEvent {
 	assert.Gt(seq, int64(0), "seq must be positive")
+	assert.Is_true(!timestamp.IsZero(), "timestamp must be set")
 	assert.Is_true(runID != RunID(""), "runID must not be empty")
 
 	return RunFinishedEvent{
-		RunID: runID,
-		Seq:   seq,
-		Type:  EventTypeRunFinished,
+		RunID:         runID,
+		Seq:           seq,
+		Timestamp:     timestamp,
+		Type:          EventTypeRunFinished,
+		SchemaVersion: SchemaVersionCurrent,
 	}
 }
 
 // FormatRunFailed creates a fully-formed RunFailedEvent.
-func FormatRunFailed(seq int64, runID RunID, reason string) RunFailedEvent {
+func FormatRunFailed(seq int64, timestamp time.Time, runID RunID, reason string) RunFailedEvent {
 	assert.Gt(seq, int64(0), "seq must be positive")
+	assert.Is_true(!timestamp.IsZero(), "timestamp must be set")
 	assert.Is_true(runID != RunID(""), "runID must not be empty")
 	assert.Not_empty(reason, "reason must not be empty")
 
 	return RunFailedEvent{
-		RunID:  runID,
-		Reason: reason,
-		Seq:    seq,
-		Type:   EventTypeRunFailed,
+		RunID:         runID,
+		Reason:        reason,
+		Seq:           seq,
+		Timestamp:     timestamp,
+		Type:          EventTypeRunFailed,
+		SchemaVersion: SchemaVersionCurrent,
 	}
 }
 
 // FormatStepStarted creates a fully-formed StepStartedEvent.
-func FormatStepStarted(seq int64, runID RunID, stepID string, phase Phase) StepStartedEvent {
+func FormatStepStarted(seq int64, timestamp time.Time, runID RunID, stepID string,
+	phase Phase) StepStartedEvent {
 	assert.Gt(seq, int64(0), "seq must be positive")
+	assert.Is_true(!timestamp.IsZero(), "timestamp must be set")
 	assert.Is_true(runID != RunID(""), "runID must not be empty")
 	assert.Not_empty(stepID, "stepID must not be empty")
 	assert.Is_true(phase.IsValid(), "phase must be valid")
 
 	return StepStartedEvent{
-		RunID:  runID,
-		StepID: stepID,
-		Phase:  phase,
-		Seq:    seq,
-		Type:   EventTypeStepStarted,
+		RunID:         runID,
+		StepID:        stepID,
+		Phase:         phase,
+		Seq:           seq,
+		Timestamp:     timestamp,
+		Type:          EventTypeStepStarted,
+		SchemaVersion: SchemaVersionCurrent,
 	}
 }
 
 // FormatStepFinished creates a fully-formed StepFinishedEvent.
-func FormatStepFinished(seq int64, runID RunID, stepID string, phase Phase) StepFinishedEvent {
+func FormatStepFinished(seq int64, timestamp time.Time, runID RunID, stepID string,
+	phase Phase) StepFinishedEvent {
 	assert.Gt(seq, int64(0), "seq must be positive")
+	assert.Is_true(!timestamp.IsZero(), "timestamp must be set")
 	assert.Is_true(runID != RunID(""), "runID must not be empty")
 	assert.Not_empty(stepID, "stepID must not be empty")
 	assert.Is_true(phase.IsValid(), "phase must be valid")
 
 	return StepFinishedEvent{
-		RunID:  runID,
-		StepID: stepID,
-		Phase:  phase,
-		Seq:    seq,
-		Type:   EventTypeStepFinished,
+		RunID:         runID,
+		StepID:        stepID,
+		Phase:         phase,
+		Seq:           seq,
+		Timestamp:     timestamp,
+		Type:          EventTypeStepFinished,
+		SchemaVersion: SchemaVersionCurrent,
 	}
 }
 
 // FormatStepFailed creates a fully-formed StepFailedEvent.
-func FormatStepFailed(seq int64, runID RunID, stepID string, phase Phase, reason string) StepFailedEvent {
+func FormatStepFailed(seq int64, timestamp time.Time, runID RunID, stepID string,
+	phase Phase, reason string) StepFailedEvent {
 	assert.Gt(seq, int64(0), "seq must be positive")
+	assert.Is_true(!timestamp.IsZero(), "timestamp must be set")
 	assert.Is_true(runID != RunID(""), "runID must not be empty")
 	assert.Not_empty(stepID, "stepID must not be empty")
 	assert.Is_true(phase.IsValid(), "phase must be valid")
 	assert.Not_empty(reason, "reason must not be empty")
 
 	return StepFailedEvent{
-		RunID:  runID,
-		StepID: stepID,
-		Phase:  phase,
-		Reason: reason,
-		Seq:    seq,
-		Type:   EventTypeStepFailed,
+		RunID:         runID,
+		StepID:        stepID,
+		Phase:         phase,
+		Reason:        reason,
+		Seq:           seq,
+		Timestamp:     timestamp,
+		Type:          EventTypeStepFailed,
+		SchemaVersion: SchemaVersionCurrent,
 	}
 }
 
 // FormatLLMRequested creates a fully-formed LLMRequestedEvent.
-func FormatLLMRequested(seq int64, runID RunID, stepID string) LLMRequestedEvent {
+func FormatLLMRequested(seq int64, timestamp time.Time, runID RunID, stepID string,
+	payload LLMRequestPayload) LLMRequestedEvent {
 	assert.Gt(seq, int64(0), "seq must be positive")
+	assert.Is_true(!timestamp.IsZero(), "timestamp must be set")
 	assert.Is_true(runID != RunID(""), "runID must not be empty")
 	assert.Not_empty(stepID, "stepID must not be empty")
+	assert.Not_empty(payload.RequestID, "request ID must not be empty")
+	assert.Is_true(payload.Provider.IsValid(), "provider must be valid")
+	assert.Not_empty(payload.Model, "model must not be empty")
+	assert.Is_true(payload.Prompt.IsSet(), "prompt must be recorded")
 
 	return LLMRequestedEvent{
-		RunID:  runID,
-		StepID: stepID,
-		Seq:    seq,
-		Type:   EventTypeLLMRequested,
+		RunID:             runID,
+		StepID:            stepID,
+		LLMRequestPayload: payload,
+		Seq:               seq,
+		Timestamp:         timestamp,
+		Type:              EventTypeLLMRequested,
+		SchemaVersion:     SchemaVersionCurrent,
 	}
 }
 
 // FormatLLMResponded creates a fully-formed LLMRespondedEvent.
-func FormatLLMResponded(seq int64, runID RunID, stepID string) LLMRespondedEvent {
+func FormatLLMResponded(seq int64, timestamp time.Time, runID RunID, stepID string,
+	payload LLMResponsePayload) LLMRespondedEvent {
 	assert.Gt(seq, int64(0), "seq must be positive")
+	assert.Is_true(!timestamp.IsZero(), "timestamp must be set")
 	assert.Is_true(runID != RunID(""), "runID must not be empty")
 	assert.Not_empty(stepID, "stepID must not be empty")
+	assert.Not_empty(payload.RequestID, "request ID must not be empty")
+	assert.Is_true(payload.Response.IsSet(), "response must be recorded")
 
 	return LLMRespondedEvent{
-		RunID:  runID,
-		StepID: stepID,
-		Seq:    seq,
-		Type:   EventTypeLLMResponded,
+		RunID:              runID,
+		StepID:             stepID,
+		LLMResponsePayload: payload,
+		Seq:                seq,
+		Timestamp:          timestamp,
+		Type:               EventTypeLLMResponded,
+		SchemaVersion:      SchemaVersionCurrent,
 	}
 }
 
 // FormatToolCalled creates a fully-formed ToolCalledEvent.
-func FormatToolCalled(seq int64, runID RunID, stepID string, toolName string) ToolCalledEvent {
+func FormatToolCalled(seq int64, timestamp time.Time, runID RunID, stepID string,
+	payload ToolCallPayload) ToolCalledEvent {
 	assert.Gt(seq, int64(0), "seq must be positive")
+	assert.Is_true(!timestamp.IsZero(), "timestamp must be set")
 	assert.Is_true(runID != RunID(""), "runID must not be empty")
 	assert.Not_empty(stepID, "stepID must not be empty")
-	assert.Not_empty(toolName, "toolName must not be empty")
+	assert.Not_empty(payload.CallID, "call ID must not be empty")
+	assert.Not_empty(payload.ToolName, "toolName must not be empty")
+	assert.Is_true(payload.Arguments.IsSet(), "arguments must be recorded")
 
 	return ToolCalledEvent{
-		RunID:    runID,
-		StepID:   stepID,
-		ToolName: toolName,
-		Seq:      seq,
-		Type:     EventTypeToolCalled,
+		RunID:           runID,
+		StepID:          stepID,
+		ToolCallPayload: payload,
+		Seq:             seq,
+		Timestamp:       timestamp,
+		Type:            EventTypeToolCalled,
+		SchemaVersion:   SchemaVersionCurrent,
 	}
 }
 
 // FormatToolReturned creates a fully-formed ToolReturnedEvent.
-func FormatToolReturned(seq int64, runID RunID, stepID string, toolName string) ToolReturnedEvent {
+func FormatToolReturned(seq int64, timestamp time.Time, runID RunID, stepID string,
+	payload ToolResultPayload) ToolReturnedEvent {
 	assert.Gt(seq, int64(0), "seq must be positive")
+	assert.Is_true(!timestamp.IsZero(), "timestamp must be set")
 	assert.Is_true(runID != RunID(""), "runID must not be empty")
 	assert.Not_empty(stepID, "stepID must not be empty")
-	assert.Not_empty(toolName, "toolName must not be empty")
+	assert.Not_empty(payload.CallID, "call ID must not be empty")
+	assert.Not_empty(payload.ToolName, "toolName must not be empty")
+	assert.Is_true(payload.Result.IsSet(), "result must be recorded")
 
 	return ToolReturnedEvent{
-		RunID:    runID,
-		StepID:   stepID,
-		ToolName: toolName,
-		Seq:      seq,
-		Type:     EventTypeToolReturned,
+		RunID:             runID,
+		StepID:            stepID,
+		ToolResultPayload: payload,
+		Seq:               seq,
+		Timestamp:         timestamp,
+		Type:              EventTypeToolReturned,
+		SchemaVersion:     SchemaVersionCurrent,
 	}
 }
 
 // FormatToolFailed creates a fully-formed ToolFailedEvent.
-func FormatToolFailed(seq int64, runID RunID, stepID string, toolName string, reason string) ToolFailedEvent {
+func FormatToolFailed(seq int64, timestamp time.Time, runID RunID, stepID string,
+	payload ToolFailurePayload) ToolFailedEvent {
 	assert.Gt(seq, int64(0), "seq must be positive")
+	assert.Is_true(!timestamp.IsZero(), "timestamp must be set")
 	assert.Is_true(runID != RunID(""), "runID must not be empty")
 	assert.Not_empty(stepID, "stepID must not be empty")
-	assert.Not_empty(toolName, "toolName must not be empty")
-	assert.Not_empty(reason, "reason must not be empty")
+	assert.Not_empty(payload.CallID, "call ID must not be empty")
+	assert.Not_empty(payload.ToolName, "toolName must not be empty")
+	assert.Not_empty(payload.Reason, "reason must not be empty")
 
 	return ToolFailedEvent{
-		RunID:    runID,
-		StepID:   stepID,
-		ToolName: toolName,
-		Reason:   reason,
-		Seq:      seq,
-		Type:     EventTypeToolFailed,
+		RunID:              runID,
+		StepID:             stepID,
+		ToolFailurePayload: payload,
+		Seq:                seq,
+		Timestamp:          timestamp,
+		Type:               EventTypeToolFailed,
+		SchemaVersion:      SchemaVersionCurrent,
 	}
 }
 
 // FormatArtifactCreated creates a fully-formed ArtifactCreatedEvent.
-func FormatArtifactCreated(seq int64, runID RunID, stepID string, path string) ArtifactCreatedEvent {
+func FormatArtifactCreated(seq int64, timestamp time.Time, runID RunID, stepID string,
+	artifact Artifact) ArtifactCreatedEvent {
 	assert.Gt(seq, int64(0), "seq must be positive")
+	assert.Is_true(!timestamp.IsZero(), "timestamp must be set")
 	assert.Is_true(runID != RunID(""), "runID must not be empty")
 	assert.Not_empty(stepID, "stepID must not be empty")
-	assert.Not_empty(path, "path must not be empty")
+	assert.Not_empty(artifact.Path, "path must not be empty")
+	assert.Not_empty(artifact.SHA256, "artifact hash must not be empty")
+	assert.Not_empty(artifact.MediaType, "media type must not be empty")
 
 	return ArtifactCreatedEvent{
-		RunID:  runID,
-		StepID: stepID,
-		Path:   path,
-		Seq:    seq,
-		Type:   EventTypeArtifactCreated,
+		RunID:         runID,
+		StepID:        stepID,
+		Artifact:      artifact,
+		Seq:           seq,
+		Timestamp:     timestamp,
+		Type:          EventTypeArtifactCreated,
+		SchemaVersion: SchemaVersionCurrent,
+	}
+}
+
+// FormatSignalGenerated creates a fully-formed SignalGeneratedEvent.
+func FormatSignalGenerated(seq int64, timestamp time.Time, runID RunID, stepID string,
+	signal Signal) SignalGeneratedEvent {
+	assert.Gt(seq, int64(0), "seq must be positive")
+	assert.Is_true(!timestamp.IsZero(), "timestamp must be set")
+	assert.Is_true(runID != RunID(""), "runID must not be empty")
+	assert.Not_empty(stepID, "stepID must not be empty")
+	assert.Not_empty(signal.SignalID, "signal ID must not be empty")
+	assert.Not_empty(signal.StrategyID, "strategy ID must not be empty")
+	assert.Not_empty(signal.Symbol, "symbol must not be empty")
+	assert.Is_true(signal.Direction.IsValid(), "signal direction must be valid (T42)")
+	assert.Is_true(signal.hasValidConfidence(), "confidence must be in [0, 1] (T43)")
+
+	return SignalGeneratedEvent{
+		RunID:         runID,
+		StepID:        stepID,
+		Signal:        signal,
+		Seq:           seq,
+		Timestamp:     timestamp,
+		Type:          EventTypeSignalGenerated,
+		SchemaVersion: SchemaVersionCurrent,
+	}
+}
+
+// FormatOrderSubmitted creates a fully-formed OrderSubmittedEvent.
+func FormatOrderSubmitted(seq int64, timestamp time.Time, runID RunID, stepID string,
+	order Order) OrderSubmittedEvent {
+	assert.Gt(seq, int64(0), "seq must be positive")
+	assert.Is_true(!timestamp.IsZero(), "timestamp must be set")
+	assert.Is_true(runID != RunID(""), "runID must not be empty")
+	assert.Not_empty(stepID, "stepID must not be empty")
+	assert.Not_empty(order.OrderID, "order ID must not be empty")
+	assert.Not_empty(order.StrategyID, "strategy ID must not be empty")
+	assert.Not_empty(order.Symbol, "symbol must not be empty")
+	assert.Is_true(order.Side.IsValid(), "order side must be valid (T14)")
+	assert.Is_true(order.OrderType.IsValid(), "order type must be valid (T15)")
+	assert.Is_true(order.hasPricesForType(), "order prices must match its type (T15)")
+	assert.Gt(order.Quantity, int64(0), "quantity must be positive (T16)")
+	assert.Is_true(order.TimeInForce.IsValid(), "time in force must be valid (T17)")
+
+	return OrderSubmittedEvent{
+		RunID:         runID,
+		StepID:        stepID,
+		Order:         order,
+		Seq:           seq,
+		Timestamp:     timestamp,
+		Type:          EventTypeOrderSubmitted,
+		SchemaVersion: SchemaVersionCurrent,
+	}
+}
+
+// FormatOrderAcknowledged creates a fully-formed OrderAcknowledgedEvent.
+func FormatOrderAcknowledged(seq int64, timestamp time.Time, runID RunID, stepID string,
+	ack OrderAck) OrderAcknowledgedEvent {
+	assert.Gt(seq, int64(0), "seq must be positive")
+	assert.Is_true(!timestamp.IsZero(), "timestamp must be set")
+	assert.Is_true(runID != RunID(""), "runID must not be empty")
+	assert.Not_empty(stepID, "stepID must not be empty")
+	assert.Not_empty(ack.OrderID, "order ID must not be empty")
+	assert.Not_empty(ack.BrokerOrderID, "broker order ID must not be empty")
+
+	return OrderAcknowledgedEvent{
+		RunID:         runID,
+		StepID:        stepID,
+		OrderAck:      ack,
+		Seq:           seq,
+		Timestamp:     timestamp,
+		Type:          EventTypeOrderAcknowledged,
+		SchemaVersion: SchemaVersionCurrent,
+	}
+}
+
+// FormatOrderFilled creates a fully-formed OrderFilledEvent.
+func FormatOrderFilled(seq int64, timestamp time.Time, runID RunID, stepID string,
+	fill Fill) OrderFilledEvent {
+	assert.Gt(seq, int64(0), "seq must be positive")
+	assert.Is_true(!timestamp.IsZero(), "timestamp must be set")
+	assert.Is_true(runID != RunID(""), "runID must not be empty")
+	assert.Not_empty(stepID, "stepID must not be empty")
+	assert.Not_empty(fill.OrderID, "order ID must not be empty")
+	assert.Not_empty(fill.FillID, "fill ID must not be empty")
+	assert.Gt(fill.Quantity, int64(0), "fill quantity must be positive (T16)")
+	assert.Gt(fill.PriceMicros, int64(0), "fill price must be positive (T63)")
+	assert.Is_true(fill.CommissionMicros >= 0, "commission must not be negative (T63)")
+	assert.Is_true(!fill.FillTime.IsZero(), "fill time must be set (T63)")
+
+	return OrderFilledEvent{
+		RunID:         runID,
+		StepID:        stepID,
+		Fill:          fill,
+		Seq:           seq,
+		Timestamp:     timestamp,
+		Type:          EventTypeOrderFilled,
+		SchemaVersion: SchemaVersionCurrent,
+	}
+}
+
+// FormatOrderCancelled creates a fully-formed OrderCancelledEvent.
+func FormatOrderCancelled(seq int64, timestamp time.Time, runID RunID, stepID string,
+	cancel OrderCancel) OrderCancelledEvent {
+	assert.Gt(seq, int64(0), "seq must be positive")
+	assert.Is_true(!timestamp.IsZero(), "timestamp must be set")
+	assert.Is_true(runID != RunID(""), "runID must not be empty")
+	assert.Not_empty(stepID, "stepID must not be empty")
+	assert.Not_empty(cancel.OrderID, "order ID must not be empty")
+	assert.Not_empty(cancel.Reason, "reason must not be empty")
+
+	return OrderCancelledEvent{
+		RunID:         runID,
+		StepID:        stepID,
+		OrderCancel:   cancel,
+		Seq:           seq,
+		Timestamp:     timestamp,
+		Type:          EventTypeOrderCancelled,
+		SchemaVersion: SchemaVersionCurrent,
+	}
+}
+
+// FormatOrderRejected creates a fully-formed OrderRejectedEvent.
+func FormatOrderRejected(seq int64, timestamp time.Time, runID RunID, stepID string,
+	reject OrderReject) OrderRejectedEvent {
+	assert.Gt(seq, int64(0), "seq must be positive")
+	assert.Is_true(!timestamp.IsZero(), "timestamp must be set")
+	assert.Is_true(runID != RunID(""), "runID must not be empty")
+	assert.Not_empty(stepID, "stepID must not be empty")
+	assert.Not_empty(reject.OrderID, "order ID must not be empty")
+	assert.Not_empty(reject.Reason, "reason must not be empty")
+
+	return OrderRejectedEvent{
+		RunID:         runID,
+		StepID:        stepID,
+		OrderReject:   reject,
+		Seq:           seq,
+		Timestamp:     timestamp,
+		Type:          EventTypeOrderRejected,
+		SchemaVersion: SchemaVersionCurrent,
 	}
 }
@@ -12,7 +12,7 @@ func TestFormatter_RunStarted(t *testing.T) {
 	workspaceRoot := "/tmp/workspace"
 	seq := int64(42)
 
-	event := FormatRunStarted(seq, runID, workspaceRoot)
+	event := FormatRunStarted(seq, testTime, runID, workspaceRoot)
 
 	assert.Equal(t, EventTypeRunStarted, event.Type)
 	assert.Equal(t, seq, event.Seq)
@@ -25,7 +25,7 @@ func TestFormatter_RunFinished(t *testing.T) {
 	runID := RunID("test-run")
 	seq := int64(43)
 
-	event := FormatRunFinished(seq, runID)
+	event := FormatRunFinished(seq, testTime, runID)
 
 	assert.Equal(t, EventTypeRunFinished, event.Type)
 	assert.Equal(t, seq, event.Seq)
@@ -38,7 +38,7 @@ func TestFormatter_RunFailed(t *testing.T) {
 	reason := "test failure"
 	seq := int64(44)
 
-	event := FormatRunFailed(seq, runID, reason)
+	event := FormatRunFailed(seq, testTime, runID, reason)
 
 	assert.Equal(t, EventTypeRunFailed, event.Type)
 	assert.Equal(t, seq, event.Seq)
@@ -53,7 +53,7 @@ func TestFormatter_StepStarted(t *testing.T) {
 	phase := PhaseDataIngestion
 	seq := int64(45)
 
-	event := FormatStepStarted(seq, runID, stepID, phase)
+	event := FormatStepStarted(seq, testTime, runID, stepID, phase)
 
 	assert.Equal(t, EventTypeStepStarted, event.Type)
 	assert.Equal(t, seq, event.Seq)
@@ -69,7 +69,7 @@ func TestFormatter_StepFinished(t *testing.T) {
 	phase := PhaseDataIngestion
 	seq := int64(46)
 
-	event := FormatStepFinished(seq, runID, stepID, phase)
+	event := FormatStepFinished(seq, testTime, runID, stepID, phase)
 
 	assert.Equal(t, EventTypeStepFinished, event.Type)
 	assert.Equal(t, seq, event.Seq)
@@ -86,7 +86,7 @@ func TestFormatter_StepFailed(t *testing.T) {
 	reason := "step error"
 	seq := int64(47)
 
-	event := FormatStepFailed(seq, runID, stepID, phase, reason)
+	event := FormatStepFailed(seq, testTime, runID, stepID, phase, reason)
 
 	assert.Equal(t, EventTypeStepFailed, event.Type)
 	assert.Equal(t, seq, event.Seq)
@@ -101,13 +101,15 @@ func TestFormatter_LLMRequested(t *testing.T) {
 	runID := RunID("test-run")
 	stepID := "step-1"
 	seq := int64(48)
+	payload := testLLMRequestPayload("call-1")
 
-	event := FormatLLMRequested(seq, runID, stepID)
+	event := FormatLLMRequested(seq, testTime, runID, stepID, payload)
 
 	assert.Equal(t, EventTypeLLMRequested, event.Type)
 	assert.Equal(t, seq, event.Seq)
 	assert.Equal(t, runID, event.RunID)
 	assert.Equal(t, stepID, event.StepID)
+	assert.Equal(t, payload, event.LLMRequestPayload)
 }
 
 // TestFormatter_LLMResponded verifies FormatLLMResponded sets correct Type and Seq
@@ -115,77 +117,173 @@ func TestFormatter_LLMResponded(t *testing.T) {
 	runID := RunID("test-run")
 	stepID := "step-1"
 	seq := int64(49)
+	payload := testLLMResponsePayload("call-1")
 
-	event := FormatLLMResponded(seq, runID, stepID)
+	event := FormatLLMResponded(seq, testTime, runID, stepID, payload)
 
 	assert.Equal(t, EventTypeLLMResponded, event.Type)
 	assert.Equal(t, seq, event.Seq)
 	assert.Equal(t, runID, event.RunID)
 	assert.Equal(t, stepID, event.StepID)
+	assert.Equal(t, payload, event.LLMResponsePayload)
 }
 
 // TestFormatter_ToolCalled verifies FormatToolCalled sets correct Type and Seq
 func TestFormatter_ToolCalled(t *testing.T) {
 	runID := RunID("test-run")
 	stepID := "step-1"
-	toolName := "calculator"
+	payload := testToolCallPayload("call-1")
 	seq := int64(50)
 
-	event := FormatToolCalled(seq, runID, stepID, toolName)
+	event := FormatToolCalled(seq, testTime, runID, stepID, payload)
 
 	assert.Equal(t, EventTypeToolCalled, event.Type)
 	assert.Equal(t, seq, event.Seq)
 	assert.Equal(t, runID, event.RunID)
 	assert.Equal(t, stepID, event.StepID)
-	assert.Equal(t, toolName, event.ToolName)
+	assert.Equal(t, payload, event.ToolCallPayload)
 }
 
 // TestFormatter_ToolReturned verifies FormatToolReturned sets correct Type and Seq
 func TestFormatter_ToolReturned(t *testing.T) {
 	runID := RunID("test-run")
 	stepID := "step-1"
-	toolName := "calculator"
+	payload := testToolResultPayload("call-1")
 	seq := int64(51)
 
-	event := FormatToolReturned(seq, runID, stepID, toolName)
+	event := FormatToolReturned(seq, testTime, runID, stepID, payload)
 
 	assert.Equal(t, EventTypeToolReturned, event.Type)
 	assert.Equal(t, seq, event.Seq)
 	assert.Equal(t, runID, event.RunID)
 	assert.Equal(t, stepID, event.StepID)
-	assert.Equal(t, toolName, event.ToolName)
+	assert.Equal(t, payload, event.ToolResultPayload)
 }
 
 // TestFormatter_ToolFailed verifies FormatToolFailed sets correct Type and Seq
 func TestFormatter_ToolFailed(t *testing.T) {
 	runID := RunID("test-run")
 	stepID := "step-1"
-	toolName := "calculator"
-	reason := "division by zero"
+	payload := testToolFailure("call-1").payload()
 	seq := int64(52)
 
-	event := FormatToolFailed(seq, runID, stepID, toolName, reason)
+	event := FormatToolFailed(seq, testTime, runID, stepID, payload)
 
 	assert.Equal(t, EventTypeToolFailed, event.Type)
 	assert.Equal(t, seq, event.Seq)
 	assert.Equal(t, runID, event.RunID)
 	assert.Equal(t, stepID, event.StepID)
-	assert.Equal(t, toolName, event.ToolName)
-	assert.Equal(t, reason, event.Reason)
+	assert.Equal(t, payload, event.ToolFailurePayload)
 }
 
 // TestFormatter_ArtifactCreated verifies FormatArtifactCreated sets correct Type and Seq
 func TestFormatter_ArtifactCreated(t *testing.T) {
 	runID := RunID("test-run")
 	stepID := "step-1"
-	path := "/tmp/artifact.txt"
+	artifact := testArtifact("out/artifact.txt")
 	seq := int64(53)
 
-	event := FormatArtifactCreated(seq, runID, stepID, path)
+	event := FormatArtifactCreated(seq, testTime, runID, stepID, artifact)
 
 	assert.Equal(t, EventTypeArtifactCreated, event.Type)
 	assert.Equal(t, seq, event.Seq)
 	assert.Equal(t, runID, event.RunID)
 	assert.Equal(t, stepID, event.StepID)
-	assert.Equal(t, path, event.Path)
+	assert.Equal(t, artifact, event.Artifact)
+}
+
+// TestFormatter_SignalGenerated verifies FormatSignalGenerated sets correct Type and Seq
+func TestFormatter_SignalGenerated(t *testing.T) {
+	runID := RunID("test-run")
+	stepID := "step-1"
+	signal := testSignal("signal-1")
+	seq := int64(54)
+
+	event := FormatSignalGenerated(seq, testTime, runID, stepID, signal)
+
+	assert.Equal(t, EventTypeSignalGenerated, event.Type)
+	assert.Equal(t, seq, event.Seq)
+	assert.Equal(t, runID, event.RunID)
+	assert.Equal(t, stepID, event.StepID)
+	assert.Equal(t, signal, event.Signal)
+}
+
+// TestFormatter_OrderSubmitted verifies FormatOrderSubmitted sets correct Type and Seq
+func TestFormatter_OrderSubmitted(t *testing.T) {
+	runID := RunID("test-run")
+	stepID := "step-1"
+	order := testOrder("order-1")
+	seq := int64(55)
+
+	event := FormatOrderSubmitted(seq, testTime, runID, stepID, order)
+
+	assert.Equal(t, EventTypeOrderSubmitted, event.Type)
+	assert.Equal(t, seq, event.Seq)
+	assert.Equal(t, runID, event.RunID)
+	assert.Equal(t, stepID, event.StepID)
+	assert.Equal(t, order, event.Order)
+}
+
+// TestFormatter_OrderAcknowledged verifies FormatOrderAcknowledged sets correct Type and Seq
+func TestFormatter_OrderAcknowledged(t *testing.T) {
+	runID := RunID("test-run")
+	stepID := "step-1"
+	ack := OrderAck{OrderID: "order-1", BrokerOrderID: "B-1"}
+	seq := int64(56)
+
+	event := FormatOrderAcknowledged(seq, testTime, runID, stepID, ack)
+
+	assert.Equal(t, EventTypeOrderAcknowledged, event.Type)
+	assert.Equal(t, seq, event.Seq)
+	assert.Equal(t, runID, event.RunID)
+	assert.Equal(t, stepID, event.StepID)
+	assert.Equal(t, ack, event.OrderAck)
+}
+
+// TestFormatter_OrderFilled verifies FormatOrderFilled sets correct Type and Seq
+func TestFormatter_OrderFilled(t *testing.T) {
+	runID := RunID("test-run")
+	stepID := "step-1"
+	fill := testFill("order-1", "fill-1", 10)
+	seq := int64(57)
+
+	event := FormatOrderFilled(seq, testTime, runID, stepID, fill)
+
+	assert.Equal(t, EventTypeOrderFilled, event.Type)
+	assert.Equal(t, seq, event.Seq)
+	assert.Equal(t, runID, event.RunID)
+	assert.Equal(t, stepID, event.StepID)
+	assert.Equal(t, fill, event.Fill)
+}
+
+// TestFormatter_OrderCancelled verifies FormatOrderCancelled sets correct Type and Seq
+func TestFormatter_OrderCancelled(t *testing.T) {
+	runID := RunID("test-run")
+	stepID := "step-1"
+	cancel := OrderCancel{OrderID: "order-1", Reason: "expired"}
+	seq := int64(58)
+
+	event := FormatOrderCancelled(seq, testTime, runID, stepID, cancel)
+
+	assert.Equal(t, EventTypeOrderCancelled, event.Type)
+	assert.Equal(t, seq, event.Seq)
+	assert.Equal(t, runID, event.RunID)
+	assert.Equal(t, stepID, event.StepID)
+	assert.Equal(t, cancel, event.OrderCancel)
+}
+
+// TestFormatter_OrderRejected verifies FormatOrderRejected sets correct Type and Seq
+func TestFormatter_OrderRejected(t *testing.T) {
+	runID := RunID("test-run")
+	stepID := "step-1"
+	reject := OrderReject{OrderID: "order-1", Reason: "halted"}
+	seq := int64(59)
+
+	event := FormatOrderRejected(seq, testTime, runID, stepID, reject)
+
+	assert.Equal(t, EventTypeOrderRejected, event.Type)
+	assert.Equal(t, seq, event.Seq)
+	assert.Equal(t, runID, event.RunID)
+	assert.Equal(t, stepID, event.StepID)
+	assert.Equal(t, reject, event.OrderReject)
 }
@@ -0,0 +1,228 @@
+package runtime
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"aiplatform/pkg/assert"
+)
+
+// Every line the writer appends is sealed: the event's JSON object gets two
+// trailing fields,
+//
+//	{...event fields...,"crc32c":<crc>,"prev_hash":"<hex>"}
+//
+// crc32c is the CRC-32C (Castagnoli) of the event's JSON object as it was
+// encoded, before sealing. It catches bit rot within one line.
+//
+// prev_hash is the hex SHA-256 of the previous line, sealed fields included
+// and newline excluded; it is empty for the first line. Each line therefore
+// commits to the whole log before it, so a changed, dropped, or reordered
+// line breaks the chain at the next one.
+//
+// Lines written before sealing existed carry neither field. They are only
+// accepted as a prefix of the log: once a sealed line appears, every later
+// line must be sealed too.
+
+// sealFieldPrefix starts the sealed fields. It cannot appear inside an
+// event's own JSON: quotes within string values are always escaped.
+const sealFieldPrefix = `,"crc32c":`
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+var (
+	// ErrChecksumMismatch means a line's payload does not match its crc32c.
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+
+	// ErrChainBroken means a line's prev_hash does not match the line before it.
+	ErrChainBroken = errors.New("hash chain broken")
+
+	// ErrUnsealedLine means a line without checksum fields follows a sealed one.
+	ErrUnsealedLine = errors.New("unsealed line after sealed lines")
+)
+
+// ChainError reports the first line of a log that fails verification.
+type ChainError struct {
+	// Line is the 1-based line number.
+	Line int
+
+	// Seq is the seq of the line, or 0 if it could not be read.
+	Seq int64
+
+	Err error
+}
+
+func (e *ChainError) Error() string {
+	if e.Seq == 0 {
+		return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+	}
+	return fmt.Sprintf("line %d (seq %d): %v", e.Line, e.Seq, e.Err)
+}
+
+func (e *ChainError) Unwrap() error {
+	return e.Err
+}
+
+// lineHash returns the hex SHA-256 that the next line's prev_hash must hold.
+func lineHash(line []byte) string {
+	sum := sha256.Sum256(line)
+	return hex.EncodeToString(sum[:])
+}
+
+// sealLine appends payload to dst as a sealed line, newline included, and
+// returns the hash the next line must chain to. payload must be one JSON
+// object without a trailing newline.
+func sealLine(dst *bytes.Buffer, payload []byte, prevHash string) string {
+	assert.Not_nil(dst, "dst must not be nil")
+	assert.Is_true(len(payload) >= 2, "payload must be a JSON object")
+	assert.Is_true(payload[len(payload)-1] == '}', "payload must end its object")
+
+	start := dst.Len()
+	dst.Write(payload[:len(payload)-1])
+	dst.WriteString(sealFieldPrefix)
+	dst.WriteString(strconv.FormatUint(uint64(crc32.Checksum(payload, castagnoli)), 10))
+	dst.WriteString(`,"prev_hash":"`)
+	dst.WriteString(prevHash)
+	dst.WriteString("\"}")
+	hash := lineHash(dst.Bytes()[start:])
+	dst.WriteByte('\n')
+	return hash
+}
+
+// unsealLine splits a sealed line into the payload it was sealed from and
+// its sealed fields. sealed is false for a line written before sealing.
+func unsealLine(line []byte) (payload []byte, crc uint32, prevHash string, sealed bool, err error) {
+	at := bytes.LastIndex(line, []byte(sealFieldPrefix))
+	if at < 0 {
+		return line, 0, "", false, nil
+	}
+
+	rest := line[at+len(sealFieldPrefix):]
+	crcText, rest, found := bytes.Cut(rest, []byte(`,"prev_hash":"`))
+	if !found {
+		return nil, 0, "", false, fmt.Errorf("malformed checksum fields")
+	}
+	value, err := strconv.ParseUint(string(crcText), 10, 32)
+	if err != nil {
+		return nil, 0, "", false, fmt.Errorf("malformed crc32c: %w", err)
+	}
+	hashText, found := bytes.CutSuffix(rest, []byte("\"}"))
+	if !found {
+		return nil, 0, "", false, fmt.Errorf("malformed prev_hash")
+	}
+
+	payload = make([]byte, 0, at+1)
+	payload = append(payload, line[:at]...)
+	payload = append(payload, '}')
+	return payload, uint32(value), string(hashText), true, nil
+}
+
+// chainVerifier checks the checksum and chain link of each line of a log,
+// in order. The zero value is ready to verify a log from its first line.
+type chainVerifier struct {
+	// lastHash is the hash of the last line verified.
+	lastHash string
+
+	// sealed is set once a sealed line has been seen.
+	sealed bool
+}
+
+// verify checks one line, without its newline, and advances the chain.
+func (v *chainVerifier) verify(line []byte) error {
+	payload, crc, prevHash, sealed, err := unsealLine(line)
+	if err != nil {
+		return err
+	}
+	if !sealed {
+		if v.sealed {
+			return ErrUnsealedLine
+		}
+	} else {
+		if crc32.Checksum(payload, castagnoli) != crc {
+			return ErrChecksumMismatch
+		}
+		if prevHash != v.lastHash {
+			return ErrChainBroken
+		}
+		v.sealed = true
+	}
+	v.lastHash = lineHash(line)
+	return nil
+}
+
+// VerifyResult summarizes a log that passed verification.
+type VerifyResult struct {
+	// Events is how many complete lines the log holds.
+	Events int
+
+	// LastSeq is the seq of the last event, or 0 for an empty log.
+	LastSeq int64
+
+	// LastHash is the hash the next appended line must chain to.
+	LastHash string
+
+	// TornBytes is the length of an unterminated final line, which is
+	// left for repair on the next open. Zero if the log ends cleanly.
+	TornBytes int
+}
+
+// VerifyLog checks every line of a log: that it decodes, that seq
+// strictly increases, and that its checksum and chain link hold. path is
+// either a run's log directory, whose segments are verified in order as
+// one chain, or a single log file.
+// A checksum or chain failure is reported as a *ChainError naming the
+// first broken line.
+func VerifyLog(path string) (VerifyResult, error) {
+	assert.Not_empty(path, "path must not be empty")
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return VerifyResult{}, fmt.Errorf("failed to open event log %s: %w", path, err)
+	}
+	paths := []string{path}
+	if info.IsDir() {
+		paths, err = listSegmentPaths(path)
+		if err != nil {
+			return VerifyResult{}, err
+		}
+		if len(paths) == 0 {
+			return VerifyResult{}, nil
+		}
+	}
+
+	var reader logReader
+	for i, segmentPath := range paths {
+		err := reader.readFile(segmentPath, func(Event) error { return nil }, i == len(paths)-1)
+		if err != nil {
+			if len(paths) > 1 {
+				return VerifyResult{}, fmt.Errorf("segment %s: %w", filepath.Base(segmentPath), err)
+			}
+			return VerifyResult{}, err
+		}
+	}
+	result := VerifyResult{
+		Events:   reader.events,
+		LastSeq:  reader.lastSeq,
+		LastHash: reader.chain.lastHash,
+	}
+
+	tailPath := paths[len(paths)-1]
+	file, err := os.Open(tailPath)
+	if err != nil {
+		return VerifyResult{}, fmt.Errorf("failed to open event log %s: %w", tailPath, err)
+	}
+	defer file.Close()
+	_, torn, err := findTornTail(file)
+	if err != nil {
+		return VerifyResult{}, err
+	}
+	result.TornBytes = len(torn)
+	return result, nil
+}
@@ -0,0 +1,174 @@
+package runtime
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readLogLines returns the lines of a log file without their newlines.
+func readLogLines(t *testing.T, path string) []string {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+}
+
+// writeLogLines replaces a log file with the given lines.
+func writeLogLines(t *testing.T, path string, lines []string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644))
+}
+
+// requireChainError asserts err is a *ChainError at the given seq.
+func requireChainError(t *testing.T, err error, seq int64, cause error) {
+	t.Helper()
+	var chainErr *ChainError
+	require.True(t, errors.As(err, &chainErr), "want *ChainError, got %v", err)
+	assert.Equal(t, seq, chainErr.Seq)
+	assert.ErrorIs(t, err, cause)
+}
+
+// TestEventLog_SealsEveryLine verifies the writer seals each line with a
+// checksum and a link to the line before, and that the log verifies.
+func TestEventLog_SealsEveryLine(t *testing.T) {
+	workspaceRoot := t.TempDir()
+	runID := RunID("run-sealed")
+	writeFullRun(t, runID, workspaceRoot)
+
+	path := segmentFilePath(runID, workspaceRoot, 1)
+	lines := readLogLines(t, path)
+	prevHash := ""
+	for _, line := range lines {
+		assert.Contains(t, line, `,"crc32c":`)
+		assert.Contains(t, line, `"prev_hash":"`+prevHash+`"`)
+		prevHash = lineHash([]byte(line))
+	}
+
+	result, err := VerifyLog(path)
+	require.NoError(t, err)
+	assert.Equal(t, len(lines), result.Events)
+	assert.Equal(t, int64(len(lines)), result.LastSeq)
+	assert.Equal(t, prevHash, result.LastHash)
+	assert.Zero(t, result.TornBytes)
+}
+
+// TestVerifyLog_DetectsDamage verifies bit rot, edits, and dropped lines
+// are each reported at the first broken seq, and that a damaged log is
+// refused on open.
+func TestVerifyLog_DetectsDamage(t *testing.T) {
+	tests := []struct {
+		name   string
+		damage func(lines []string) []string
+		seq    int64
+		cause  error
+	}{
+		{
+			name: "bit rot in payload",
+			damage: func(lines []string) []string {
+				lines[2] = strings.Replace(lines[2], `"quote"`, `"quota"`, 1)
+				return lines
+			},
+			seq:   3,
+			cause: ErrChecksumMismatch,
+		},
+		{
+			name: "resealed edit",
+			damage: func(lines []string) []string {
+				// The edit carries a valid checksum; only the chain catches it.
+				payload, _, prevHash, _, err := unsealLine([]byte(lines[2]))
+				require.NoError(t, err)
+				payload = bytes.Replace(payload, []byte(`"quote"`), []byte(`"quota"`), 1)
+				var sealed bytes.Buffer
+				sealLine(&sealed, payload, prevHash)
+				lines[2] = strings.TrimSuffix(sealed.String(), "\n")
+				return lines
+			},
+			seq:   4,
+			cause: ErrChainBroken,
+		},
+		{
+			name: "dropped line",
+			damage: func(lines []string) []string {
+				return append(lines[:3], lines[4:]...)
+			},
+			seq:   5,
+			cause: ErrChainBroken,
+		},
+		{
+			name: "unsealed line appended",
+			damage: func(lines []string) []string {
+				return append(lines, `{"run_id":"run-damaged","seq":11,"type":"run.finished"}`)
+			},
+			seq:   11,
+			cause: ErrUnsealedLine,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workspaceRoot := t.TempDir()
+			runID := RunID("run-damaged")
+			writeFullRun(t, runID, workspaceRoot)
+
+			path := segmentFilePath(runID, workspaceRoot, 1)
+			writeLogLines(t, path, tt.damage(readLogLines(t, path)))
+
+			_, err := VerifyLog(path)
+			requireChainError(t, err, tt.seq, tt.cause)
+
+			_, err = ReadEvents(runID, workspaceRoot)
+			requireChainError(t, err, tt.seq, tt.cause)
+
+			_, err = OpenEventLog(runID, workspaceRoot, testLogOptions())
+			requireChainError(t, err, tt.seq, tt.cause)
+		})
+	}
+}
+
+// TestVerifyLog_BlankLine verifies a blank line is reported as a decode
+// error at its line, not a panic.
+func TestVerifyLog_BlankLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blank.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte("\n"), 0644))
+
+	_, err := VerifyLog(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "line 1: invalid JSON: blank line")
+}
+
+// TestEventLog_SealsAfterUnsealedPrefix verifies a log written before
+// sealing stays readable, and appends to it start a chain from its last line.
+func TestEventLog_SealsAfterUnsealedPrefix(t *testing.T) {
+	workspaceRoot := t.TempDir()
+	runID := RunID("run-unsealed-prefix")
+	path := legacyLogFilePath(runID, workspaceRoot)
+	require.NoError(t, os.MkdirAll(logDirPath(workspaceRoot), 0755))
+	unsealed := []string{
+		`{"run_id":"run-unsealed-prefix","workspace_root":"` + workspaceRoot + `","seq":1,"type":"run.started"}`,
+		`{"run_id":"run-unsealed-prefix","step_id":"s1","phase":1,"seq":2,"type":"step.started"}`,
+	}
+	writeLogLines(t, path, unsealed)
+
+	log, err := OpenEventLog(runID, workspaceRoot, testLogOptions())
+	require.NoError(t, err)
+	require.NoError(t, log.AppendStepFinished(runID, "s1", PhaseDataIngestion))
+	require.NoError(t, log.Close())
+
+	// Opening moved the log into its run directory as the first segment.
+	assert.NoFileExists(t, path)
+	path = segmentFilePath(runID, workspaceRoot, 1)
+	lines := readLogLines(t, path)
+	require.Len(t, lines, 3)
+	assert.Contains(t, lines[2], `"prev_hash":"`+lineHash([]byte(unsealed[1]))+`"`)
+
+	result, err := VerifyLog(path)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), result.LastSeq)
+}
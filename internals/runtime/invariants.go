@@ -0,0 +1,606 @@
+package runtime
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+
+	"aiplatform/pkg/assert"
+)
+
+// ReplayMode tells the validator whether the run is expected to be over.
+// The zero value is invalid so callers must choose explicitly.
+type ReplayMode int
+
+const (
+	// ReplayModeComplete requires a terminal event and terminated steps.
+	// Use it for logs that are finished and being audited.
+	ReplayModeComplete ReplayMode = 1
+
+	// ReplayModeInProgress accepts a missing terminal event and open steps.
+	// Use it for logs of runs that are still running or crashed mid-run.
+	ReplayModeInProgress ReplayMode = 2
+)
+
+// Invariant identifiers reported in violations.
+// Numbered invariants use their ALGO.md number; named ones use the heading.
+const (
+	InvariantRunMissingStart       = "2a.1"
+	InvariantRunDuplicateStart     = "2a.2"
+	InvariantRunStartNotFirst      = "2a.3"
+	InvariantRunMissingTermination = "2a.4"
+	InvariantRunDuplicateTerminal  = "2a.5"
+	InvariantRunTerminalNotLast    = "2a.6"
+	InvariantPhaseOrder            = "3"
+	InvariantSeqOrdering           = "38"
+	InvariantStepLifecycle         = "step_lifecycle"
+	InvariantStepBelongsToRun      = "step_run"
+	InvariantLLMLifecycle          = "llm_lifecycle"
+	InvariantToolLifecycle         = "tool_lifecycle"
+	InvariantArtifactIntegrity     = "artifact_integrity"
+	InvariantOrderLifecycle        = "T11" // numbered in TRADING.md
+)
+
+// Violation describes one broken invariant found while walking an event stream.
+type Violation struct {
+	Invariant string // ALGO.md invariant identifier, e.g. "2a.5"
+	Seq       int64  // seq of the offending event, 0 if the stream as a whole is at fault
+	RunID     RunID
+	StepID    string // empty when the violation is not about a step
+	Message   string
+}
+
+// String formats the violation for logs and error messages.
+func (v Violation) String() string {
+	if v.StepID == "" {
+		return fmt.Sprintf("invariant %s: seq %d run %s: %s",
+			v.Invariant, v.Seq, v.RunID, v.Message)
+	}
+	return fmt.Sprintf("invariant %s: seq %d run %s step %s: %s",
+		v.Invariant, v.Seq, v.RunID, v.StepID, v.Message)
+}
+
+// stepStatus is the lifecycle position of a single step during validation.
+type stepStatus int
+
+const (
+	stepStatusRunning    stepStatus = 1
+	stepStatusTerminated stepStatus = 2
+)
+
+// stepRecord remembers what the validator has seen for one step.
+// Its fields are exported so a snapshot can carry it.
+type stepRecord struct {
+	Phase  Phase      `json:"phase"`
+	Status stepStatus `json:"status"`
+
+	// LLMPending counts the step's LLM requests still awaiting a response.
+	LLMPending int `json:"llm_pending"`
+
+	// ToolPending counts the step's tool calls not yet returned or failed.
+	ToolPending int `json:"tool_pending"`
+}
+
+// llmCallRecord remembers one LLM call, keyed by request ID.
+type llmCallRecord struct {
+	StepID    string `json:"step_id"`
+	Responded bool   `json:"responded"`
+}
+
+// toolCallRecord remembers one tool call, keyed by call ID.
+type toolCallRecord struct {
+	StepID     string `json:"step_id"`
+	ToolName   string `json:"tool_name"`
+	Terminated bool   `json:"terminated"`
+}
+
+// eventValidator accumulates state while walking one run's events.
+// It is single-use: construct it, or resume it from a snapshot's state,
+// feed events in order, then finish.
+type eventValidator struct {
+	mode          ReplayMode
+	runID         RunID
+	lastSeq       int64
+	started       bool
+	terminalSeq   int64
+	phase         Phase // zero until the first step.started
+	steps         map[string]*stepRecord
+	llmCalls      map[string]*llmCallRecord  // keyed by request ID
+	toolCalls     map[string]*toolCallRecord // keyed by call ID
+	orders        *OrderTracker
+	violations    []Violation
+	eventsChecked int
+}
+
+// validatorState is what an eventValidator has learned from the events
+// up to some seq, as a snapshot stores it. A validator resumed from it
+// judges the events after that seq as if it had seen every one before.
+// Phase is zero until the first step.started.
+type validatorState struct {
+	Started     bool                      `json:"started"`
+	TerminalSeq int64                     `json:"terminal_seq"`
+	Phase       Phase                     `json:"phase,omitempty"`
+	Steps       map[string]stepRecord     `json:"steps"`
+	LLMCalls    map[string]llmCallRecord  `json:"llm_calls"`
+	ToolCalls   map[string]toolCallRecord `json:"tool_calls"`
+	Orders      *OrderTracker             `json:"orders"`
+}
+
+// newEventValidator returns a validator that has seen no events.
+func newEventValidator(mode ReplayMode) *eventValidator {
+	assert.Is_true(mode == ReplayModeComplete || mode == ReplayModeInProgress,
+		fmt.Sprintf("replay mode must be valid, got %d", mode))
+
+	return &eventValidator{
+		mode:      mode,
+		steps:     make(map[string]*stepRecord),
+		llmCalls:  make(map[string]*llmCallRecord),
+		toolCalls: make(map[string]*toolCallRecord),
+		orders:    NewOrderTracker(),
+	}
+}
+
+// resumeEventValidator returns a validator that has seen the events of
+// run runID up to seq, as described by state. It shares nothing with
+// state.
+func resumeEventValidator(mode ReplayMode, runID RunID, seq int64,
+	state validatorState) *eventValidator {
+	assert.Gt(seq, 0, "seq must be positive")
+	assert.Not_nil(state.Orders, "state must carry its orders")
+
+	v := newEventValidator(mode)
+	v.runID = runID
+	v.lastSeq = seq
+	v.eventsChecked = 1 // Only the first event may be run.started.
+	v.started = state.Started
+	v.terminalSeq = state.TerminalSeq
+	v.phase = state.Phase
+	for stepID, record := range state.Steps {
+		v.steps[stepID] = &record
+	}
+	for requestID, call := range state.LLMCalls {
+		v.llmCalls[requestID] = &call
+	}
+	for callID, call := range state.ToolCalls {
+		v.toolCalls[callID] = &call
+	}
+	v.orders = state.Orders.clone()
+	return v
+}
+
+// state returns what the validator has learned so far. It shares nothing
+// with the validator, so it stays as it is while the validator goes on.
+func (v *eventValidator) state() validatorState {
+	state := validatorState{
+		Started:     v.started,
+		TerminalSeq: v.terminalSeq,
+		Phase:       v.phase,
+		Steps:       make(map[string]stepRecord, len(v.steps)),
+		LLMCalls:    make(map[string]llmCallRecord, len(v.llmCalls)),
+		ToolCalls:   make(map[string]toolCallRecord, len(v.toolCalls)),
+		Orders:      v.orders.clone(),
+	}
+	for stepID, record := range v.steps {
+		state.Steps[stepID] = *record
+	}
+	for requestID, call := range v.llmCalls {
+		state.LLMCalls[requestID] = *call
+	}
+	for callID, call := range v.toolCalls {
+		state.ToolCalls[callID] = *call
+	}
+	return state
+}
+
+// validate checks that a decoded state is complete and within bounds.
+func (s validatorState) validate() error {
+	if s.Phase != 0 && !s.Phase.IsValid() {
+		return fmt.Errorf("invalid phase %d", s.Phase)
+	}
+	if s.Orders == nil {
+		return fmt.Errorf("no order state")
+	}
+	if len(s.Steps) > stepsPerRunMax {
+		return fmt.Errorf("%d steps, limit is %d", len(s.Steps), stepsPerRunMax)
+	}
+	if len(s.LLMCalls)+len(s.ToolCalls) > eventsPerLogMax {
+		return fmt.Errorf("%d calls, limit is %d",
+			len(s.LLMCalls)+len(s.ToolCalls), eventsPerLogMax)
+	}
+	return nil
+}
+
+// ValidateEvents walks a decoded event stream for one run and returns every
+// [REPLAY] invariant it breaks, in seq order. An empty result means the
+// stream can be trusted.
+//
+// It never panics on bad input: corrupted or hand-edited logs are exactly
+// what it exists to catch.
+func ValidateEvents(events []Event, mode ReplayMode) []Violation {
+	assert.Is_true(len(events) <= eventsPerLogMax, "events exceed replay limit")
+
+	v := newEventValidator(mode)
+	for _, event := range events {
+		v.check(event)
+	}
+	v.finish()
+	return v.violations
+}
+
+// ValidateLog reads a run's log from disk and validates it, including the
+// artifacts it records. The error reports logs that cannot be decoded at
+// all; decodable logs that break invariants are reported as violations.
+func ValidateLog(runID RunID, workspaceRoot string, mode ReplayMode) ([]Violation, error) {
+	assert.Is_true(runID != RunID(""), "runID must not be empty")
+	assert.Not_empty(workspaceRoot, "workspaceRoot must not be empty")
+
+	events, err := ReadEvents(runID, workspaceRoot)
+	if err != nil {
+		return nil, err
+	}
+	violations := ValidateEvents(events, mode)
+	return append(violations, VerifyArtifacts(events, workspaceRoot)...), nil
+}
+
+// VerifyArtifacts checks that every artifact.created event names a file
+// that still exists inside the workspace and matches its recorded hash,
+// and that every LLM and tool body, inline or stored as an artifact,
+// matches its recorded hash. Artifacts written before hashes were
+// recorded are only checked to exist.
+func VerifyArtifacts(events []Event, workspaceRoot string) []Violation {
+	assert.Is_true(filepath.IsAbs(workspaceRoot), "workspace root must be absolute path")
+	assert.Is_true(len(events) <= eventsPerLogMax, "events exceed replay limit")
+
+	var violations []Violation
+	for _, event := range events {
+		header := headerOf(event)
+		store := NewArtifactStore(workspaceRoot, header.RunID)
+
+		var err error
+		switch e := event.(type) {
+		case ArtifactCreatedEvent:
+			if e.SHA256 == "" {
+				_, err = store.resolve(e.Path)
+			} else {
+				err = store.Verify(e.Artifact)
+			}
+		case LLMRequestedEvent:
+			err = store.verifyBody(e.Prompt)
+		case LLMRespondedEvent:
+			err = store.verifyBody(e.Response)
+		case ToolCalledEvent:
+			err = store.verifyBody(e.Arguments)
+		case ToolReturnedEvent:
+			err = store.verifyBody(e.Result)
+		}
+		if err != nil {
+			violations = append(violations, Violation{
+				Invariant: InvariantArtifactIntegrity,
+				Seq:       header.Seq,
+				RunID:     header.RunID,
+				StepID:    header.StepID,
+				Message:   err.Error(),
+			})
+		}
+	}
+	return violations
+}
+
+// report records a violation against the given event header.
+func (v *eventValidator) report(invariant string, header eventHeader, format string, args ...any) {
+	v.violations = append(v.violations, Violation{
+		Invariant: invariant,
+		Seq:       header.Seq,
+		RunID:     header.RunID,
+		StepID:    header.StepID,
+		Message:   fmt.Sprintf(format, args...),
+	})
+}
+
+// check validates a single event against everything seen before it.
+func (v *eventValidator) check(event Event) {
+	header := headerOf(event)
+	v.eventsChecked++
+
+	v.checkSeq(header)
+	v.checkRun(header)
+	// T11 holds whatever state the order's step is in, so an order event
+	// outside a running step still moves, or is refused by, its order.
+	if isOrderEvent(event) {
+		v.checkOrder(event, header)
+	}
+	if header.StepID != "" {
+		v.checkStep(event, header)
+	}
+}
+
+// follow moves the validator past an event without keeping what it
+// breaks. The event log follows what it writes, so snapshots of its head
+// carry the validator's state.
+func (v *eventValidator) follow(event Event) {
+	v.check(event)
+	v.violations = v.violations[:0]
+}
+
+// checkSeq enforces Invariant 38: seq is positive and strictly increasing.
+func (v *eventValidator) checkSeq(header eventHeader) {
+	if header.Seq <= 0 {
+		v.report(InvariantSeqOrdering, header, "seq must be positive")
+	} else if header.Seq <= v.lastSeq {
+		v.report(InvariantSeqOrdering, header,
+			"seq is not strictly increasing (previous: %d)", v.lastSeq)
+	}
+	if header.Seq > v.lastSeq {
+		v.lastSeq = header.Seq
+	}
+}
+
+// checkRun enforces the run lifecycle rules of Invariant 2a.
+func (v *eventValidator) checkRun(header eventHeader) {
+	if v.eventsChecked == 1 {
+		v.runID = header.RunID
+		if header.Type != EventTypeRunStarted {
+			v.report(InvariantRunStartNotFirst, header,
+				"first event is %s, expected %s", header.Type, EventTypeRunStarted)
+		}
+	} else if header.RunID != v.runID {
+		v.report(InvariantStepBelongsToRun, header, "event belongs to run %s, expected %s",
+			header.RunID, v.runID)
+	}
+
+	if v.terminalSeq > 0 {
+		if isTerminalEventType(header.Type) {
+			v.report(InvariantRunDuplicateTerminal, header,
+				"second terminal event (first at seq %d)", v.terminalSeq)
+		} else {
+			v.report(InvariantRunTerminalNotLast, header,
+				"%s after terminal event at seq %d", header.Type, v.terminalSeq)
+		}
+		return
+	}
+
+	switch header.Type {
+	case EventTypeRunStarted:
+		if v.started {
+			v.report(InvariantRunDuplicateStart, header, "duplicate %s", EventTypeRunStarted)
+		}
+		v.started = true
+	case EventTypeRunFinished, EventTypeRunFailed:
+		v.terminalSeq = header.Seq
+	}
+}
+
+// checkStep enforces the step lifecycle and the phase order (Invariant 3).
+// Only step.started moves the run's phase; every other step event must
+// reference a step that is running.
+func (v *eventValidator) checkStep(event Event, header eventHeader) {
+	assert.Not_empty(header.StepID, "step event must carry a step ID")
+	record, known := v.steps[header.StepID]
+
+	if started, ok := event.(StepStartedEvent); ok {
+		if known {
+			v.report(InvariantStepLifecycle, header, "duplicate %s", EventTypeStepStarted)
+			return
+		}
+		v.checkPhaseTransition(header, started.Phase)
+		v.steps[header.StepID] = &stepRecord{Phase: started.Phase, Status: stepStatusRunning}
+		return
+	}
+
+	if !known {
+		v.report(InvariantStepLifecycle, header, "%s before %s", header.Type, EventTypeStepStarted)
+		return
+	}
+	if record.Status == stepStatusTerminated {
+		v.report(InvariantStepLifecycle, header, "%s after step terminated", header.Type)
+		return
+	}
+
+	switch e := event.(type) {
+	case StepFinishedEvent:
+		v.checkStepPhase(header, record, e.Phase)
+		if record.LLMPending > 0 {
+			v.report(InvariantLLMLifecycle, header,
+				"step finished with %d LLM request(s) unanswered", record.LLMPending)
+		}
+		if record.ToolPending > 0 {
+			v.report(InvariantToolLifecycle, header,
+				"step finished with %d tool call(s) outstanding", record.ToolPending)
+		}
+		record.Status = stepStatusTerminated
+	case LLMRequestedEvent:
+		v.checkLLMRequested(header, record, e.RequestID)
+	case LLMRespondedEvent:
+		v.checkLLMResponded(header, record, e.RequestID)
+	case ToolCalledEvent:
+		v.checkToolCalled(header, record, e.CallID, e.ToolName)
+	case ToolReturnedEvent:
+		v.checkToolTerminated(header, record, e.CallID, e.ToolName)
+	case ToolFailedEvent:
+		v.checkToolTerminated(header, record, e.CallID, e.ToolName)
+	case StepFailedEvent:
+		v.checkStepPhase(header, record, e.Phase)
+		record.Status = stepStatusTerminated
+	}
+}
+
+// checkOrder enforces T11 with the same state machine the log applies
+// when order events are appended.
+func (v *eventValidator) checkOrder(event Event, header eventHeader) {
+	if err := v.orders.Apply(event); err != nil {
+		v.report(InvariantOrderLifecycle, header, "%v", err)
+	}
+}
+
+// checkLLMRequested enforces the start of the LLM event lifecycle: each
+// request ID is used by exactly one llm.requested. Events written before
+// request IDs existed carry none and are not tracked.
+func (v *eventValidator) checkLLMRequested(header eventHeader, record *stepRecord,
+	requestID string) {
+	assert.Not_nil(record, "step record must not be nil")
+	if requestID == "" {
+		return
+	}
+	if _, seen := v.llmCalls[requestID]; seen {
+		v.report(InvariantLLMLifecycle, header, "duplicate %s for request %s",
+			EventTypeLLMRequested, requestID)
+		return
+	}
+	v.llmCalls[requestID] = &llmCallRecord{StepID: header.StepID}
+	record.LLMPending++
+}
+
+// checkLLMResponded enforces the end of the LLM event lifecycle: each
+// request gets exactly one llm.responded, in the step that made it.
+func (v *eventValidator) checkLLMResponded(header eventHeader, record *stepRecord,
+	requestID string) {
+	assert.Not_nil(record, "step record must not be nil")
+	if requestID == "" {
+		return
+	}
+	call, seen := v.llmCalls[requestID]
+	if !seen {
+		v.report(InvariantLLMLifecycle, header, "%s for unknown request %s",
+			EventTypeLLMResponded, requestID)
+		return
+	}
+	if call.Responded {
+		v.report(InvariantLLMLifecycle, header, "duplicate %s for request %s",
+			EventTypeLLMResponded, requestID)
+		return
+	}
+	if call.StepID != header.StepID {
+		v.report(InvariantLLMLifecycle, header, "request %s was made in step %s",
+			requestID, call.StepID)
+		return
+	}
+	call.Responded = true
+	record.LLMPending--
+}
+
+// checkToolCalled enforces the start of the tool event lifecycle: each
+// call ID is used by exactly one tool.called. Events written before call
+// IDs existed carry none and are not tracked.
+func (v *eventValidator) checkToolCalled(header eventHeader, record *stepRecord, callID string,
+	toolName string) {
+	assert.Not_nil(record, "step record must not be nil")
+	if callID == "" {
+		return
+	}
+	if _, seen := v.toolCalls[callID]; seen {
+		v.report(InvariantToolLifecycle, header, "duplicate %s for call %s",
+			EventTypeToolCalled, callID)
+		return
+	}
+	v.toolCalls[callID] = &toolCallRecord{StepID: header.StepID, ToolName: toolName}
+	record.ToolPending++
+}
+
+// checkToolTerminated enforces the end of the tool event lifecycle: each
+// call gets exactly one tool.returned or tool.failed, in the step that
+// made it, naming the same tool.
+func (v *eventValidator) checkToolTerminated(header eventHeader, record *stepRecord,
+	callID string, toolName string) {
+	assert.Not_nil(record, "step record must not be nil")
+	assert.Is_true(header.Type == EventTypeToolReturned || header.Type == EventTypeToolFailed,
+		"event must terminate a tool call")
+	if callID == "" {
+		return
+	}
+	call, seen := v.toolCalls[callID]
+	if !seen {
+		v.report(InvariantToolLifecycle, header, "%s for unknown call %s", header.Type, callID)
+		return
+	}
+	if call.Terminated {
+		v.report(InvariantToolLifecycle, header, "%s for call %s that already terminated",
+			header.Type, callID)
+		return
+	}
+	if call.StepID != header.StepID {
+		v.report(InvariantToolLifecycle, header, "call %s was made in step %s",
+			callID, call.StepID)
+		return
+	}
+	if call.ToolName != toolName {
+		v.report(InvariantToolLifecycle, header, "call %s was to tool %s, not %s",
+			callID, call.ToolName, toolName)
+		return
+	}
+	call.Terminated = true
+	record.ToolPending--
+}
+
+// checkPhaseTransition applies IsValidTransition to a step.started phase.
+// The first step must be in data_ingestion: phase gating means no later
+// phase may appear without the earlier ones before it.
+func (v *eventValidator) checkPhaseTransition(header eventHeader, phase Phase) {
+	if !phase.IsValid() {
+		v.report(InvariantPhaseOrder, header, "invalid phase %d", phase)
+		return
+	}
+	if v.phase == 0 {
+		if phase != PhaseDataIngestion {
+			v.report(InvariantPhaseOrder, header, "first step is in %s, expected %s",
+				phase, PhaseDataIngestion)
+		}
+	} else if !IsValidTransition(v.phase, phase) {
+		v.report(InvariantPhaseOrder, header, "illegal phase transition %s -> %s", v.phase, phase)
+	}
+	v.phase = phase
+}
+
+// checkStepPhase ensures a step terminates in the phase it started in.
+func (v *eventValidator) checkStepPhase(header eventHeader, record *stepRecord, phase Phase) {
+	assert.Not_nil(record, "step record must not be nil")
+	if phase != record.Phase {
+		v.report(InvariantPhaseOrder, header, "step started in %s but %s reports %s",
+			record.Phase, header.Type, phase)
+	}
+}
+
+// finish reports invariants that can only be judged once the stream ends.
+func (v *eventValidator) finish() {
+	stream := eventHeader{RunID: v.runID}
+	if !v.started {
+		v.report(InvariantRunMissingStart, stream, "no %s event", EventTypeRunStarted)
+	}
+	if v.mode == ReplayModeInProgress {
+		return
+	}
+
+	if v.terminalSeq == 0 {
+		v.report(InvariantRunMissingTermination, stream, "no terminal event")
+	}
+	// Walk steps in a stable order so the violation list is deterministic.
+	for _, stepID := range sortedStepIDs(v.steps) {
+		if v.steps[stepID].Status == stepStatusRunning {
+			stepHeader := eventHeader{RunID: v.runID, StepID: stepID}
+			v.report(InvariantStepLifecycle, stepHeader, "step never terminated")
+		}
+	}
+	for _, orderID := range v.orders.OpenOrderIDs() {
+		state, _ := v.orders.Order(orderID)
+		v.report(InvariantOrderLifecycle, stream, "order %s never terminated (%s)",
+			orderID, state.Status)
+	}
+}
+
+// isTerminalEventType reports whether an event type ends a run.
+func isTerminalEventType(eventType EventType) bool {
+	switch eventType {
+	case EventTypeRunFinished, EventTypeRunFailed:
+		return true
+	}
+	return false
+}
+
+// sortedStepIDs returns the map's step IDs in lexical order.
+func sortedStepIDs(steps map[string]*stepRecord) []string {
+	ids := make([]string, 0, len(steps))
+	for id := range steps {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
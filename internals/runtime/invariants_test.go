@@ -0,0 +1,292 @@
+package runtime
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const validatorRunID = RunID("run-validator")
+
+// wellFormedRun returns a complete run that satisfies every replay invariant.
+func wellFormedRun() []Event {
+	return []Event{
+		FormatRunStarted(1, testTime, validatorRunID, "/w"),
+		FormatStepStarted(2, testTime, validatorRunID, "s1", PhaseDataIngestion),
+		FormatToolCalled(3, testTime, validatorRunID, "s1", testToolCallPayload("call-s1")),
+		FormatToolReturned(4, testTime, validatorRunID, "s1", testToolResultPayload("call-s1")),
+		FormatStepFailed(5, testTime, validatorRunID, "s1", PhaseDataIngestion, "retry"),
+		FormatStepStarted(6, testTime, validatorRunID, "s2", PhaseDataIngestion),
+		FormatStepFinished(7, testTime, validatorRunID, "s2", PhaseDataIngestion),
+		FormatStepStarted(8, testTime, validatorRunID, "s3", PhaseSignalGeneration),
+		FormatLLMRequested(9, testTime, validatorRunID, "s3", testLLMRequestPayload("call-s3")),
+		FormatLLMResponded(10, testTime, validatorRunID, "s3", testLLMResponsePayload("call-s3")),
+		FormatStepFinished(11, testTime, validatorRunID, "s3", PhaseSignalGeneration),
+		FormatStepStarted(12, testTime, validatorRunID, "s4", PhaseRiskValidation),
+		FormatStepFinished(13, testTime, validatorRunID, "s4", PhaseRiskValidation),
+		FormatStepStarted(14, testTime, validatorRunID, "s5", PhaseOrderExecution),
+		FormatArtifactCreated(15, testTime, validatorRunID, "s5", testArtifact("orders.json")),
+		FormatStepFinished(16, testTime, validatorRunID, "s5", PhaseOrderExecution),
+		FormatRunFinished(17, testTime, validatorRunID),
+	}
+}
+
+// TestValidateEvents_WellFormedRun verifies a clean log produces no violations.
+func TestValidateEvents_WellFormedRun(t *testing.T) {
+	assert.Empty(t, ValidateEvents(wellFormedRun(), ReplayModeComplete))
+	assert.Empty(t, ValidateEvents(wellFormedRun(), ReplayModeInProgress))
+}
+
+// TestInvariant_2a_RunLifecycle verifies every failure case listed under
+// ALGO.md Invariant 2a is detected and attributed to the right seq.
+func TestInvariant_2a_RunLifecycle(t *testing.T) {
+	tests := []struct {
+		name      string
+		events    []Event
+		invariant string
+		seq       int64
+	}{
+		{
+			name:      "missing_start",
+			events:    []Event{FormatRunFinished(1, testTime, validatorRunID)},
+			invariant: InvariantRunMissingStart,
+			seq:       0,
+		},
+		{
+			name: "duplicate_start",
+			events: []Event{
+				FormatRunStarted(1, testTime, validatorRunID, "/w"),
+				FormatRunStarted(2, testTime, validatorRunID, "/w"),
+				FormatRunFinished(3, testTime, validatorRunID),
+			},
+			invariant: InvariantRunDuplicateStart,
+			seq:       2,
+		},
+		{
+			name: "start_not_first",
+			events: []Event{
+				FormatStepStarted(1, testTime, validatorRunID, "s1", PhaseDataIngestion),
+				FormatRunStarted(2, testTime, validatorRunID, "/w"),
+				FormatRunFinished(3, testTime, validatorRunID),
+			},
+			invariant: InvariantRunStartNotFirst,
+			seq:       1,
+		},
+		{
+			name:      "missing_termination",
+			events:    []Event{FormatRunStarted(1, testTime, validatorRunID, "/w")},
+			invariant: InvariantRunMissingTermination,
+			seq:       0,
+		},
+		{
+			name: "duplicate_termination",
+			events: []Event{
+				FormatRunStarted(1, testTime, validatorRunID, "/w"),
+				FormatRunFinished(2, testTime, validatorRunID),
+				FormatRunFailed(3, testTime, validatorRunID, "late"),
+			},
+			invariant: InvariantRunDuplicateTerminal,
+			seq:       3,
+		},
+		{
+			name: "termination_not_last",
+			events: []Event{
+				FormatRunStarted(1, testTime, validatorRunID, "/w"),
+				FormatRunFailed(2, testTime, validatorRunID, "crashed"),
+				FormatStepStarted(3, testTime, validatorRunID, "s1", PhaseDataIngestion),
+			},
+			invariant: InvariantRunTerminalNotLast,
+			seq:       3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations := ValidateEvents(tt.events, ReplayModeComplete)
+			v := findViolation(t, violations, tt.invariant)
+			assert.Equal(t, tt.seq, v.Seq)
+			assert.Equal(t, validatorRunID, v.RunID)
+		})
+	}
+}
+
+// TestInvariant_3_PhaseOrder verifies phase transitions obey IsValidTransition.
+func TestInvariant_3_PhaseOrder(t *testing.T) {
+	tests := []struct {
+		name   string
+		phases []Phase
+		valid  bool
+	}{
+		{"forward_by_one", []Phase{PhaseDataIngestion, PhaseSignalGeneration}, true},
+		{"retry_same_phase", []Phase{PhaseDataIngestion, PhaseDataIngestion}, true},
+		{"skip_forward", []Phase{PhaseDataIngestion, PhaseRiskValidation}, false},
+		{"backward", []Phase{PhaseDataIngestion, PhaseSignalGeneration, PhaseDataIngestion}, false},
+		{"first_not_data_ingestion", []Phase{PhaseSignalGeneration}, false},
+		{"invalid_phase", []Phase{PhaseDataIngestion, Phase(9)}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := []Event{FormatRunStarted(1, testTime, validatorRunID, "/w")}
+			seq := int64(2)
+			for i, phase := range tt.phases {
+				stepID := "s" + string(rune('a'+i))
+				// Built by hand so an invalid phase can reach the validator.
+				events = append(events, StepStartedEvent{
+					RunID: validatorRunID, StepID: stepID, Phase: phase,
+					Seq: seq, Type: EventTypeStepStarted,
+				})
+				seq++
+			}
+
+			violations := ValidateEvents(events, ReplayModeInProgress)
+			if tt.valid {
+				assert.Empty(t, violations)
+			} else {
+				v := findViolation(t, violations, InvariantPhaseOrder)
+				assert.Equal(t, seq-1, v.Seq, "violation should point at the last step")
+			}
+		})
+	}
+}
+
+// TestValidateEvents_StepLifecycle verifies the step lifecycle rules.
+func TestValidateEvents_StepLifecycle(t *testing.T) {
+	start := FormatRunStarted(1, testTime, validatorRunID, "/w")
+	tests := []struct {
+		name   string
+		events []Event
+		stepID string
+		seq    int64
+	}{
+		{
+			name: "finished_before_started",
+			events: []Event{
+				start,
+				FormatStepFinished(2, testTime, validatorRunID, "s1", PhaseDataIngestion),
+			},
+			stepID: "s1",
+			seq:    2,
+		},
+		{
+			name: "duplicate_start",
+			events: []Event{
+				start,
+				FormatStepStarted(2, testTime, validatorRunID, "s1", PhaseDataIngestion),
+				FormatStepStarted(3, testTime, validatorRunID, "s1", PhaseDataIngestion),
+			},
+			stepID: "s1",
+			seq:    3,
+		},
+		{
+			name: "event_after_step_terminated",
+			events: []Event{
+				start,
+				FormatStepStarted(2, testTime, validatorRunID, "s1", PhaseDataIngestion),
+				FormatStepFinished(3, testTime, validatorRunID, "s1", PhaseDataIngestion),
+				FormatToolCalled(4, testTime, validatorRunID, "s1", testToolCallPayload("call-s1")),
+			},
+			stepID: "s1",
+			seq:    4,
+		},
+		{
+			name: "double_termination",
+			events: []Event{
+				start,
+				FormatStepStarted(2, testTime, validatorRunID, "s1", PhaseDataIngestion),
+				FormatStepFinished(3, testTime, validatorRunID, "s1", PhaseDataIngestion),
+				FormatStepFailed(4, testTime, validatorRunID, "s1", PhaseDataIngestion, "late"),
+			},
+			stepID: "s1",
+			seq:    4,
+		},
+		{
+			name: "llm_for_unknown_step",
+			events: []Event{
+				start,
+				FormatLLMRequested(2, testTime, validatorRunID, "ghost", testLLMRequestPayload("call-ghost")),
+			},
+			stepID: "ghost",
+			seq:    2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations := ValidateEvents(tt.events, ReplayModeInProgress)
+			v := findViolation(t, violations, InvariantStepLifecycle)
+			assert.Equal(t, tt.stepID, v.StepID)
+			assert.Equal(t, tt.seq, v.Seq)
+			assert.Equal(t, validatorRunID, v.RunID)
+		})
+	}
+}
+
+// TestValidateEvents_ModeControlsOpenRuns verifies that an incomplete run is
+// only a violation when the log is expected to be complete.
+func TestValidateEvents_ModeControlsOpenRuns(t *testing.T) {
+	events := []Event{
+		FormatRunStarted(1, testTime, validatorRunID, "/w"),
+		FormatStepStarted(2, testTime, validatorRunID, "s1", PhaseDataIngestion),
+	}
+
+	assert.Empty(t, ValidateEvents(events, ReplayModeInProgress))
+
+	violations := ValidateEvents(events, ReplayModeComplete)
+	require.Len(t, violations, 2)
+	assert.Equal(t, InvariantRunMissingTermination, violations[0].Invariant)
+	assert.Equal(t, InvariantStepLifecycle, violations[1].Invariant)
+	assert.Equal(t, "s1", violations[1].StepID)
+}
+
+// TestInvariant_38_SeqOrdering verifies seq must be positive and increasing.
+func TestInvariant_38_SeqOrdering(t *testing.T) {
+	events := []Event{
+		RunStartedEvent{RunID: validatorRunID, WorkspaceRoot: "/w", Seq: 0, Type: EventTypeRunStarted},
+		FormatStepStarted(5, testTime, validatorRunID, "s1", PhaseDataIngestion),
+		FormatStepFinished(5, testTime, validatorRunID, "s1", PhaseDataIngestion),
+	}
+
+	violations := ValidateEvents(events, ReplayModeInProgress)
+	require.Len(t, violations, 2)
+	assert.Equal(t, InvariantSeqOrdering, violations[0].Invariant)
+	assert.Equal(t, int64(0), violations[0].Seq)
+	assert.Equal(t, InvariantSeqOrdering, violations[1].Invariant)
+	assert.Equal(t, int64(5), violations[1].Seq)
+}
+
+// TestValidateEvents_ForeignRunID verifies events from another run are flagged.
+func TestValidateEvents_ForeignRunID(t *testing.T) {
+	events := []Event{
+		FormatRunStarted(1, testTime, validatorRunID, "/w"),
+		FormatRunFinished(2, testTime, RunID("run-other")),
+	}
+
+	v := findViolation(t, ValidateEvents(events, ReplayModeInProgress), InvariantStepBelongsToRun)
+	assert.Equal(t, int64(2), v.Seq)
+	assert.Equal(t, RunID("run-other"), v.RunID)
+}
+
+// TestValidateLog_ReadsFromDisk verifies the on-disk entry point.
+func TestValidateLog_ReadsFromDisk(t *testing.T) {
+	workspaceRoot := t.TempDir()
+	runID := RunID("test-validate-log-001")
+	writeFullRun(t, runID, workspaceRoot)
+
+	violations, err := ValidateLog(runID, workspaceRoot, ReplayModeComplete)
+	require.NoError(t, err)
+	assert.Empty(t, violations)
+}
+
+// findViolation returns the first violation of the given invariant.
+func findViolation(t *testing.T, violations []Violation, invariant string) Violation {
+	t.Helper()
+	for _, v := range violations {
+		if v.Invariant == invariant {
+			return v
+		}
+	}
+	require.Failf(t, "violation not found", "invariant %s in %v", invariant, violations)
+	return Violation{}
+}
@@ -0,0 +1,166 @@
+package runtime
+
+import (
+	"fmt"
+	"time"
+)
+
+// LLMProvider names the service an LLM call goes to (TRADING.md T80).
+type LLMProvider string
+
+const (
+	LLMProviderOpenAI    LLMProvider = "openai"
+	LLMProviderAnthropic LLMProvider = "anthropic"
+)
+
+// IsValid reports whether the provider is one T80 allows.
+func (p LLMProvider) IsValid() bool {
+	return p == LLMProviderOpenAI || p == LLMProviderAnthropic
+}
+
+const (
+	// llmRequestIDBytesMax bounds a request ID.
+	llmRequestIDBytesMax = 128
+
+	// llmModelBytesMax bounds a model name.
+	llmModelBytesMax = 128
+
+	// llmTokensMax bounds one call's token count, in or out.
+	llmTokensMax = 10_000_000
+
+	// llmCostMicrosMax bounds one call's cost: $10,000 in micro-dollars.
+	llmCostMicrosMax = 10_000 * 1_000_000
+
+	// llmLatencyMax bounds how long one call may be recorded as taking.
+	llmLatencyMax = time.Hour
+)
+
+// LLMRequest describes an LLM call as it is sent.
+type LLMRequest struct {
+	// RequestID identifies the call within its run. The response carries
+	// the same ID.
+	RequestID string
+
+	Provider LLMProvider
+	Model    string
+	Prompt   string
+
+	// EstimatedCostMicros is the expected cost in micro-dollars, logged
+	// before the call so budgets can be enforced (TRADING.md T85).
+	EstimatedCostMicros int64
+}
+
+// LLMResponse describes the outcome of an LLM call.
+type LLMResponse struct {
+	// RequestID is the ID of the request this answers.
+	RequestID string
+
+	Content      string
+	InputTokens  int64
+	OutputTokens int64
+
+	// CostMicros is the billed cost in micro-dollars.
+	CostMicros int64
+
+	// Latency is how long the provider took to answer.
+	Latency time.Duration
+}
+
+// LLMRequestPayload is the llm.requested payload as it is logged.
+type LLMRequestPayload struct {
+	RequestID           string      `json:"request_id"`
+	Provider            LLMProvider `json:"provider"`
+	Model               string      `json:"model"`
+	Prompt              Body        `json:"prompt"`
+	EstimatedCostMicros int64       `json:"estimated_cost_micros"`
+}
+
+// LLMResponsePayload is the llm.responded payload as it is logged.
+type LLMResponsePayload struct {
+	RequestID     string `json:"request_id"`
+	Response      Body   `json:"response"`
+	InputTokens   int64  `json:"input_tokens"`
+	OutputTokens  int64  `json:"output_tokens"`
+	CostMicros    int64  `json:"cost_micros"`
+	LatencyMillis int64  `json:"latency_ms"`
+}
+
+// validate checks a request before it is logged.
+func (r LLMRequest) validate() error {
+	if err := validateRequestID(r.RequestID); err != nil {
+		return err
+	}
+	if !r.Provider.IsValid() {
+		return fmt.Errorf("invalid LLM provider %q", r.Provider)
+	}
+	if r.Model == "" || len(r.Model) > llmModelBytesMax {
+		return fmt.Errorf("model must be 1 to %d bytes", llmModelBytesMax)
+	}
+	if r.Prompt == "" {
+		return fmt.Errorf("prompt must not be empty")
+	}
+	if r.EstimatedCostMicros < 0 || r.EstimatedCostMicros > llmCostMicrosMax {
+		return fmt.Errorf("estimated cost must be in [0, %d] micro-dollars, got %d",
+			llmCostMicrosMax, r.EstimatedCostMicros)
+	}
+	return nil
+}
+
+// validate checks a response before it is logged.
+func (r LLMResponse) validate() error {
+	if err := validateRequestID(r.RequestID); err != nil {
+		return err
+	}
+	if r.InputTokens < 0 || r.InputTokens > llmTokensMax {
+		return fmt.Errorf("input tokens must be in [0, %d], got %d", llmTokensMax, r.InputTokens)
+	}
+	if r.OutputTokens < 0 || r.OutputTokens > llmTokensMax {
+		return fmt.Errorf("output tokens must be in [0, %d], got %d", llmTokensMax, r.OutputTokens)
+	}
+	if r.CostMicros < 0 || r.CostMicros > llmCostMicrosMax {
+		return fmt.Errorf("cost must be in [0, %d] micro-dollars, got %d", llmCostMicrosMax, r.CostMicros)
+	}
+	if r.Latency < 0 || r.Latency > llmLatencyMax {
+		return fmt.Errorf("latency must be in [0, %s], got %s", llmLatencyMax, r.Latency)
+	}
+	return nil
+}
+
+// validateRequestID checks an ID that links events of one call.
+func validateRequestID(id string) error {
+	if id == "" || len(id) > llmRequestIDBytesMax {
+		return fmt.Errorf("request ID must be 1 to %d bytes", llmRequestIDBytesMax)
+	}
+	return nil
+}
+
+// payload stores the prompt as a Body and returns the payload to log.
+func (r LLMRequest) payload(workspaceRoot string, runID RunID) (LLMRequestPayload, error) {
+	prompt, err := newBody(workspaceRoot, runID, r.Prompt)
+	if err != nil {
+		return LLMRequestPayload{}, fmt.Errorf("failed to record prompt: %w", err)
+	}
+	return LLMRequestPayload{
+		RequestID:           r.RequestID,
+		Provider:            r.Provider,
+		Model:               r.Model,
+		Prompt:              prompt,
+		EstimatedCostMicros: r.EstimatedCostMicros,
+	}, nil
+}
+
+// payload stores the content as a Body and returns the payload to log.
+func (r LLMResponse) payload(workspaceRoot string, runID RunID) (LLMResponsePayload, error) {
+	response, err := newBody(workspaceRoot, runID, r.Content)
+	if err != nil {
+		return LLMResponsePayload{}, fmt.Errorf("failed to record response: %w", err)
+	}
+	return LLMResponsePayload{
+		RequestID:     r.RequestID,
+		Response:      response,
+		InputTokens:   r.InputTokens,
+		OutputTokens:  r.OutputTokens,
+		CostMicros:    r.CostMicros,
+		LatencyMillis: r.Latency.Milliseconds(),
+	}, nil
+}
@@ -0,0 +1,216 @@
+package runtime
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testLLMRequest returns a small, valid LLM request.
+func testLLMRequest(requestID string) LLMRequest {
+	return LLMRequest{
+		RequestID:           requestID,
+		Provider:            LLMProviderAnthropic,
+		Model:               "claude-3-opus",
+		Prompt:              "Given the quotes, should we buy, sell, or hold?",
+		EstimatedCostMicros: 1_500,
+	}
+}
+
+// testLLMResponse returns a small, valid LLM response.
+func testLLMResponse(requestID string) LLMResponse {
+	return LLMResponse{
+		RequestID:    requestID,
+		Content:      `{"action":"hold","reasoning":"spread too wide"}`,
+		InputTokens:  120,
+		OutputTokens: 14,
+		CostMicros:   1_320,
+		Latency:      850 * time.Millisecond,
+	}
+}
+
+// testLLMRequestPayload returns the payload testLLMRequest is logged with.
+// The prompt is small enough to inline, so nothing touches the disk.
+func testLLMRequestPayload(requestID string) LLMRequestPayload {
+	payload, err := testLLMRequest(requestID).payload("/w", validatorRunID)
+	if err != nil {
+		panic(err)
+	}
+	return payload
+}
+
+// testLLMResponsePayload returns the payload testLLMResponse is logged with.
+func testLLMResponsePayload(requestID string) LLMResponsePayload {
+	payload, err := testLLMResponse(requestID).payload("/w", validatorRunID)
+	if err != nil {
+		panic(err)
+	}
+	return payload
+}
+
+// TestEventLog_LLMPayloads verifies an LLM call round-trips with its
+// provider, model, tokens, cost, latency, and request ID, and that a
+// large body is stored as an artifact and referenced by path.
+func TestEventLog_LLMPayloads(t *testing.T) {
+	workspaceRoot := t.TempDir()
+	runID := RunID("run-llm-payloads")
+	log, err := OpenEventLog(runID, workspaceRoot, testLogOptions())
+	require.NoError(t, err)
+
+	request := testLLMRequest("call-1")
+	request.Prompt = strings.Repeat("quote AAPL 189.25\n", 1024)
+	response := testLLMResponse("call-1")
+
+	require.NoError(t, log.AppendRunStarted(runID, workspaceRoot))
+	require.NoError(t, log.AppendStepStarted(runID, "s1", PhaseDataIngestion))
+	require.NoError(t, log.AppendLLMRequested(runID, "s1", request))
+	require.NoError(t, log.AppendLLMResponded(runID, "s1", response))
+	require.NoError(t, log.Close())
+
+	events, err := ReadEvents(runID, workspaceRoot)
+	require.NoError(t, err)
+	require.Len(t, events, 4)
+	assert.Empty(t, ValidateEvents(events, ReplayModeInProgress))
+
+	requested := events[2].(LLMRequestedEvent)
+	assert.Equal(t, "call-1", requested.RequestID)
+	assert.Equal(t, LLMProviderAnthropic, requested.Provider)
+	assert.Equal(t, "claude-3-opus", requested.Model)
+	assert.Equal(t, int64(1_500), requested.EstimatedCostMicros)
+	assert.Empty(t, requested.Prompt.Inline, "a large prompt must not be inlined")
+	assert.Equal(t, int64(len(request.Prompt)), requested.Prompt.Size)
+	assert.True(t, strings.HasPrefix(requested.Prompt.Path, ".aiplatform/artifacts/"))
+	prompt, err := NewArtifactStore(workspaceRoot, runID).readBody(requested.Prompt)
+	require.NoError(t, err)
+	assert.Equal(t, request.Prompt, prompt)
+
+	responded := events[3].(LLMRespondedEvent)
+	assert.Equal(t, FormatLLMResponded(4, testTime, runID, "s1", testLLMResponsePayload("call-1")), responded)
+	assert.Equal(t, response.Content, responded.Response.Inline)
+	assert.Equal(t, int64(850), responded.LatencyMillis)
+
+	// A stored body that changes no longer matches its event.
+	require.NoError(t, os.WriteFile(
+		filepath.Join(workspaceRoot, requested.Prompt.Path), []byte("tampered"), 0644))
+	_, err = NewArtifactStore(workspaceRoot, runID).readBody(requested.Prompt)
+	assert.ErrorContains(t, err, "hash")
+
+	// Replay verifies stored bodies like any other artifact.
+	violations, err := ValidateLog(runID, workspaceRoot, ReplayModeInProgress)
+	require.NoError(t, err)
+	v := findViolation(t, violations, InvariantArtifactIntegrity)
+	assert.Equal(t, int64(3), v.Seq)
+	assert.Equal(t, "s1", v.StepID)
+}
+
+// TestEventLog_RejectsInvalidLLMPayloads verifies bad requests and
+// responses are refused before anything is written.
+func TestEventLog_RejectsInvalidLLMPayloads(t *testing.T) {
+	workspaceRoot := t.TempDir()
+	runID := RunID("run-llm-invalid")
+	log, err := OpenEventLog(runID, workspaceRoot, testLogOptions())
+	require.NoError(t, err)
+	defer log.Close()
+
+	requests := map[string]func(*LLMRequest){
+		"no_request_id":  func(r *LLMRequest) { r.RequestID = "" },
+		"bad_provider":   func(r *LLMRequest) { r.Provider = "acme" },
+		"no_model":       func(r *LLMRequest) { r.Model = "" },
+		"no_prompt":      func(r *LLMRequest) { r.Prompt = "" },
+		"negative_cost":  func(r *LLMRequest) { r.EstimatedCostMicros = -1 },
+		"excessive_cost": func(r *LLMRequest) { r.EstimatedCostMicros = llmCostMicrosMax + 1 },
+	}
+	for name, mutate := range requests {
+		request := testLLMRequest("call-1")
+		mutate(&request)
+		assert.Error(t, log.AppendLLMRequested(runID, "s1", request), name)
+	}
+
+	responses := map[string]func(*LLMResponse){
+		"no_request_id":    func(r *LLMResponse) { r.RequestID = "" },
+		"negative_tokens":  func(r *LLMResponse) { r.OutputTokens = -1 },
+		"excessive_tokens": func(r *LLMResponse) { r.InputTokens = llmTokensMax + 1 },
+		"negative_cost":    func(r *LLMResponse) { r.CostMicros = -1 },
+		"negative_latency": func(r *LLMResponse) { r.Latency = -time.Second },
+	}
+	for name, mutate := range responses {
+		response := testLLMResponse("call-1")
+		mutate(&response)
+		assert.Error(t, log.AppendLLMResponded(runID, "s1", response), name)
+	}
+
+	_, err = ReadEvents(runID, workspaceRoot)
+	require.NoError(t, err)
+	info, err := os.Stat(log.Path())
+	require.NoError(t, err)
+	assert.Zero(t, info.Size())
+}
+
+// TestValidateEvents_LLMLifecycle verifies each LLM request gets exactly
+// one response, in the same step, before the step finishes.
+func TestValidateEvents_LLMLifecycle(t *testing.T) {
+	start := FormatRunStarted(1, testTime, validatorRunID, "/w")
+	step := func(seq int64, stepID string) Event {
+		return FormatStepStarted(seq, testTime, validatorRunID, stepID, PhaseDataIngestion)
+	}
+	requested := func(seq int64, stepID string, requestID string) Event {
+		return FormatLLMRequested(seq, testTime, validatorRunID, stepID, testLLMRequestPayload(requestID))
+	}
+	responded := func(seq int64, stepID string, requestID string) Event {
+		return FormatLLMResponded(seq, testTime, validatorRunID, stepID, testLLMResponsePayload(requestID))
+	}
+
+	tests := []struct {
+		name   string
+		events []Event
+		seq    int64
+	}{
+		{
+			name:   "response_without_request",
+			events: []Event{start, step(2, "s1"), responded(3, "s1", "call-1")},
+			seq:    3,
+		},
+		{
+			name: "duplicate_request",
+			events: []Event{start, step(2, "s1"),
+				requested(3, "s1", "call-1"), requested(4, "s1", "call-1")},
+			seq: 4,
+		},
+		{
+			name: "duplicate_response",
+			events: []Event{start, step(2, "s1"), requested(3, "s1", "call-1"),
+				responded(4, "s1", "call-1"), responded(5, "s1", "call-1")},
+			seq: 5,
+		},
+		{
+			name: "response_in_other_step",
+			events: []Event{start, step(2, "s1"), step(3, "s2"),
+				requested(4, "s1", "call-1"), responded(5, "s2", "call-1")},
+			seq: 5,
+		},
+		{
+			name: "step_finished_unanswered",
+			events: []Event{start, step(2, "s1"), requested(3, "s1", "call-1"),
+				FormatStepFinished(4, testTime, validatorRunID, "s1", PhaseDataIngestion)},
+			seq: 4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations := ValidateEvents(tt.events, ReplayModeInProgress)
+			v := findViolation(t, violations, InvariantLLMLifecycle)
+			assert.Equal(t, tt.seq, v.Seq)
+		})
+	}
+
+	// A step that fails may leave a request unanswered.
+	failed := []Event{start, step(2, "s1"), requested(3, "s1", "call-1"),
+		FormatStepFailed(4, testTime, validatorRunID, "s1", PhaseDataIngestion, "timeout")}
+	assert.Empty(t, ValidateEvents(failed, ReplayModeInProgress))
+}
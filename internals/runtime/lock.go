@@ -0,0 +1,102 @@
+package runtime
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"aiplatform/pkg/assert"
+)
+
+// ErrLogLocked means another EventLog, in this process or another, holds
+// a run's log open. Two writers would both resume from the same seq and
+// interleave duplicates, breaking Invariant 38, so the second open fails.
+var ErrLogLocked = errors.New("event log is locked")
+
+// errLockHeld is returned by lockFile when another holder has the lock.
+var errLockHeld = errors.New("lock held")
+
+// lockHolderBytesMax bounds how much of a lock file is read for the PID.
+const lockHolderBytesMax = 32
+
+// logLock is an exclusive advisory lock on a run's log, held by the
+// EventLog from open to close.
+//
+// The lock file is never removed, only unlocked: deleting it while another
+// process waits to lock it would let two processes each hold a lock on a
+// different file. The one exception is a run that failed to start, whose
+// fresh ID no other process can know. A crashed holder's lock is released
+// by the OS.
+type logLock struct {
+	file *os.File
+}
+
+// lockFilePath returns the lock file guarding a run's log.
+func lockFilePath(runID RunID, workspaceRoot string) string {
+	assert.Is_true(runID != RunID(""), "runID must not be empty")
+	return filepath.Join(logDirPath(workspaceRoot), string(runID)+".lock")
+}
+
+// lockRunLog takes the exclusive lock on a run's log without waiting.
+// If it is held, the error wraps ErrLogLocked and names the holder's PID.
+// The log directory must exist.
+func lockRunLog(runID RunID, workspaceRoot string) (*logLock, error) {
+	path := lockFilePath(runID, workspaceRoot)
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file %s: %w", path, err)
+	}
+
+	if err := lockFile(file); err != nil {
+		holder := readLockHolder(file)
+		file.Close()
+		if errors.Is(err, errLockHeld) {
+			return nil, fmt.Errorf("%w: run %s is open in process %s (lock file %s)",
+				ErrLogLocked, runID, holder, path)
+		}
+		return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+	}
+
+	// Record our PID, so a process that fails to lock can name us.
+	pid := []byte(strconv.Itoa(os.Getpid()) + "\n")
+	if err := file.Truncate(0); err == nil {
+		_, err = file.WriteAt(pid, 0)
+	}
+	if err != nil {
+		unlockFile(file)
+		file.Close()
+		return nil, fmt.Errorf("failed to record lock holder in %s: %w", path, err)
+	}
+	return &logLock{file: file}, nil
+}
+
+// readLockHolder returns the PID recorded in a lock file, or "unknown" if
+// the holder has not written it yet.
+func readLockHolder(file *os.File) string {
+	buf := make([]byte, lockHolderBytesMax)
+	n, _ := file.ReadAt(buf, 0)
+	pid, err := strconv.Atoi(string(bytes.TrimSpace(buf[:n])))
+	if err != nil || pid <= 0 {
+		return "unknown"
+	}
+	return strconv.Itoa(pid)
+}
+
+// release unlocks and closes the lock file.
+func (l *logLock) release() error {
+	assert.Not_nil(l, "lock must not be nil")
+	assert.Not_nil(l.file, "lock must be held")
+
+	err := unlockFile(l.file)
+	if closeErr := l.file.Close(); err == nil {
+		err = closeErr
+	}
+	l.file = nil
+	if err != nil {
+		return fmt.Errorf("failed to release log lock: %w", err)
+	}
+	return nil
+}
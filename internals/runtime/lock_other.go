@@ -0,0 +1,30 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package runtime
+
+import (
+	"os"
+	"sync"
+)
+
+// Platforms without flock only exclude EventLogs within this process.
+// Two processes opening the same run's log are not detected here.
+//
+// heldLocks is keyed by lock file path. OpenEventLog may be called from
+// any goroutine, not only the engine's, so the set cannot live in one
+// goroutine; sync.Map's LoadOrStore takes a lock in one atomic step.
+var heldLocks sync.Map
+
+// lockFile marks file's path as locked by this process.
+func lockFile(file *os.File) error {
+	if _, held := heldLocks.LoadOrStore(file.Name(), true); held {
+		return errLockHeld
+	}
+	return nil
+}
+
+// unlockFile releases a lock taken by lockFile.
+func unlockFile(file *os.File) error {
+	heldLocks.Delete(file.Name())
+	return nil
+}
@@ -0,0 +1,127 @@
+package runtime
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestOpenEventLog_LockedByOtherWriter verifies a second open of a run's
+// log fails fast naming the holder's PID, and succeeds once the first log
+// is closed.
+func TestOpenEventLog_LockedByOtherWriter(t *testing.T) {
+	workspaceRoot := t.TempDir()
+	runID := RunID("run-locked")
+
+	log, err := OpenEventLog(runID, workspaceRoot, testLogOptions())
+	require.NoError(t, err)
+	require.NoError(t, log.AppendRunStarted(runID, workspaceRoot))
+
+	_, err = OpenEventLog(runID, workspaceRoot, testLogOptions())
+	require.ErrorIs(t, err, ErrLogLocked)
+	assert.ErrorContains(t, err, "process "+strconv.Itoa(os.Getpid()))
+	_, _, err = recoverRun(runID, workspaceRoot)
+	assert.ErrorIs(t, err, ErrLogLocked)
+
+	// Other runs in the workspace are not affected.
+	other, err := OpenEventLog(RunID("run-other"), workspaceRoot, testLogOptions())
+	require.NoError(t, err)
+	require.NoError(t, other.Close())
+
+	require.NoError(t, log.Close())
+	log, err = OpenEventLog(runID, workspaceRoot, testLogOptions())
+	require.NoError(t, err)
+	require.NoError(t, log.AppendStepStarted(runID, "s1", PhaseDataIngestion))
+	require.NoError(t, log.Close())
+
+	// The lock file stays behind and is not mistaken for a run.
+	assert.FileExists(t, lockFilePath(runID, workspaceRoot))
+	runIDs, err := listLoggedRuns(workspaceRoot)
+	require.NoError(t, err)
+	assert.Equal(t, []RunID{RunID("run-locked"), RunID("run-other")}, runIDs)
+}
+
+// TestOpenEventLog_FailedOpenReleasesLock verifies an open that fails after
+// taking the lock does not leave the run locked.
+func TestOpenEventLog_FailedOpenReleasesLock(t *testing.T) {
+	workspaceRoot := t.TempDir()
+	runID := RunID("run-lock-failed")
+
+	log, err := OpenEventLog(runID, workspaceRoot, testLogOptions())
+	require.NoError(t, err)
+	require.NoError(t, log.AppendRunStarted(runID, workspaceRoot))
+	require.NoError(t, log.Close())
+
+	// A legacy log beside the segments makes the layout ambiguous.
+	legacy := legacyLogFilePath(runID, workspaceRoot)
+	require.NoError(t, os.WriteFile(legacy, nil, 0644))
+	_, err = OpenEventLog(runID, workspaceRoot, testLogOptions())
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrLogLocked)
+
+	require.NoError(t, os.Remove(legacy))
+	log, err = OpenEventLog(runID, workspaceRoot, testLogOptions())
+	require.NoError(t, err)
+	require.NoError(t, log.Close())
+}
+
+// TestRecovery_HoldsLockUntilReopen verifies the lock recovery takes
+// before replaying an incomplete run is the one its reopened log holds,
+// so no other writer can slip in between, and that a terminal run's lock
+// is released.
+func TestRecovery_HoldsLockUntilReopen(t *testing.T) {
+	workspaceRoot := resolvedTempDir(t)
+	finished := RunID("run-lock-finished")
+	crashed := RunID("run-lock-crashed")
+	writeFullRun(t, finished, workspaceRoot)
+	writeIncompleteRun(t, crashed, workspaceRoot)
+
+	reg := newRegistry(testLogOptions())
+	require.NoError(t, recoverIntoRegistry(reg, finished, workspaceRoot, IncompleteRunResume))
+	require.NoError(t, recoverIntoRegistry(reg, crashed, workspaceRoot, IncompleteRunResume))
+	require.Contains(t, reg.logs, crashed)
+
+	_, err := OpenEventLog(crashed, workspaceRoot, testLogOptions())
+	assert.ErrorIs(t, err, ErrLogLocked)
+	log, err := OpenEventLog(finished, workspaceRoot, testLogOptions())
+	require.NoError(t, err)
+	require.NoError(t, log.Close())
+	require.NoError(t, closeRegistryLogs(reg))
+
+	// A run that fails to recover does not stay locked.
+	require.NoError(t, os.WriteFile(legacyLogFilePath(crashed, workspaceRoot), nil, 0644))
+	assert.Error(t, recoverIntoRegistry(newRegistry(testLogOptions()), crashed, workspaceRoot, IncompleteRunResume))
+	require.NoError(t, os.Remove(legacyLogFilePath(crashed, workspaceRoot)))
+	log, err = OpenEventLog(crashed, workspaceRoot, testLogOptions())
+	require.NoError(t, err)
+	require.NoError(t, log.Close())
+}
+
+// TestStartRun_FailureRemovesLockFile verifies a run that fails to start
+// leaves no lock file behind.
+func TestStartRun_FailureRemovesLockFile(t *testing.T) {
+	ctx := context.Background()
+	workspaceRoot := resolvedTempDir(t)
+
+	// Timestamps past year 9999 cannot be encoded, so run.started fails.
+	options := testLogOptions()
+	options.Clock = NewManualClock(time.Date(10000, 1, 1, 0, 0, 0, 0, time.UTC))
+	engine := startEngine(newRegistry(options))
+	_, err := engine.StartRun(ctx, workspaceRoot)
+	require.ErrorContains(t, err, "failed to write run.started")
+	assert.NotContains(t, err.Error(), "failed to remove", "cleanup must succeed")
+	require.NoError(t, engine.Shutdown(ctx))
+
+	locks, err := filepath.Glob(filepath.Join(logDirPath(workspaceRoot), "*.lock"))
+	require.NoError(t, err)
+	assert.Empty(t, locks)
+	runIDs, err := listLoggedRuns(workspaceRoot)
+	require.NoError(t, err)
+	assert.Empty(t, runIDs)
+}
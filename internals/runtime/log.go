@@ -2,116 +2,57 @@ package runtime
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"sync/atomic"
+	"time"
 
 	"aiplatform/pkg/assert"
 )
 
-// Internal request types for typed append operations.
-// Each request corresponds to one event type and carries the minimal payload.
-
-type runStartedRequest struct {
-	runID         RunID
-	workspaceRoot string
-	resultCh      chan<- error
-}
-
-type runFinishedRequest struct {
-	runID    RunID
-	resultCh chan<- error
-}
-
-type runFailedRequest struct {
-	runID    RunID
-	reason   string
-	resultCh chan<- error
-}
-
-type stepStartedRequest struct {
-	runID    RunID
-	stepID   string
-	phase    Phase
-	resultCh chan<- error
-}
-
-type stepFinishedRequest struct {
-	runID    RunID
-	stepID   string
-	phase    Phase
-	resultCh chan<- error
-}
-
-type stepFailedRequest struct {
-	runID    RunID
-	stepID   string
-	phase    Phase
-	reason   string
-	resultCh chan<- error
-}
-
-type llmRequestedRequest struct {
-	runID    RunID
-	stepID   string
-	resultCh chan<- error
-}
-
-type llmRespondedRequest struct {
-	runID    RunID
-	stepID   string
+// appendBatchEventsMax bounds how many queued appends the writer commits
+// with one flush and fsync. It matches the append queue capacity, so a full
+// queue drains in one group commit.
+const appendBatchEventsMax = 64
+
+// appendRequest asks the writer to append one event. The writer assigns
+// the seq and timestamp, then calls format to build the event. format
+// always wraps one of the Format functions, so the formatter stays the
+// only place that sets Type.
+type appendRequest struct {
+	format   func(seq int64, timestamp time.Time) Event
 	resultCh chan<- error
 }
 
-type toolCalledRequest struct {
-	runID    RunID
-	stepID   string
-	toolName string
-	resultCh chan<- error
-}
+// logHead is the position of the last event written to a log, and what
+// validating the log up to it has learned.
+type logHead struct {
+	// lastSeq is the seq of the last event written, or 0 if none.
+	lastSeq int64
 
-type toolReturnedRequest struct {
-	runID    RunID
-	stepID   string
-	toolName string
-	resultCh chan<- error
-}
+	// lastHash is the hash of the line holding lastSeq.
+	lastHash string
 
-type toolFailedRequest struct {
-	runID    RunID
-	stepID   string
-	toolName string
-	reason   string
-	resultCh chan<- error
+	// validation is the state of a validator that has seen every event up
+	// to lastSeq.
+	validation validatorState
 }
 
-type artifactCreatedRequest struct {
-	runID    RunID
-	stepID   string
-	path     string
-	resultCh chan<- error
+// headRequest asks the writer for the log's head.
+type headRequest struct {
+	resultCh chan<- headResult
 }
 
-// appendRequest is a union type for all append requests.
-type appendRequest interface {
-	isAppendRequest()
+// headResult answers a headRequest.
+type headResult struct {
+	head logHead
+	err  error
 }
 
-func (runStartedRequest) isAppendRequest()      {}
-func (runFinishedRequest) isAppendRequest()     {}
-func (runFailedRequest) isAppendRequest()       {}
-func (stepStartedRequest) isAppendRequest()     {}
-func (stepFinishedRequest) isAppendRequest()    {}
-func (stepFailedRequest) isAppendRequest()      {}
-func (llmRequestedRequest) isAppendRequest()    {}
-func (llmRespondedRequest) isAppendRequest()    {}
-func (toolCalledRequest) isAppendRequest()      {}
-func (toolReturnedRequest) isAppendRequest()    {}
-func (toolFailedRequest) isAppendRequest()      {}
-func (artifactCreatedRequest) isAppendRequest() {}
-
 // EventLog is an append-only log of events for a single run.
 // It is safe for concurrent callers; appends are serialized internally
 // by a single writer goroutine (Tiger Beetle principle: single-threaded writes).
@@ -122,19 +63,104 @@ type EventLog struct {
 	// writer provides buffering for writes.
 	writer *bufio.Writer
 
-	// encoder writes JSON to the output.
+	// batch collects the encoded events of one group commit.
+	// Only touched by the writer goroutine.
+	batch *bytes.Buffer
+
+	// payload holds one event's JSON before it is sealed into batch.
+	// Only touched by the writer goroutine.
+	payload *bytes.Buffer
+
+	// encoder writes JSON into payload.
 	encoder *json.Encoder
 
+	// batchEventsMax bounds how many requests one group commit writes.
+	batchEventsMax int
+
 	// nextSeq is the next sequence number to assign.
 	// Only touched by the writer goroutine.
 	nextSeq int64
 
+	// lastHash is the hash of the last line written, which the next line
+	// chains to. Only touched by the writer goroutine.
+	lastHash string
+
+	// sealed is set once the log holds a sealed line; the writer seals
+	// every line it writes. Only touched by the writer goroutine.
+	sealed bool
+
 	// runID identifies which run this log belongs to.
 	runID RunID
 
+	// workspaceRoot is where bodies too large to inline are stored.
+	workspaceRoot string
+
+	// runDir holds the log's segments and their index.
+	runDir string
+
+	// index lists the closed segments.
+	// Only touched by the writer goroutine.
+	index segmentIndex
+
+	// segment is the number of the tail segment, the one appended to.
+	// Only touched by the writer goroutine.
+	segment int
+
+	// segmentFirstSeq is the seq of the tail segment's first event, or 0
+	// while it is empty. Only touched by the writer goroutine.
+	segmentFirstSeq int64
+
+	// segmentBytes and segmentEvents measure the tail segment, to decide
+	// when it rolls over. Only touched by the writer goroutine.
+	segmentBytes  int64
+	segmentEvents int
+
+	// segmentPath is the tail segment's path. It changes when the writer
+	// rolls over, so Path reads it atomically.
+	segmentPath atomic.Pointer[string]
+
+	// options decides when appends are fsynced.
+	options LogOptions
+
+	// tailRepair records the torn final line cut on open, if any.
+	tailRepair *TailRepair
+
+	// lock keeps other EventLogs off this run's files until Close.
+	lock *logLock
+
+	// validator follows every event written. Its order tracker refuses an
+	// append that breaks T11, and a snapshot of the log's head carries its
+	// state. Nil for a reopened log until an order event or a snapshot
+	// needs it. Only touched by the writer goroutine.
+	validator *eventValidator
+
+	// pending holds the events encoded into batch, so a validator loaded
+	// mid-batch also sees those not yet on disk.
+	// Only touched by the writer goroutine.
+	pending []Event
+
+	// unsynced counts events flushed to the OS since the last fsync.
+	// Only touched by the writer goroutine, and by Close after it exits.
+	unsynced int
+
+	// failed is set when a flush or fsync fails. The file may then hold
+	// an event that was never acknowledged, so every later append fails.
+	// Only touched by the writer goroutine, and by Close after it exits.
+	failed error
+
 	// appendCh is the channel for enqueuing append requests.
 	appendCh chan appendRequest
 
+	// subscribeCh registers live subscribers with the writer goroutine.
+	subscribeCh chan subscribeRequest
+
+	// headCh asks the writer goroutine for the log's head.
+	headCh chan headRequest
+
+	// subscribers receive each event after it is written.
+	// Only touched by the writer goroutine.
+	subscribers []*subscriber
+
 	// closeCh signals the writer goroutine to shut down.
 	closeCh chan struct{}
 
@@ -145,135 +171,215 @@ type EventLog struct {
 	closed atomic.Bool
 }
 
-// Open creates or opens an event log file for a run.
+// Open creates or opens the event log of a run.
 //
 // Tiger Beetle Principle: Crash recovery is essential.
-// If the file already exists, we scan to find the last sequence number
-// and resume from there. This allows the engine to recover from crashes
-// and continue appending events with correct sequence numbers.
-func OpenEventLog(runID RunID, workspaceRoot string) (*EventLog, error) {
+// If the log already exists, we cut any final line a crash tore, then
+// scan the tail segment to find the last sequence number and resume from
+// there. This allows the engine to recover from crashes and continue
+// appending events with correct sequence numbers.
+//
+// options decides when appends are fsynced and when the log rolls over to
+// a new segment. Unless it is SyncNone, the directories holding a newly
+// created segment are fsynced too.
+func OpenEventLog(runID RunID, workspaceRoot string, options LogOptions) (*EventLog, error) {
+	return openEventLog(runID, workspaceRoot, options, appendBatchEventsMax)
+}
+
+// openEventLog opens a log whose writer commits up to batchEventsMax
+// requests at once. Benchmarks use a batch of one to measure what group
+// commit saves.
+func openEventLog(runID RunID, workspaceRoot string, options LogOptions,
+	batchEventsMax int) (*EventLog, error) {
+	assert.Is_true(batchEventsMax > 0, "batchEventsMax must be positive")
+
+	if err := options.validate(); err != nil {
+		return nil, err
+	}
+
 	// Construct the log directory path.
-	logDir := filepath.Join(workspaceRoot, ".aiplatform", "logs")
+	logDir := logDirPath(workspaceRoot)
+	_, err := os.Stat(logDir)
+	isNewDir := os.IsNotExist(err)
 
 	// Ensure the log directory exists.
 	if err := os.MkdirAll(logDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create log directory %s: %w", logDir, err)
 	}
 
-	// Construct the full path to the log file.
-	logPath := filepath.Join(logDir, string(runID)+".jsonl")
+	// Take the run's lock before touching its files: a second writer, here
+	// or in another process, would resume from the same seq as this one.
+	lock, err := lockRunLog(runID, workspaceRoot)
+	if err != nil {
+		return nil, err
+	}
+	return startLockedEventLog(runID, workspaceRoot, options, batchEventsMax, isNewDir, lock)
+}
 
-	// Check if file exists and has content.
-	// We need to know if we're resuming an existing run or starting fresh.
-	fileInfo, err := os.Stat(logPath)
-	isNewFile := os.IsNotExist(err) || fileInfo.Size() == 0
+// startLockedEventLog opens a run's log under a lock the caller holds and
+// starts its writer. The log takes the lock over and releases it on
+// close; if opening fails, the lock is released here.
+func startLockedEventLog(runID RunID, workspaceRoot string, options LogOptions,
+	batchEventsMax int, isNewDir bool, lock *logLock) (*EventLog, error) {
+	assert.Not_nil(lock, "lock must not be nil")
 
-	// Open the file for writing.
-	file, err := os.OpenFile(logPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	log, err := openLockedEventLog(runID, workspaceRoot, options, batchEventsMax, isNewDir)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open event log %s: %w", logPath, err)
-	}
-
-	// Determine the starting sequence number.
-	// For a new file, we start at 1.
-	// For an existing file, we scan to find the last sequence number.
-	nextSeq := int64(1)
-	if !isNewFile {
-		// File exists with content - scan to find the last sequence number.
-		// This is critical for crash recovery: we need to resume with
-		// the correct sequence to maintain Invariant 38 (strictly increasing).
-		lastSeq, err := scanLastSeq(file)
-		if err != nil {
-			file.Close()
-			return nil, fmt.Errorf("failed to scan existing log %s: %w", logPath, err)
-		}
-		nextSeq = lastSeq + 1
+		lock.release()
+		return nil, err
 	}
+	log.lock = lock
 
-	// Create a buffered writer for the file.
-	writer := bufio.NewWriterSize(file, 4096)
+	// Start the single writer goroutine.
+	// This is the only goroutine that mutates nextSeq and writes to the log.
+	go log.writerLoop()
 
-	// Create a JSON encoder that writes to our buffered writer.
-	encoder := json.NewEncoder(writer)
+	return log, nil
+}
 
-	// SetEscapeHTML(false) means we don't escape <, >, & as \u003c, etc.
-	encoder.SetEscapeHTML(false)
+// openLockedEventLog opens a run's log once its lock is held. isNewDir
+// reports whether the log directory was just created.
+func openLockedEventLog(runID RunID, workspaceRoot string, options LogOptions,
+	batchEventsMax int, isNewDir bool) (*EventLog, error) {
+	logDir := logDirPath(workspaceRoot)
 
-	log := &EventLog{
-		file:     file,
-		writer:   writer,
-		encoder:  encoder,
-		nextSeq:  nextSeq,
-		runID:    runID,
-		appendCh: make(chan appendRequest, 64), // Buffered for performance
-		closeCh:  make(chan struct{}),
-		doneCh:   make(chan struct{}),
+	// A log written before segmentation becomes the run's first segment.
+	if err := migrateLegacyLog(runID, workspaceRoot, options); err != nil {
+		return nil, err
 	}
 
-	// Start the single writer goroutine.
-	// This is the only goroutine that mutates nextSeq and writes to the log.
-	go log.writerLoop()
+	// Ensure the run's segment directory exists.
+	runDir := runLogDirPath(runID, workspaceRoot)
+	_, err := os.Stat(runDir)
+	isNewRunDir := os.IsNotExist(err)
+	if err := os.MkdirAll(runDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create log directory %s: %w", runDir, err)
+	}
 
+	tail, repair, err := loadRepairedSegments(runID, workspaceRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	// The directories a new segment's entry depends on, innermost first.
+	dirs := []string{runDir}
+	if isNewRunDir {
+		dirs = append(dirs, logDir)
+	}
+	if isNewDir {
+		dirs = append(dirs, filepath.Dir(logDir), workspaceRoot)
+	}
+	file, size, err := openTailSegment(filepath.Join(runDir, segmentName(tail.number)), options, dirs)
+	if err != nil {
+		return nil, err
+	}
+
+	log := newEventLog(runID, workspaceRoot, options, batchEventsMax, tail, file, size)
+	log.tailRepair = repair
 	return log, nil
 }
 
-// scanLastSeq reads an existing log file to find the last sequence number.
-// This enables crash recovery by allowing us to resume appending with
-// the correct next sequence number (Invariant 38).
+// loadRepairedSegments cuts a line torn by a crash from a run's log, then
+// scans its segments.
 //
-// Tiger Beetle Principle: Validate everything during recovery.
-// We parse each line to ensure the log is valid before resuming.
-// If the log is corrupt, we fail fast with a clear error.
-func scanLastSeq(file *os.File) (int64, error) {
-	// We need to read the file, but we opened it with O_APPEND|O_WRONLY.
-	// We can't read from a write-only file handle, so we need to open
-	// a separate read handle for scanning.
-	assert.Not_nil(file, "file must not be nil")
-
-	readFile, err := os.Open(file.Name())
+// For a new log the tail is empty, so appends start at seq 1. For an
+// existing log, the tail segment is scanned for the last sequence number.
+// This is critical for crash recovery: we need to resume with the correct
+// sequence to maintain Invariant 38 (strictly increasing). Closed
+// segments are covered by the index.
+func loadRepairedSegments(runID RunID, workspaceRoot string) (segmentTail, *TailRepair, error) {
+	runDir := runLogDirPath(runID, workspaceRoot)
+
+	// Repair before scanning, so the scan only has to tell good logs from
+	// corrupt ones.
+	repair, err := repairTornTail(runID, workspaceRoot)
 	if err != nil {
-		return 0, fmt.Errorf("failed to open file for reading: %w", err)
+		return segmentTail{}, nil, fmt.Errorf("failed to repair event log %s: %w", runDir, err)
 	}
-	defer readFile.Close()
-
-	scanner := bufio.NewScanner(readFile)
-	var lastSeq int64 = 0
-	var lineNum int
+	tail, err := loadSegments(runDir)
+	if err != nil {
+		return segmentTail{}, nil, fmt.Errorf("failed to scan existing log %s: %w", runDir, err)
+	}
+	return tail, repair, nil
+}
 
-	// Scan the file line by line.
-	// Each line should be a valid JSON object with a "seq" field.
-	for scanner.Scan() {
-		lineNum++
-		line := scanner.Text()
+// openTailSegment opens the tail segment for appending and returns it
+// with its size. If the segment is new, dirs, the directories its entry
+// depends on, are fsynced unless options is SyncNone, so an fsynced event
+// can never sit in a file that vanishes on crash.
+func openTailSegment(segmentPath string, options LogOptions,
+	dirs []string) (*os.File, int64, error) {
+	_, err := os.Stat(segmentPath)
+	isCreated := os.IsNotExist(err)
+	file, err := os.OpenFile(segmentPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to open event log %s: %w", segmentPath, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, 0, fmt.Errorf("failed to stat event log %s: %w", segmentPath, err)
+	}
 
-		// Parse just the seq field for efficiency.
-		// We don't need to parse the full event, just validate seq exists and is valid.
-		var envelope struct {
-			Seq int64 `json:"seq"`
-		}
-		if err := json.Unmarshal([]byte(line), &envelope); err != nil {
-			return 0, fmt.Errorf("line %d: invalid JSON: %w", lineNum, err)
+	if isCreated && options.Sync != SyncNone {
+		if err := syncDirs(dirs...); err != nil {
+			file.Close()
+			return nil, 0, err
 		}
+	}
+	return file, info.Size(), nil
+}
 
-		// Validate sequence is strictly increasing (Invariant 38).
-		if envelope.Seq <= lastSeq {
-			return 0, fmt.Errorf("line %d: sequence number %d is not strictly increasing (previous: %d)",
-				lineNum, envelope.Seq, lastSeq)
-		}
+// newEventLog builds a run's log around its open tail segment, which
+// holds segmentBytes bytes. The caller starts its writer.
+func newEventLog(runID RunID, workspaceRoot string, options LogOptions, batchEventsMax int,
+	tail segmentTail, segment *os.File, segmentBytes int64) *EventLog {
+	// Create a JSON encoder that writes each event into the payload buffer.
+	// Sealed lines collect in the batch buffer, which reaches the buffered
+	// writer in one write per group commit.
+	payload := new(bytes.Buffer)
+	encoder := json.NewEncoder(payload)
 
-		// Pair assertion: validate at read time (also validated at write time)
-		assert.Gt(envelope.Seq, 0, "seq must be positive")
-		assert.Gt(envelope.Seq, lastSeq, "seq must strictly increase")
+	// SetEscapeHTML(false) means we don't escape <, >, & as \u003c, etc.
+	encoder.SetEscapeHTML(false)
 
-		lastSeq = envelope.Seq
+	log := &EventLog{
+		file:            segment,
+		writer:          bufio.NewWriterSize(segment, 4096),
+		batch:           new(bytes.Buffer),
+		payload:         payload,
+		encoder:         encoder,
+		batchEventsMax:  batchEventsMax,
+		nextSeq:         tail.lastSeq + 1,
+		lastHash:        tail.lastHash,
+		sealed:          tail.sealed,
+		runID:           runID,
+		workspaceRoot:   workspaceRoot,
+		runDir:          runLogDirPath(runID, workspaceRoot),
+		index:           tail.index,
+		segment:         tail.number,
+		segmentFirstSeq: tail.firstSeq,
+		segmentBytes:    segmentBytes,
+		segmentEvents:   tail.events,
+		options:         options,
+		appendCh:        make(chan appendRequest, appendBatchEventsMax), // Buffered for performance
+		subscribeCh:     make(chan subscribeRequest),
+		headCh:          make(chan headRequest),
+		closeCh:         make(chan struct{}),
+		doneCh:          make(chan struct{}),
 	}
-
-	if err := scanner.Err(); err != nil {
-		return 0, fmt.Errorf("failed to read log file: %w", err)
+	segmentPath := segment.Name()
+	log.segmentPath.Store(&segmentPath)
+	if tail.lastSeq == 0 {
+		log.validator = newEventValidator(ReplayModeInProgress)
 	}
+	return log
+}
 
-	// If file is empty, lastSeq will be 0, which is correct (next will be 1).
-	return lastSeq, nil
+// logDirPath returns the directory holding every run's event log.
+func logDirPath(workspaceRoot string) string {
+	assert.Not_empty(workspaceRoot, "workspaceRoot must not be empty")
+	return filepath.Join(workspaceRoot, ".aiplatform", "logs")
 }
 
 // writerLoop is the single writer goroutine that processes all append requests.
@@ -283,74 +389,43 @@ func scanLastSeq(file *os.File) (int64, error) {
 // and ensure deterministic ordering without complex locking.
 //
 // The writer assigns Seq and calls the formatter to create fully-formed events.
+// Requests are handled in batches: everything already queued is written with
+// one flush and at most one fsync (group commit).
 func (l *EventLog) writerLoop() {
 	defer close(l.doneCh)
+	defer l.closeSubscribers()
+
+	// Only SyncInterval syncs on a timer; a nil channel never fires.
+	var syncTick <-chan time.Time
+	if l.options.Sync == SyncInterval {
+		ticker := time.NewTicker(l.options.SyncInterval)
+		defer ticker.Stop()
+		syncTick = ticker.C
+	}
 
+	batch := make([]appendRequest, 0, l.batchEventsMax)
 	for {
 		select {
+		case <-syncTick:
+			l.syncPending()
+
+		case req := <-l.subscribeCh:
+			l.registerSubscriber(req)
+
+		case req := <-l.headCh:
+			req.resultCh <- l.currentHead()
+
 		case req := <-l.appendCh:
-			// Process the append request by type
-			err := l.processRequest(req)
-			// Send result back to caller via the request's result channel
-			switch r := req.(type) {
-			case runStartedRequest:
-				r.resultCh <- err
-			case runFinishedRequest:
-				r.resultCh <- err
-			case runFailedRequest:
-				r.resultCh <- err
-			case stepStartedRequest:
-				r.resultCh <- err
-			case stepFinishedRequest:
-				r.resultCh <- err
-			case stepFailedRequest:
-				r.resultCh <- err
-			case llmRequestedRequest:
-				r.resultCh <- err
-			case llmRespondedRequest:
-				r.resultCh <- err
-			case toolCalledRequest:
-				r.resultCh <- err
-			case toolReturnedRequest:
-				r.resultCh <- err
-			case toolFailedRequest:
-				r.resultCh <- err
-			case artifactCreatedRequest:
-				r.resultCh <- err
-			}
+			batch = l.collectBatch(append(batch[:0], req))
+			l.processBatch(batch)
 
 		case <-l.closeCh:
 			// Drain any remaining requests before shutting down
 			for {
 				select {
 				case req := <-l.appendCh:
-					err := l.processRequest(req)
-					switch r := req.(type) {
-					case runStartedRequest:
-						r.resultCh <- err
-					case runFinishedRequest:
-						r.resultCh <- err
-					case runFailedRequest:
-						r.resultCh <- err
-					case stepStartedRequest:
-						r.resultCh <- err
-					case stepFinishedRequest:
-						r.resultCh <- err
-					case stepFailedRequest:
-						r.resultCh <- err
-					case llmRequestedRequest:
-						r.resultCh <- err
-					case llmRespondedRequest:
-						r.resultCh <- err
-					case toolCalledRequest:
-						r.resultCh <- err
-					case toolReturnedRequest:
-						r.resultCh <- err
-					case toolFailedRequest:
-						r.resultCh <- err
-					case artifactCreatedRequest:
-						r.resultCh <- err
-					}
+					batch = l.collectBatch(append(batch[:0], req))
+					l.processBatch(batch)
 				default:
 					// No more requests, we're done
 					return
@@ -360,213 +435,351 @@ func (l *EventLog) writerLoop() {
 	}
 }
 
-// processRequest handles a typed append request by assigning seq,
-// calling the formatter, and encoding the event.
+// collectBatch adds requests that are already queued, without waiting,
+// until the batch holds batchEventsMax requests.
+func (l *EventLog) collectBatch(batch []appendRequest) []appendRequest {
+	assert.Is_true(len(batch) > 0, "batch must start with a request")
+
+	for len(batch) < l.batchEventsMax {
+		select {
+		case req := <-l.appendCh:
+			batch = append(batch, req)
+		default:
+			return batch
+		}
+	}
+	return batch
+}
+
+// processBatch writes a batch of requests and answers each caller.
+//
+// Each request is formatted and encoded on its own, so one bad request
+// only fails itself. The encoded events are then flushed together and
+// fsynced once as the policy demands. A caller is only told its event
+// was written after the flush and fsync covering it succeeded.
 // This is only called from the writer goroutine.
-func (l *EventLog) processRequest(req appendRequest) error {
-	// Precondition assertions
-	assert.Not_nil(l, "EventLog must not be nil")
+func (l *EventLog) processBatch(batch []appendRequest) {
 	assert.Not_nil(l.file, "file must be open")
-	assert.Gt(l.nextSeq, 0, "nextSeq must be positive")
+	assert.Is_true(len(batch) > 0, "batch must not be empty")
+	assert.Is_true(len(batch) <= l.batchEventsMax, "batch must be bounded")
 
-	// Assign the next sequence number.
-	// No atomics needed - we're the only writer.
-	seq := l.nextSeq
-	l.nextSeq++
+	// A full segment rolls over before the next events are written, so a
+	// failed rollover fails them rather than events already acknowledged.
+	if l.failed == nil && l.segmentFull() {
+		l.rollSegment()
+	}
 
-	// Postcondition: seq must be positive (Invariant 38)
-	assert.Gt(seq, 0, "seq must be positive after increment")
-
-	// Call the formatter based on request type to create the fully-formed event.
-	// Formatter is the only place that sets Type.
-	var event Event
-	switch r := req.(type) {
-	case runStartedRequest:
-		evt := FormatRunStarted(seq, r.runID, r.workspaceRoot)
-		event = evt
-	case runFinishedRequest:
-		evt := FormatRunFinished(seq, r.runID)
-		event = evt
-	case runFailedRequest:
-		evt := FormatRunFailed(seq, r.runID, r.reason)
-		event = evt
-	case stepStartedRequest:
-		evt := FormatStepStarted(seq, r.runID, r.stepID, r.phase)
-		event = evt
-	case stepFinishedRequest:
-		evt := FormatStepFinished(seq, r.runID, r.stepID, r.phase)
-		event = evt
-	case stepFailedRequest:
-		evt := FormatStepFailed(seq, r.runID, r.stepID, r.phase, r.reason)
-		event = evt
-	case llmRequestedRequest:
-		evt := FormatLLMRequested(seq, r.runID, r.stepID)
-		event = evt
-	case llmRespondedRequest:
-		evt := FormatLLMResponded(seq, r.runID, r.stepID)
-		event = evt
-	case toolCalledRequest:
-		evt := FormatToolCalled(seq, r.runID, r.stepID, r.toolName)
-		event = evt
-	case toolReturnedRequest:
-		evt := FormatToolReturned(seq, r.runID, r.stepID, r.toolName)
-		event = evt
-	case toolFailedRequest:
-		evt := FormatToolFailed(seq, r.runID, r.stepID, r.toolName, r.reason)
-		event = evt
-	case artifactCreatedRequest:
-		evt := FormatArtifactCreated(seq, r.runID, r.stepID, r.path)
-		event = evt
-	default:
-		return fmt.Errorf("unknown request type: %T", req)
-	}
-
-	// Encode the event as JSON and write to the buffer.
-	// The encoder adds a newline after each event (JSONL format, Invariant 40).
-	if err := l.encoder.Encode(event); err != nil {
-		return fmt.Errorf("failed to encode event: %w", err)
+	errs := make([]error, len(batch))
+	events := make([]Event, len(batch))
+	encoded := 0
+	for i, req := range batch {
+		events[i], errs[i] = l.encodeRequest(req)
+		if errs[i] == nil {
+			encoded++
+		}
 	}
 
-	// Flush the buffer to disk.
-	// Without flushing, data sits in memory and could be lost on crash.
-	// Tiger Beetle would fsync here for durability. For now, we flush
-	// to OS cache. We can add fsync later if needed.
-	if err := l.writer.Flush(); err != nil {
-		return fmt.Errorf("failed to flush event to disk: %w", err)
+	if encoded > 0 {
+		l.flushBatch(encoded)
 	}
 
-	return nil
+	for i, req := range batch {
+		if errs[i] == nil && l.failed != nil {
+			errs[i] = l.failed
+		}
+		// Subscribers only ever see events that reached the file.
+		if errs[i] == nil {
+			l.publish(events[i])
+		}
+		req.resultCh <- errs[i]
+	}
 }
 
-// Typed append methods - these are the only public APIs for appending events.
-// Each method is synchronous and blocks until the event is written or an error occurs.
-//
-// Tiger Beetle Principle: Events are written before state is updated.
-// The caller should write the event, then update in-memory state.
-// If the write fails, the state should NOT be updated.
-//
-// Invariant 35: Append-only - we never modify existing events.
-// Invariant 38: Sequence numbers strictly increase.
-// Invariant 40: JSONLines format - one JSON object per line.
+// encodeRequest assigns the next seq and the clock's current time to a
+// request, formats its event, and encodes it into the pending batch. The
+// seq is only consumed if encoding succeeds, so a rejected request leaves
+// no gap.
+// This is only called from the writer goroutine.
+func (l *EventLog) encodeRequest(req appendRequest) (Event, error) {
+	assert.Gt(l.nextSeq, 0, "nextSeq must be positive")
 
-// AppendRunStarted writes a run.started event.
-func (l *EventLog) AppendRunStarted(runID RunID, workspaceRoot string) error {
-	if l.closed.Load() {
-		return fmt.Errorf("cannot append to closed log")
+	if l.failed != nil {
+		return nil, fmt.Errorf("event log is unusable after an earlier failure: %w", l.failed)
 	}
 
-	resultCh := make(chan error, 1)
-	req := runStartedRequest{
-		runID:         runID,
-		workspaceRoot: workspaceRoot,
-		resultCh:      resultCh,
+	seq := l.nextSeq
+	event := req.format(seq, l.options.Clock.Now())
+	assert.Is_true(headerOf(event).Seq == seq, "formatter must use the assigned seq")
+
+	if err := l.checkOrders(event); err != nil {
+		return nil, err
 	}
 
-	select {
-	case l.appendCh <- req:
-		return <-resultCh
-	case <-l.closeCh:
-		return fmt.Errorf("log is closing")
+	// Encode the event as JSON, then seal it into the batch buffer with
+	// its checksum and chain link. The encoder writes nothing if
+	// marshalling fails; sealing ends the line with a newline (JSONL
+	// format, Invariant 40).
+	defer l.payload.Reset()
+	if err := l.encoder.Encode(event); err != nil {
+		return nil, fmt.Errorf("failed to encode event: %w", err)
+	}
+	encoded := bytes.TrimSuffix(l.payload.Bytes(), []byte("\n"))
+	l.lastHash = sealLine(l.batch, encoded, l.lastHash)
+	l.sealed = true
+	if l.segmentFirstSeq == 0 {
+		l.segmentFirstSeq = seq
 	}
-}
 
-// AppendRunFinished writes a run.finished event.
-func (l *EventLog) AppendRunFinished(runID RunID) error {
-	if l.closed.Load() {
-		return fmt.Errorf("cannot append to closed log")
+	l.pending = append(l.pending, event)
+	if l.validator != nil {
+		l.validator.follow(event)
 	}
 
-	resultCh := make(chan error, 1)
-	req := runFinishedRequest{
-		runID:    runID,
-		resultCh: resultCh,
+	l.nextSeq++
+	// Postcondition: seq strictly increases (Invariant 38)
+	assert.Gt(l.nextSeq, seq, "seq must strictly increase")
+	return event, nil
+}
+
+// checkOrders enforces T11 on an event about to be written: an order
+// event must be a legal transition of its order, and a run must not
+// finish with an order still open.
+// This is only called from the writer goroutine.
+func (l *EventLog) checkOrders(event Event) error {
+	_, finished := event.(RunFinishedEvent)
+	if !finished && !isOrderEvent(event) {
+		return nil
+	}
+	validator, err := l.loadValidator()
+	if err != nil {
+		return err
 	}
+	if !finished {
+		return validator.orders.Check(event)
+	}
+	if open := validator.orders.OpenOrderIDs(); len(open) > 0 {
+		state, _ := validator.orders.Order(open[0])
+		return fmt.Errorf("cannot finish run %s: order %s is still open (%s, T11)",
+			l.runID, open[0], state.Status)
+	}
+	return nil
+}
 
-	select {
-	case l.appendCh <- req:
-		return <-resultCh
-	case <-l.closeCh:
-		return fmt.Errorf("log is closing")
+// currentHead returns the log's head, loading the validator first if the
+// log was reopened. It is only called between batches, so every event
+// written so far is on disk.
+// This is only called from the writer goroutine.
+func (l *EventLog) currentHead() headResult {
+	validator, err := l.loadValidator()
+	if err != nil {
+		return headResult{err: err}
 	}
+	return headResult{head: logHead{
+		lastSeq:    l.nextSeq - 1,
+		lastHash:   l.lastHash,
+		validation: validator.state(),
+	}}
 }
 
-// AppendRunFailed writes a run.failed event.
-func (l *EventLog) AppendRunFailed(runID RunID, reason string) error {
-	if l.closed.Load() {
-		return fmt.Errorf("cannot append to closed log")
+// loadValidator returns the log's validator. A reopened log only scanned
+// its tail, so the first call catches a validator up with the events
+// written so far: those on disk, from the latest usable snapshot if there
+// is one, or else from the whole log, then those still pending in the
+// batch. The log only refuses what breaks T11, so violations are not
+// kept; replay reports them.
+// This is only called from the writer goroutine.
+func (l *EventLog) loadValidator() (*eventValidator, error) {
+	if l.validator != nil {
+		return l.validator, nil
 	}
 
-	resultCh := make(chan error, 1)
-	req := runFailedRequest{
-		runID:    runID,
-		reason:   reason,
-		resultCh: resultCh,
+	resumed, err := resumeFromSnapshot(l.runID, l.workspaceRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load validation state from event log: %w", err)
+	}
+	validator := newEventValidator(ReplayModeInProgress)
+	if resumed != nil {
+		validator = resumed.validator
+		validator.violations = nil
+	} else {
+		err = readRunLog(l.runID, l.workspaceRoot, func(event Event) error {
+			validator.follow(event)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to load validation state from event log: %w", err)
+		}
+	}
+	for _, event := range l.pending {
+		validator.follow(event)
 	}
+	l.validator = validator
+	return validator, nil
+}
 
-	select {
-	case l.appendCh <- req:
-		return <-resultCh
-	case <-l.closeCh:
-		return fmt.Errorf("log is closing")
+// flushBatch writes the encoded batch to the OS, then fsyncs as the
+// policy demands. On failure the log is marked failed.
+// This is only called from the writer goroutine.
+func (l *EventLog) flushBatch(encoded int) {
+	assert.Is_true(encoded > 0, "batch must hold an encoded event")
+	defer func() {
+		l.batch.Reset()
+		l.pending = l.pending[:0]
+	}()
+
+	// Write and flush the batch to the OS.
+	// Without flushing, data sits in memory and could be lost on crash.
+	if _, err := l.writer.Write(l.batch.Bytes()); err != nil {
+		l.failed = fmt.Errorf("failed to write events to disk: %w", err)
+		return
+	}
+	if err := l.writer.Flush(); err != nil {
+		l.failed = fmt.Errorf("failed to flush events to disk: %w", err)
+		return
+	}
+	l.unsynced += encoded
+	l.segmentBytes += int64(l.batch.Len())
+	l.segmentEvents += encoded
+
+	// Then fsync as the policy demands, so the batch survives power loss.
+	switch l.options.Sync {
+	case SyncAlways:
+		l.syncPending()
+	case SyncEveryN:
+		if l.unsynced >= l.options.SyncEveryEvents {
+			l.syncPending()
+		}
 	}
 }
 
-// AppendStepStarted writes a step.started event.
-func (l *EventLog) AppendStepStarted(runID RunID, stepID string, phase Phase) error {
-	if l.closed.Load() {
-		return fmt.Errorf("cannot append to closed log")
+// segmentFull reports whether the tail segment has reached a rollover
+// limit. This is only called from the writer goroutine.
+func (l *EventLog) segmentFull() bool {
+	return l.segmentBytes >= l.options.SegmentBytesMax ||
+		l.segmentEvents >= l.options.SegmentEventsMax
+}
+
+// rollSegment closes the full tail segment and starts the next one.
+//
+// The segment is synced and recorded in the index before the next one is
+// created, so the index only ever lists segments that will not change.
+// A crash in between leaves an index that lists every segment, and the
+// next open starts the missing tail. On failure the log is marked failed.
+// This is only called from the writer goroutine.
+func (l *EventLog) rollSegment() {
+	assert.Is_true(l.segmentEvents > 0, "only a segment holding events rolls over")
+
+	if err := l.file.Sync(); err != nil {
+		l.failed = fmt.Errorf("failed to sync log segment: %w", err)
+		return
 	}
+	l.unsynced = 0
 
-	resultCh := make(chan error, 1)
-	req := stepStartedRequest{
-		runID:    runID,
-		stepID:   stepID,
-		phase:    phase,
-		resultCh: resultCh,
+	if l.segment >= segmentsPerLogMax {
+		l.failed = fmt.Errorf("log %s exceeds %d segments", l.runDir, segmentsPerLogMax)
+		return
+	}
+	index := segmentIndex{Segments: append(slices.Clip(l.index.Segments), segmentEntry{
+		Name:     segmentName(l.segment),
+		FirstSeq: l.segmentFirstSeq,
+		LastSeq:  l.nextSeq - 1,
+		Events:   l.segmentEvents,
+		LastHash: l.lastHash,
+		Sealed:   l.sealed,
+	})}
+	if err := writeSegmentIndex(l.runDir, index); err != nil {
+		l.failed = fmt.Errorf("failed to index log segment: %w", err)
+		return
 	}
+	l.index = index
 
-	select {
-	case l.appendCh <- req:
-		return <-resultCh
-	case <-l.closeCh:
-		return fmt.Errorf("log is closing")
+	path := filepath.Join(l.runDir, segmentName(l.segment+1))
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		l.failed = fmt.Errorf("failed to create log segment %s: %w", path, err)
+		return
 	}
+	if l.options.Sync != SyncNone {
+		if err := syncDirs(l.runDir); err != nil {
+			file.Close()
+			l.failed = err
+			return
+		}
+	}
+	if err := l.file.Close(); err != nil {
+		file.Close()
+		l.failed = fmt.Errorf("failed to close log segment: %w", err)
+		return
+	}
+
+	l.file = file
+	l.writer.Reset(file)
+	l.segment++
+	l.segmentFirstSeq = 0
+	l.segmentBytes = 0
+	l.segmentEvents = 0
+	l.segmentPath.Store(&path)
 }
 
-// AppendStepFinished writes a step.finished event.
-func (l *EventLog) AppendStepFinished(runID RunID, stepID string, phase Phase) error {
-	if l.closed.Load() {
-		return fmt.Errorf("cannot append to closed log")
+// syncPending fsyncs the file if any flushed event is not yet synced.
+// A failed fsync leaves the file contents unknown, so the log is marked
+// failed rather than retried. This is only called from the writer
+// goroutine, and by Close after it exits.
+func (l *EventLog) syncPending() {
+	if l.unsynced == 0 || l.failed != nil {
+		return
+	}
+	if err := l.file.Sync(); err != nil {
+		l.failed = fmt.Errorf("failed to sync event log: %w", err)
+		return
 	}
+	l.unsynced = 0
+}
 
-	resultCh := make(chan error, 1)
-	req := stepFinishedRequest{
-		runID:    runID,
-		stepID:   stepID,
-		phase:    phase,
-		resultCh: resultCh,
+// head returns the position of the last event written, and the state of
+// validating the log up to it. Events appended
+// concurrently may or may not be included; callers that need an exact
+// head must be the log's only appender.
+func (l *EventLog) head() (logHead, error) {
+	if l.closed.Load() {
+		return logHead{}, fmt.Errorf("cannot read the head of a closed log")
 	}
 
+	resultCh := make(chan headResult, 1)
 	select {
-	case l.appendCh <- req:
-		return <-resultCh
+	case l.headCh <- headRequest{resultCh: resultCh}:
+		result := <-resultCh
+		return result.head, result.err
 	case <-l.closeCh:
-		return fmt.Errorf("log is closing")
+		return logHead{}, fmt.Errorf("log is closing")
 	}
 }
 
-// AppendStepFailed writes a step.failed event.
-func (l *EventLog) AppendStepFailed(runID RunID, stepID string, phase Phase, reason string) error {
+// Typed append methods - these are the only public APIs for appending events.
+// Each method is synchronous and blocks until the event is written or an error occurs.
+//
+// Tiger Beetle Principle: Events are written before state is updated.
+// The caller should write the event, then update in-memory state.
+// If the write fails, the state should NOT be updated.
+//
+// Invariant 35: Append-only - we never modify existing events.
+// Invariant 38: Sequence numbers strictly increase.
+// Invariant 40: JSONLines format - one JSON object per line.
+
+// appendEvent queues an event for the writer and blocks until it is
+// written or rejected. format receives the seq and timestamp the writer
+// assigns; E is the concrete event type its formatter returns.
+func appendEvent[E Event](l *EventLog, format func(seq int64, timestamp time.Time) E) error {
+	assert.Not_nil(format, "format must not be nil")
 	if l.closed.Load() {
 		return fmt.Errorf("cannot append to closed log")
 	}
 
 	resultCh := make(chan error, 1)
-	req := stepFailedRequest{
-		runID:    runID,
-		stepID:   stepID,
-		phase:    phase,
-		reason:   reason,
+	req := appendRequest{
+		format: func(seq int64, timestamp time.Time) Event {
+			return format(seq, timestamp)
+		},
 		resultCh: resultCh,
 	}
 
@@ -578,135 +791,242 @@ func (l *EventLog) AppendStepFailed(runID RunID, stepID string, phase Phase, rea
 	}
 }
 
-// AppendLLMRequested writes an llm.requested event.
-func (l *EventLog) AppendLLMRequested(runID RunID, stepID string) error {
-	if l.closed.Load() {
-		return fmt.Errorf("cannot append to closed log")
+// checkStepEventIDs checks the run and step an event is appended under.
+// Formatters assert both on the writer goroutine, where a panic would take
+// down the process, so a bad ID from a caller is refused here instead.
+func (l *EventLog) checkStepEventIDs(runID RunID, stepID string) error {
+	if runID != l.runID {
+		return fmt.Errorf("event belongs to run %q, log is for run %s", runID, l.runID)
 	}
-
-	resultCh := make(chan error, 1)
-	req := llmRequestedRequest{
-		runID:    runID,
-		stepID:   stepID,
-		resultCh: resultCh,
+	if stepID == "" {
+		return fmt.Errorf("stepID must not be empty")
 	}
+	return nil
+}
 
-	select {
-	case l.appendCh <- req:
-		return <-resultCh
-	case <-l.closeCh:
-		return fmt.Errorf("log is closing")
+// AppendRunStarted writes a run.started event.
+func (l *EventLog) AppendRunStarted(runID RunID, workspaceRoot string) error {
+	return appendEvent(l, func(seq int64, timestamp time.Time) RunStartedEvent {
+		return FormatRunStarted(seq, timestamp, runID, workspaceRoot)
+	})
+}
+
+// AppendRunFinished writes a run.finished event.
+func (l *EventLog) AppendRunFinished(runID RunID) error {
+	return appendEvent(l, func(seq int64, timestamp time.Time) RunFinishedEvent {
+		return FormatRunFinished(seq, timestamp, runID)
+	})
+}
+
+// AppendRunFailed writes a run.failed event.
+func (l *EventLog) AppendRunFailed(runID RunID, reason string) error {
+	return appendEvent(l, func(seq int64, timestamp time.Time) RunFailedEvent {
+		return FormatRunFailed(seq, timestamp, runID, reason)
+	})
+}
+
+// AppendStepStarted writes a step.started event.
+func (l *EventLog) AppendStepStarted(runID RunID, stepID string, phase Phase) error {
+	return appendEvent(l, func(seq int64, timestamp time.Time) StepStartedEvent {
+		return FormatStepStarted(seq, timestamp, runID, stepID, phase)
+	})
+}
+
+// AppendStepFinished writes a step.finished event.
+func (l *EventLog) AppendStepFinished(runID RunID, stepID string, phase Phase) error {
+	return appendEvent(l, func(seq int64, timestamp time.Time) StepFinishedEvent {
+		return FormatStepFinished(seq, timestamp, runID, stepID, phase)
+	})
+}
+
+// AppendStepFailed writes a step.failed event.
+func (l *EventLog) AppendStepFailed(runID RunID, stepID string, phase Phase, reason string) error {
+	return appendEvent(l, func(seq int64, timestamp time.Time) StepFailedEvent {
+		return FormatStepFailed(seq, timestamp, runID, stepID, phase, reason)
+	})
+}
+
+// AppendLLMRequested writes an llm.requested event.
+// A prompt too large to inline is stored as an artifact first.
+func (l *EventLog) AppendLLMRequested(runID RunID, stepID string, request LLMRequest) error {
+	if err := l.checkStepEventIDs(runID, stepID); err != nil {
+		return err
+	}
+	if err := request.validate(); err != nil {
+		return err
+	}
+	payload, err := request.payload(l.workspaceRoot, l.runID)
+	if err != nil {
+		return err
 	}
+	return appendEvent(l, func(seq int64, timestamp time.Time) LLMRequestedEvent {
+		return FormatLLMRequested(seq, timestamp, runID, stepID, payload)
+	})
 }
 
 // AppendLLMResponded writes an llm.responded event.
-func (l *EventLog) AppendLLMResponded(runID RunID, stepID string) error {
-	if l.closed.Load() {
-		return fmt.Errorf("cannot append to closed log")
+// A response too large to inline is stored as an artifact first.
+func (l *EventLog) AppendLLMResponded(runID RunID, stepID string, response LLMResponse) error {
+	if err := l.checkStepEventIDs(runID, stepID); err != nil {
+		return err
 	}
-
-	resultCh := make(chan error, 1)
-	req := llmRespondedRequest{
-		runID:    runID,
-		stepID:   stepID,
-		resultCh: resultCh,
+	if err := response.validate(); err != nil {
+		return err
 	}
-
-	select {
-	case l.appendCh <- req:
-		return <-resultCh
-	case <-l.closeCh:
-		return fmt.Errorf("log is closing")
+	payload, err := response.payload(l.workspaceRoot, l.runID)
+	if err != nil {
+		return err
 	}
+	return appendEvent(l, func(seq int64, timestamp time.Time) LLMRespondedEvent {
+		return FormatLLMResponded(seq, timestamp, runID, stepID, payload)
+	})
 }
 
 // AppendToolCalled writes a tool.called event.
-func (l *EventLog) AppendToolCalled(runID RunID, stepID string, toolName string) error {
-	if l.closed.Load() {
-		return fmt.Errorf("cannot append to closed log")
+// Arguments too large to inline are stored as an artifact first.
+func (l *EventLog) AppendToolCalled(runID RunID, stepID string, call ToolCall) error {
+	if err := l.checkStepEventIDs(runID, stepID); err != nil {
+		return err
 	}
-
-	resultCh := make(chan error, 1)
-	req := toolCalledRequest{
-		runID:    runID,
-		stepID:   stepID,
-		toolName: toolName,
-		resultCh: resultCh,
+	if err := call.validate(); err != nil {
+		return err
 	}
-
-	select {
-	case l.appendCh <- req:
-		return <-resultCh
-	case <-l.closeCh:
-		return fmt.Errorf("log is closing")
+	payload, err := call.payload(l.workspaceRoot, l.runID)
+	if err != nil {
+		return err
 	}
+	return appendEvent(l, func(seq int64, timestamp time.Time) ToolCalledEvent {
+		return FormatToolCalled(seq, timestamp, runID, stepID, payload)
+	})
 }
 
 // AppendToolReturned writes a tool.returned event.
-func (l *EventLog) AppendToolReturned(runID RunID, stepID string, toolName string) error {
-	if l.closed.Load() {
-		return fmt.Errorf("cannot append to closed log")
+// A result too large to inline is stored as an artifact first.
+func (l *EventLog) AppendToolReturned(runID RunID, stepID string, result ToolResult) error {
+	if err := l.checkStepEventIDs(runID, stepID); err != nil {
+		return err
+	}
+	if err := result.validate(); err != nil {
+		return err
+	}
+	payload, err := result.payload(l.workspaceRoot, l.runID)
+	if err != nil {
+		return err
 	}
+	return appendEvent(l, func(seq int64, timestamp time.Time) ToolReturnedEvent {
+		return FormatToolReturned(seq, timestamp, runID, stepID, payload)
+	})
+}
 
-	resultCh := make(chan error, 1)
-	req := toolReturnedRequest{
-		runID:    runID,
-		stepID:   stepID,
-		toolName: toolName,
-		resultCh: resultCh,
+// AppendToolFailed writes a tool.failed event.
+func (l *EventLog) AppendToolFailed(runID RunID, stepID string, failure ToolFailure) error {
+	if err := l.checkStepEventIDs(runID, stepID); err != nil {
+		return err
+	}
+	if err := failure.validate(); err != nil {
+		return err
 	}
+	payload := failure.payload()
+	return appendEvent(l, func(seq int64, timestamp time.Time) ToolFailedEvent {
+		return FormatToolFailed(seq, timestamp, runID, stepID, payload)
+	})
+}
 
-	select {
-	case l.appendCh <- req:
-		return <-resultCh
-	case <-l.closeCh:
-		return fmt.Errorf("log is closing")
+// AppendArtifactCreated writes an artifact.created event.
+// The artifact must exist inside the workspace and match its hash.
+func (l *EventLog) AppendArtifactCreated(runID RunID, stepID string, artifact Artifact) error {
+	if err := l.checkStepEventIDs(runID, stepID); err != nil {
+		return err
+	}
+	if err := artifact.validate(); err != nil {
+		return err
+	}
+	if err := NewArtifactStore(l.workspaceRoot, l.runID).Verify(artifact); err != nil {
+		return err
 	}
+	return appendEvent(l, func(seq int64, timestamp time.Time) ArtifactCreatedEvent {
+		return FormatArtifactCreated(seq, timestamp, runID, stepID, artifact)
+	})
 }
 
-// AppendToolFailed writes a tool.failed event.
-func (l *EventLog) AppendToolFailed(runID RunID, stepID string, toolName string, reason string) error {
-	if l.closed.Load() {
-		return fmt.Errorf("cannot append to closed log")
+// AppendSignalGenerated writes a signal.generated event.
+func (l *EventLog) AppendSignalGenerated(runID RunID, stepID string, signal Signal) error {
+	if err := l.checkStepEventIDs(runID, stepID); err != nil {
+		return err
 	}
+	if err := signal.validate(); err != nil {
+		return err
+	}
+	return appendEvent(l, func(seq int64, timestamp time.Time) SignalGeneratedEvent {
+		return FormatSignalGenerated(seq, timestamp, runID, stepID, signal)
+	})
+}
 
-	resultCh := make(chan error, 1)
-	req := toolFailedRequest{
-		runID:    runID,
-		stepID:   stepID,
-		toolName: toolName,
-		reason:   reason,
-		resultCh: resultCh,
+// AppendOrderSubmitted writes an order.submitted event.
+func (l *EventLog) AppendOrderSubmitted(runID RunID, stepID string, order Order) error {
+	if err := l.checkStepEventIDs(runID, stepID); err != nil {
+		return err
+	}
+	if err := order.validate(); err != nil {
+		return err
 	}
+	return appendEvent(l, func(seq int64, timestamp time.Time) OrderSubmittedEvent {
+		return FormatOrderSubmitted(seq, timestamp, runID, stepID, order)
+	})
+}
 
-	select {
-	case l.appendCh <- req:
-		return <-resultCh
-	case <-l.closeCh:
-		return fmt.Errorf("log is closing")
+// AppendOrderAcknowledged writes an order.acknowledged event.
+func (l *EventLog) AppendOrderAcknowledged(runID RunID, stepID string, ack OrderAck) error {
+	if err := l.checkStepEventIDs(runID, stepID); err != nil {
+		return err
+	}
+	if err := ack.validate(); err != nil {
+		return err
 	}
+	return appendEvent(l, func(seq int64, timestamp time.Time) OrderAcknowledgedEvent {
+		return FormatOrderAcknowledged(seq, timestamp, runID, stepID, ack)
+	})
 }
 
-// AppendArtifactCreated writes an artifact.created event.
-func (l *EventLog) AppendArtifactCreated(runID RunID, stepID string, path string) error {
-	if l.closed.Load() {
-		return fmt.Errorf("cannot append to closed log")
+// AppendOrderFilled writes an order.filled event.
+// The fill time is logged in UTC, like event timestamps.
+func (l *EventLog) AppendOrderFilled(runID RunID, stepID string, fill Fill) error {
+	if err := l.checkStepEventIDs(runID, stepID); err != nil {
+		return err
 	}
+	if err := fill.validate(); err != nil {
+		return err
+	}
+	fill.FillTime = fill.FillTime.UTC()
+	return appendEvent(l, func(seq int64, timestamp time.Time) OrderFilledEvent {
+		return FormatOrderFilled(seq, timestamp, runID, stepID, fill)
+	})
+}
 
-	resultCh := make(chan error, 1)
-	req := artifactCreatedRequest{
-		runID:    runID,
-		stepID:   stepID,
-		path:     path,
-		resultCh: resultCh,
+// AppendOrderCancelled writes an order.cancelled event.
+func (l *EventLog) AppendOrderCancelled(runID RunID, stepID string, cancel OrderCancel) error {
+	if err := l.checkStepEventIDs(runID, stepID); err != nil {
+		return err
+	}
+	if err := cancel.validate(); err != nil {
+		return err
 	}
+	return appendEvent(l, func(seq int64, timestamp time.Time) OrderCancelledEvent {
+		return FormatOrderCancelled(seq, timestamp, runID, stepID, cancel)
+	})
+}
 
-	select {
-	case l.appendCh <- req:
-		return <-resultCh
-	case <-l.closeCh:
-		return fmt.Errorf("log is closing")
+// AppendOrderRejected writes an order.rejected event.
+func (l *EventLog) AppendOrderRejected(runID RunID, stepID string, reject OrderReject) error {
+	if err := l.checkStepEventIDs(runID, stepID); err != nil {
+		return err
+	}
+	if err := reject.validate(); err != nil {
+		return err
 	}
+	return appendEvent(l, func(seq int64, timestamp time.Time) OrderRejectedEvent {
+		return FormatOrderRejected(seq, timestamp, runID, stepID, reject)
+	})
 }
 
 // Close finalizes the event log.
@@ -722,6 +1042,8 @@ func (l *EventLog) Close() error {
 	if !l.closed.CompareAndSwap(false, true) {
 		return fmt.Errorf("log already closed")
 	}
+	// Release the run's lock however the close ends; the file is done with.
+	defer l.lock.release()
 
 	// Signal writer goroutine to stop
 	close(l.closeCh)
@@ -731,9 +1053,19 @@ func (l *EventLog) Close() error {
 
 	// Flush any remaining buffered data.
 	if err := l.writer.Flush(); err != nil {
+		l.file.Close()
 		return fmt.Errorf("failed to flush final events: %w", err)
 	}
 
+	// Sync whatever the policy left pending; a clean close loses nothing.
+	if l.options.Sync != SyncNone {
+		l.syncPending()
+	}
+	if l.failed != nil {
+		l.file.Close()
+		return l.failed
+	}
+
 	// Close the file.
 	// This releases the file descriptor and ensures all data is persisted.
 	if err := l.file.Close(); err != nil {
@@ -743,10 +1075,19 @@ func (l *EventLog) Close() error {
 	return nil
 }
 
-// Path returns the file path of the log.
+// Path returns the path of the segment currently appended to.
 // Useful for debugging and error messages.
 func (l *EventLog) Path() string {
 	assert.Not_nil(l, "EventLog must not be nil")
-	assert.Not_nil(l.file, "file must be open")
-	return l.file.Name()
+	return *l.segmentPath.Load()
+}
+
+// TailRepair reports the torn final line cut from the log when it was
+// opened. The second result is false if the log ended cleanly.
+func (l *EventLog) TailRepair() (TailRepair, bool) {
+	assert.Not_nil(l, "EventLog must not be nil")
+	if l.tailRepair == nil {
+		return TailRepair{}, false
+	}
+	return *l.tailRepair, true
 }
@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -24,7 +25,7 @@ func TestEventLog_ConcurrentAppends(t *testing.T) {
 
 	// Open event log
 	runID := RunID("test-concurrent-run-001")
-	log, err := OpenEventLog(runID, workspaceRoot)
+	log, err := OpenEventLog(runID, workspaceRoot, testLogOptions())
 	require.NoError(t, err)
 	defer log.Close()
 
@@ -56,7 +57,7 @@ func TestEventLog_ConcurrentAppends(t *testing.T) {
 	require.NoError(t, err)
 
 	// Verify the log file
-	logPath := filepath.Join(workspaceRoot, ".aiplatform", "logs", string(runID)+".jsonl")
+	logPath := filepath.Join(workspaceRoot, ".aiplatform", "logs", string(runID), "000001.jsonl")
 
 	// Read and validate all events
 	file, err := os.Open(logPath)
@@ -105,7 +106,7 @@ func TestEventLog_CloseWhileAppending(t *testing.T) {
 
 	// Open event log
 	runID := RunID("test-close-run-001")
-	log, err := OpenEventLog(runID, workspaceRoot)
+	log, err := OpenEventLog(runID, workspaceRoot, testLogOptions())
 	require.NoError(t, err)
 
 	// First, write some events successfully to ensure the log works
@@ -171,7 +172,7 @@ func TestEventLog_CloseWhileAppending(t *testing.T) {
 	assert.Contains(t, err.Error(), "closed", "error should mention closed log")
 
 	// Verify the log file has valid content
-	logPath := filepath.Join(workspaceRoot, ".aiplatform", "logs", string(runID)+".jsonl")
+	logPath := filepath.Join(workspaceRoot, ".aiplatform", "logs", string(runID), "000001.jsonl")
 
 	file, err := os.Open(logPath)
 	require.NoError(t, err)
@@ -221,7 +222,7 @@ func TestEventLog_DoubleClose(t *testing.T) {
 	workspaceRoot := t.TempDir()
 
 	runID := RunID("test-double-close-001")
-	log, err := OpenEventLog(runID, workspaceRoot)
+	log, err := OpenEventLog(runID, workspaceRoot, testLogOptions())
 	require.NoError(t, err)
 
 	// First close should succeed
@@ -240,7 +241,7 @@ func TestEventLog_AppendAfterClose(t *testing.T) {
 	workspaceRoot := t.TempDir()
 
 	runID := RunID("test-append-after-close-001")
-	log, err := OpenEventLog(runID, workspaceRoot)
+	log, err := OpenEventLog(runID, workspaceRoot, testLogOptions())
 	require.NoError(t, err)
 
 	// Close the log
@@ -260,7 +261,7 @@ func TestEventLog_SequenceRecovery(t *testing.T) {
 	runID := RunID("test-recovery-run-001")
 
 	// First session: write some events
-	log1, err := OpenEventLog(runID, workspaceRoot)
+	log1, err := OpenEventLog(runID, workspaceRoot, testLogOptions())
 	require.NoError(t, err)
 
 	for i := 0; i < 10; i++ {
@@ -272,7 +273,7 @@ func TestEventLog_SequenceRecovery(t *testing.T) {
 	require.NoError(t, err)
 
 	// Second session: reopen and write more events
-	log2, err := OpenEventLog(runID, workspaceRoot)
+	log2, err := OpenEventLog(runID, workspaceRoot, testLogOptions())
 	require.NoError(t, err)
 
 	for i := 0; i < 5; i++ {
@@ -284,7 +285,7 @@ func TestEventLog_SequenceRecovery(t *testing.T) {
 	require.NoError(t, err)
 
 	// Verify the log has 15 events with correct sequences
-	logPath := filepath.Join(workspaceRoot, ".aiplatform", "logs", string(runID)+".jsonl")
+	logPath := filepath.Join(workspaceRoot, ".aiplatform", "logs", string(runID), "000001.jsonl")
 
 	file, err := os.Open(logPath)
 	require.NoError(t, err)
@@ -312,3 +313,193 @@ func TestEventLog_SequenceRecovery(t *testing.T) {
 	assert.Equal(t, 15, lineCount, "should have 15 events total")
 	assert.Equal(t, int64(15), lastSeq, "last sequence should be 15")
 }
+
+// TestEventLog_SyncPolicies verifies every sync policy writes all events,
+// and that SyncAlways and SyncEveryN fsync when they promise to.
+func TestEventLog_SyncPolicies(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  LogOptions
+		unsynced int // pending fsyncs after 5 appends; -1 when timer driven
+	}{
+		{"always", withLimits(LogOptions{Sync: SyncAlways, Clock: SystemClock{}}), 0},
+		{"every_n", withLimits(LogOptions{Sync: SyncEveryN, Clock: SystemClock{}, SyncEveryEvents: 3}), 2},
+		{"interval", withLimits(LogOptions{Sync: SyncInterval, Clock: SystemClock{}, SyncInterval: time.Millisecond}), -1},
+		{"none", withLimits(LogOptions{Sync: SyncNone, Clock: SystemClock{}}), 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workspaceRoot := t.TempDir()
+			runID := RunID("test-sync-" + tt.name)
+			log, err := OpenEventLog(runID, workspaceRoot, tt.options)
+			require.NoError(t, err)
+
+			for i := 0; i < 5; i++ {
+				require.NoError(t, log.AppendRunStarted(runID, workspaceRoot))
+			}
+			// The writer updated unsynced before acknowledging the last
+			// append, and no timer runs for these policies.
+			if tt.unsynced >= 0 {
+				assert.Equal(t, tt.unsynced, log.unsynced)
+			}
+			require.NoError(t, log.Close())
+
+			events, err := ReadEvents(runID, workspaceRoot)
+			require.NoError(t, err)
+			assert.Len(t, events, 5)
+		})
+	}
+}
+
+// TestEventLog_RejectsInvalidOptions verifies options are validated before
+// any file is created.
+func TestEventLog_RejectsInvalidOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		options LogOptions
+	}{
+		{"zero", LogOptions{}},
+		{"no_clock", LogOptions{Sync: SyncAlways}},
+		{"unknown_policy", LogOptions{Sync: 9, Clock: SystemClock{}}},
+		{"every_n_zero", LogOptions{Sync: SyncEveryN, Clock: SystemClock{}}},
+		{"every_n_too_large", LogOptions{Sync: SyncEveryN, Clock: SystemClock{}, SyncEveryEvents: syncEveryEventsMax + 1}},
+		{"interval_zero", LogOptions{Sync: SyncInterval, Clock: SystemClock{}}},
+		{"interval_too_long", LogOptions{Sync: SyncInterval, Clock: SystemClock{}, SyncInterval: time.Hour}},
+		{"no_segment_limits", LogOptions{Sync: SyncAlways, Clock: SystemClock{}}},
+		{"segment_bytes_too_large", LogOptions{Sync: SyncAlways, Clock: SystemClock{},
+			SegmentBytesMax: segmentBytesLimit + 1, SegmentEventsMax: segmentEventsDefault}},
+		{"segment_events_too_large", LogOptions{Sync: SyncAlways, Clock: SystemClock{},
+			SegmentBytesMax: segmentBytesDefault, SegmentEventsMax: eventsPerLogMax + 1}},
+		{"no_snapshot_interval", LogOptions{Sync: SyncAlways, Clock: SystemClock{},
+			SegmentBytesMax: segmentBytesDefault, SegmentEventsMax: segmentEventsDefault}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workspaceRoot := t.TempDir()
+			log, err := OpenEventLog("test-invalid-options", workspaceRoot, tt.options)
+			assert.Error(t, err)
+			assert.Nil(t, log)
+			assert.NoDirExists(t, logDirPath(workspaceRoot))
+		})
+	}
+}
+
+// TestEventLog_GroupCommitResults verifies a batch is committed in request
+// order with one flush and fsync, and every caller gets its own result.
+func TestEventLog_GroupCommitResults(t *testing.T) {
+	workspaceRoot := t.TempDir()
+	runID := RunID("test-group-commit")
+	options := withLimits(LogOptions{Sync: SyncEveryN, Clock: NewManualClock(testTime), SyncEveryEvents: 3})
+	log, err := OpenEventLog(runID, workspaceRoot, options)
+	require.NoError(t, err)
+
+	results := make([]chan error, 3)
+	for i := range results {
+		results[i] = make(chan error, 1)
+	}
+	batch := []appendRequest{
+		{format: func(seq int64, timestamp time.Time) Event {
+			return FormatStepStarted(seq, timestamp, runID, "s1", PhaseDataIngestion)
+		}, resultCh: results[0]},
+		{format: func(seq int64, timestamp time.Time) Event {
+			return FormatToolCalled(seq, timestamp, runID, "s1", testToolCallPayload("call-s1"))
+		}, resultCh: results[1]},
+		{format: func(seq int64, timestamp time.Time) Event {
+			return FormatToolReturned(seq, timestamp, runID, "s1", testToolResultPayload("call-s1"))
+		}, resultCh: results[2]},
+	}
+
+	// The writer goroutine is idle while no append is queued, so the batch
+	// can be committed directly, exactly as the writer would.
+	log.processBatch(batch)
+	for _, resultCh := range results {
+		assert.NoError(t, <-resultCh)
+	}
+	assert.Equal(t, 0, log.unsynced, "one batch of three must trigger the fsync")
+	assert.Zero(t, log.batch.Len(), "the batch buffer must be reset")
+	require.NoError(t, log.Close())
+
+	events, err := ReadEvents(runID, workspaceRoot)
+	require.NoError(t, err)
+	assert.Equal(t, []Event{
+		FormatStepStarted(1, testTime, runID, "s1", PhaseDataIngestion),
+		FormatToolCalled(2, testTime, runID, "s1", testToolCallPayload("call-s1")),
+		FormatToolReturned(3, testTime, runID, "s1", testToolResultPayload("call-s1")),
+	}, events)
+}
+
+// BenchmarkEventLog_GroupCommit measures concurrent append throughput with
+// one flush and fsync per event (batch of one, the original design)
+// against group commit, under the default and the weakest sync policy.
+func BenchmarkEventLog_GroupCommit(b *testing.B) {
+	benchmarks := []struct {
+		name           string
+		options        LogOptions
+		batchEventsMax int
+	}{
+		{"always/batch_1", withLimits(LogOptions{Sync: SyncAlways, Clock: SystemClock{}}), 1},
+		{"always/batch_64", withLimits(LogOptions{Sync: SyncAlways, Clock: SystemClock{}}), appendBatchEventsMax},
+		{"none/batch_1", withLimits(LogOptions{Sync: SyncNone, Clock: SystemClock{}}), 1},
+		{"none/batch_64", withLimits(LogOptions{Sync: SyncNone, Clock: SystemClock{}}), appendBatchEventsMax},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			workspaceRoot := b.TempDir()
+			runID := RunID("bench-group-commit")
+			log, err := openEventLog(runID, workspaceRoot, bm.options, bm.batchEventsMax)
+			require.NoError(b, err)
+
+			// Bursts of tool and LLM events come from many callers at once.
+			b.SetParallelism(8)
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if err := log.AppendToolCalled(runID, "step-1", testToolCall("call-1")); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+			b.StopTimer()
+
+			require.NoError(b, log.Close())
+		})
+	}
+}
+
+// BenchmarkEventLog_Append measures the cost of one acknowledged append
+// under each sync policy. SyncAlways pays a full fsync per event; the
+// other policies trade a window of possible loss for throughput.
+func BenchmarkEventLog_Append(b *testing.B) {
+	benchmarks := []struct {
+		name    string
+		options LogOptions
+	}{
+		{"always", withLimits(LogOptions{Sync: SyncAlways, Clock: SystemClock{}})},
+		{"every_64", withLimits(LogOptions{Sync: SyncEveryN, Clock: SystemClock{}, SyncEveryEvents: 64})},
+		{"interval_10ms", withLimits(LogOptions{Sync: SyncInterval, Clock: SystemClock{}, SyncInterval: 10 * time.Millisecond})},
+		{"none", withLimits(LogOptions{Sync: SyncNone, Clock: SystemClock{}})},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			workspaceRoot := b.TempDir()
+			runID := RunID("bench-append")
+			log, err := OpenEventLog(runID, workspaceRoot, bm.options)
+			require.NoError(b, err)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := log.AppendToolCalled(runID, "step-1", testToolCall("call-1")); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.StopTimer()
+
+			require.NoError(b, log.Close())
+		})
+	}
+}
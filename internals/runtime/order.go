@@ -0,0 +1,272 @@
+package runtime
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// ordersPerRunMax bounds how many orders one run may submit.
+const ordersPerRunMax = 1024 * 1024
+
+// ErrIllegalOrderTransition is returned for an order event the order's
+// lifecycle does not allow (T11).
+var ErrIllegalOrderTransition = errors.New("illegal order transition")
+
+// OrderStatus is the derived lifecycle state of an order:
+//
+//	submitted -> acknowledged -> partially_filled -> filled
+//
+// An open order may be cancelled at any point, and rejected until its
+// first fill. Acknowledgement is optional: a broker may fill or reject an
+// order it never acknowledged. Filled, cancelled, and rejected are terminal.
+type OrderStatus int
+
+const (
+	OrderStatusSubmitted       OrderStatus = 1
+	OrderStatusAcknowledged    OrderStatus = 2
+	OrderStatusPartiallyFilled OrderStatus = 3
+	OrderStatusFilled          OrderStatus = 4
+	OrderStatusCancelled       OrderStatus = 5
+	OrderStatusRejected        OrderStatus = 6
+)
+
+// String returns the status name used in errors and violations.
+func (s OrderStatus) String() string {
+	switch s {
+	case OrderStatusSubmitted:
+		return "submitted"
+	case OrderStatusAcknowledged:
+		return "acknowledged"
+	case OrderStatusPartiallyFilled:
+		return "partially_filled"
+	case OrderStatusFilled:
+		return "filled"
+	case OrderStatusCancelled:
+		return "cancelled"
+	case OrderStatusRejected:
+		return "rejected"
+	}
+	return fmt.Sprintf("OrderStatus(%d)", int(s))
+}
+
+// IsTerminal reports whether no further event may reference the order.
+func (s OrderStatus) IsTerminal() bool {
+	return s == OrderStatusFilled || s == OrderStatusCancelled || s == OrderStatusRejected
+}
+
+// OrderState is what an OrderTracker knows about one order.
+type OrderState struct {
+	Order         Order       `json:"order"`
+	Status        OrderStatus `json:"status"`
+	BrokerOrderID string      `json:"broker_order_id,omitempty"` // empty until acknowledged
+
+	// FilledQuantity is the sum of the order's fills so far.
+	FilledQuantity int64 `json:"filled_quantity"`
+}
+
+// OrderTracker follows each order of a run through its lifecycle and
+// refuses events that would break T11. It is pure: it does no I/O, and
+// the same events always leave it in the same state.
+//
+// The event log runs one as events are appended, and the validator runs
+// one on replay, so an illegal transition is refused at both ends.
+type OrderTracker struct {
+	orders  map[string]OrderState // keyed by order ID
+	fillIDs map[string]bool
+}
+
+// NewOrderTracker returns a tracker that has seen no orders.
+func NewOrderTracker() *OrderTracker {
+	return &OrderTracker{
+		orders:  make(map[string]OrderState),
+		fillIDs: make(map[string]bool),
+	}
+}
+
+// orderTrackerState is an OrderTracker as a snapshot stores it.
+type orderTrackerState struct {
+	Orders  map[string]OrderState `json:"orders"`
+	FillIDs []string              `json:"fill_ids"`
+}
+
+// MarshalJSON encodes the tracker's state. Fill IDs are sorted, so the
+// same state always encodes the same way.
+func (t *OrderTracker) MarshalJSON() ([]byte, error) {
+	fillIDs := make([]string, 0, len(t.fillIDs))
+	for fillID := range t.fillIDs {
+		fillIDs = append(fillIDs, fillID)
+	}
+	slices.Sort(fillIDs)
+	return json.Marshal(orderTrackerState{Orders: t.orders, FillIDs: fillIDs})
+}
+
+// UnmarshalJSON restores a state MarshalJSON encoded.
+func (t *OrderTracker) UnmarshalJSON(data []byte) error {
+	var state orderTrackerState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+	if len(state.Orders) > ordersPerRunMax {
+		return fmt.Errorf("%d orders, limit is %d", len(state.Orders), ordersPerRunMax)
+	}
+	for orderID, order := range state.Orders {
+		if order.Order.OrderID != orderID {
+			return fmt.Errorf("order %s is stored under %s", order.Order.OrderID, orderID)
+		}
+	}
+
+	*t = *NewOrderTracker()
+	maps.Copy(t.orders, state.Orders)
+	for _, fillID := range state.FillIDs {
+		t.fillIDs[fillID] = true
+	}
+	return nil
+}
+
+// clone returns a tracker in the same state that shares nothing with t.
+func (t *OrderTracker) clone() *OrderTracker {
+	return &OrderTracker{orders: maps.Clone(t.orders), fillIDs: maps.Clone(t.fillIDs)}
+}
+
+// Check reports whether Apply would accept the event, without applying it.
+func (t *OrderTracker) Check(event Event) error {
+	_, err := t.transition(event)
+	return err
+}
+
+// Apply moves the order an event refers to into its next state. Events
+// that are not order events are ignored. An illegal transition returns an
+// error wrapping ErrIllegalOrderTransition and leaves the tracker as it was.
+func (t *OrderTracker) Apply(event Event) error {
+	next, err := t.transition(event)
+	if err != nil || next == nil {
+		return err
+	}
+	if filled, ok := event.(OrderFilledEvent); ok {
+		t.fillIDs[filled.FillID] = true
+	}
+	t.orders[next.Order.OrderID] = *next
+	return nil
+}
+
+// Order returns the state of an order, and false if it was never submitted.
+func (t *OrderTracker) Order(orderID string) (OrderState, bool) {
+	state, ok := t.orders[orderID]
+	return state, ok
+}
+
+// OpenOrderIDs returns the orders not yet in a terminal state, sorted.
+func (t *OrderTracker) OpenOrderIDs() []string {
+	var ids []string
+	for id, state := range t.orders {
+		if !state.Status.IsTerminal() {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// transition returns the state an order event moves its order into, or
+// nil for events that are not order events.
+func (t *OrderTracker) transition(event Event) (*OrderState, error) {
+	switch e := event.(type) {
+	case OrderSubmittedEvent:
+		if _, seen := t.orders[e.OrderID]; seen {
+			return nil, illegalOrderTransition("duplicate %s for order %s", e.Type, e.OrderID)
+		}
+		if len(t.orders) >= ordersPerRunMax {
+			return nil, fmt.Errorf("run has submitted %d orders, limit is %d",
+				len(t.orders), ordersPerRunMax)
+		}
+		return &OrderState{Order: e.Order, Status: OrderStatusSubmitted}, nil
+
+	case OrderAcknowledgedEvent:
+		state, err := t.openOrder(e.OrderID, e.Type)
+		if err != nil {
+			return nil, err
+		}
+		if state.Status != OrderStatusSubmitted {
+			return nil, illegalOrderTransition("%s for order %s that is %s",
+				e.Type, e.OrderID, state.Status)
+		}
+		state.Status = OrderStatusAcknowledged
+		state.BrokerOrderID = e.BrokerOrderID
+		return &state, nil
+
+	case OrderFilledEvent:
+		state, err := t.openOrder(e.OrderID, e.Type)
+		if err != nil {
+			return nil, err
+		}
+		if t.fillIDs[e.FillID] {
+			return nil, illegalOrderTransition("duplicate fill %s for order %s",
+				e.FillID, e.OrderID)
+		}
+		remaining := state.Order.Quantity - state.FilledQuantity
+		if e.Quantity <= 0 || e.Quantity > remaining {
+			return nil, illegalOrderTransition("fill of %d for order %s with %d open",
+				e.Quantity, e.OrderID, remaining)
+		}
+		state.FilledQuantity += e.Quantity
+		state.Status = OrderStatusPartiallyFilled
+		if state.FilledQuantity == state.Order.Quantity {
+			state.Status = OrderStatusFilled
+		}
+		return &state, nil
+
+	case OrderCancelledEvent:
+		state, err := t.openOrder(e.OrderID, e.Type)
+		if err != nil {
+			return nil, err
+		}
+		state.Status = OrderStatusCancelled
+		return &state, nil
+
+	case OrderRejectedEvent:
+		state, err := t.openOrder(e.OrderID, e.Type)
+		if err != nil {
+			return nil, err
+		}
+		if state.Status == OrderStatusPartiallyFilled {
+			return nil, illegalOrderTransition("%s for order %s that is %s",
+				e.Type, e.OrderID, state.Status)
+		}
+		state.Status = OrderStatusRejected
+		return &state, nil
+	}
+	return nil, nil
+}
+
+// openOrder returns the state of a submitted order that has not
+// terminated, for an event of eventType to move on.
+func (t *OrderTracker) openOrder(orderID string, eventType EventType) (OrderState, error) {
+	state, ok := t.orders[orderID]
+	if !ok {
+		return OrderState{}, illegalOrderTransition("%s before %s for order %s",
+			eventType, EventTypeOrderSubmitted, orderID)
+	}
+	if state.Status.IsTerminal() {
+		return OrderState{}, illegalOrderTransition("%s for order %s after it was %s",
+			eventType, orderID, state.Status)
+	}
+	return state, nil
+}
+
+// illegalOrderTransition formats an error wrapping ErrIllegalOrderTransition.
+func illegalOrderTransition(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrIllegalOrderTransition, fmt.Sprintf(format, args...))
+}
+
+// isOrderEvent reports whether an event moves an order's lifecycle.
+func isOrderEvent(event Event) bool {
+	switch event.(type) {
+	case OrderSubmittedEvent, OrderAcknowledgedEvent, OrderFilledEvent,
+		OrderCancelledEvent, OrderRejectedEvent:
+		return true
+	}
+	return false
+}
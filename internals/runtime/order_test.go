@@ -0,0 +1,215 @@
+package runtime
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Order events for tracker and validator tests, all in step s1.
+func submitted(seq int64, orderID string) Event {
+	return FormatOrderSubmitted(seq, testTime, validatorRunID, "s1", testOrder(orderID))
+}
+
+func acknowledged(seq int64, orderID string) Event {
+	return FormatOrderAcknowledged(seq, testTime, validatorRunID, "s1",
+		OrderAck{OrderID: orderID, BrokerOrderID: "B-" + orderID})
+}
+
+func filled(seq int64, orderID string, fillID string, quantity int64) Event {
+	return FormatOrderFilled(seq, testTime, validatorRunID, "s1", testFill(orderID, fillID, quantity))
+}
+
+func cancelled(seq int64, orderID string) Event {
+	return FormatOrderCancelled(seq, testTime, validatorRunID, "s1",
+		OrderCancel{OrderID: orderID, Reason: "expired"})
+}
+
+func rejected(seq int64, orderID string) Event {
+	return FormatOrderRejected(seq, testTime, validatorRunID, "s1",
+		OrderReject{OrderID: orderID, Reason: "halted"})
+}
+
+// TestOrderTracker_LegalLifecycles verifies each way an order may move
+// from submitted to a terminal state.
+func TestOrderTracker_LegalLifecycles(t *testing.T) {
+	tests := []struct {
+		name     string
+		events   []Event
+		status   OrderStatus
+		quantity int64
+	}{
+		{"filled_in_parts", []Event{submitted(1, "o1"), acknowledged(2, "o1"),
+			filled(3, "o1", "f1", 4), filled(4, "o1", "f2", 6)}, OrderStatusFilled, 10},
+		{"filled_unacknowledged", []Event{submitted(1, "o1"), filled(2, "o1", "f1", 10)},
+			OrderStatusFilled, 10},
+		{"partially_filled", []Event{submitted(1, "o1"), acknowledged(2, "o1"),
+			filled(3, "o1", "f1", 4)}, OrderStatusPartiallyFilled, 4},
+		{"cancelled_after_fill", []Event{submitted(1, "o1"), filled(2, "o1", "f1", 4),
+			cancelled(3, "o1")}, OrderStatusCancelled, 4},
+		{"cancelled", []Event{submitted(1, "o1"), cancelled(2, "o1")}, OrderStatusCancelled, 0},
+		{"rejected", []Event{submitted(1, "o1"), acknowledged(2, "o1"), rejected(3, "o1")},
+			OrderStatusRejected, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orders := NewOrderTracker()
+			for _, event := range tt.events {
+				require.NoError(t, orders.Apply(event))
+			}
+			state, ok := orders.Order("o1")
+			require.True(t, ok)
+			assert.Equal(t, tt.status, state.Status)
+			assert.Equal(t, tt.quantity, state.FilledQuantity)
+			assert.Equal(t, testOrder("o1"), state.Order)
+			assert.Equal(t, !tt.status.IsTerminal(), len(orders.OpenOrderIDs()) == 1)
+		})
+	}
+}
+
+// TestOrderTracker_RejectsIllegalTransitions verifies an event its order's
+// lifecycle does not allow is refused and leaves the tracker unchanged.
+func TestOrderTracker_RejectsIllegalTransitions(t *testing.T) {
+	tests := []struct {
+		name   string
+		before []Event
+		event  Event
+	}{
+		{"fill_before_submit", nil, filled(1, "o1", "f1", 10)},
+		{"ack_before_submit", nil, acknowledged(1, "o1")},
+		{"cancel_unknown_order", []Event{submitted(1, "o1")}, cancelled(2, "o2")},
+		{"duplicate_submit", []Event{submitted(1, "o1")}, submitted(2, "o1")},
+		{"duplicate_ack", []Event{submitted(1, "o1"), acknowledged(2, "o1")}, acknowledged(3, "o1")},
+		{"ack_after_fill", []Event{submitted(1, "o1"), filled(2, "o1", "f1", 4)}, acknowledged(3, "o1")},
+		{"overfill", []Event{submitted(1, "o1"), filled(2, "o1", "f1", 4)}, filled(3, "o1", "f2", 7)},
+		{"duplicate_fill", []Event{submitted(1, "o1"), filled(2, "o1", "f1", 4)}, filled(3, "o1", "f1", 4)},
+		{"reject_after_fill", []Event{submitted(1, "o1"), filled(2, "o1", "f1", 4)}, rejected(3, "o1")},
+		{"cancel_after_filled", []Event{submitted(1, "o1"), filled(2, "o1", "f1", 10)}, cancelled(3, "o1")},
+		{"fill_after_cancel", []Event{submitted(1, "o1"), cancelled(2, "o1")}, filled(3, "o1", "f1", 4)},
+		{"ack_after_reject", []Event{submitted(1, "o1"), rejected(2, "o1")}, acknowledged(3, "o1")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orders := NewOrderTracker()
+			for _, event := range tt.before {
+				require.NoError(t, orders.Apply(event))
+			}
+			before, _ := orders.Order("o1")
+
+			assert.ErrorIs(t, orders.Check(tt.event), ErrIllegalOrderTransition)
+			assert.ErrorIs(t, orders.Apply(tt.event), ErrIllegalOrderTransition)
+			after, _ := orders.Order("o1")
+			assert.Equal(t, before, after)
+		})
+	}
+
+	// Events that are not order events do not concern the tracker.
+	assert.NoError(t, NewOrderTracker().Apply(FormatRunFinished(1, testTime, validatorRunID)))
+}
+
+// TestEventLog_RefusesIllegalOrderTransitions verifies the log applies
+// the tracker as events are appended, including to orders it only knows
+// from before it was reopened.
+func TestEventLog_RefusesIllegalOrderTransitions(t *testing.T) {
+	workspaceRoot := t.TempDir()
+	runID := RunID("run-orders")
+	log, err := OpenEventLog(runID, workspaceRoot, testLogOptions())
+	require.NoError(t, err)
+
+	require.NoError(t, log.AppendRunStarted(runID, workspaceRoot))
+	require.NoError(t, log.AppendStepStarted(runID, "s1", PhaseDataIngestion))
+	err = log.AppendOrderFilled(runID, "s1", testFill("o1", "f1", 10))
+	assert.ErrorIs(t, err, ErrIllegalOrderTransition)
+	require.NoError(t, log.AppendOrderSubmitted(runID, "s1", testOrder("o1")))
+	require.NoError(t, log.AppendOrderSubmitted(runID, "s1", testOrder("o2")))
+	require.NoError(t, log.AppendOrderFilled(runID, "s1", testFill("o1", "f1", 10)))
+	require.NoError(t, log.Close())
+
+	log, err = OpenEventLog(runID, workspaceRoot, testLogOptions())
+	require.NoError(t, err)
+	require.NoError(t, log.AppendStepStarted(runID, "s2", PhaseDataIngestion))
+	err = log.AppendOrderCancelled(runID, "s2", OrderCancel{OrderID: "o1", Reason: "expired"})
+	assert.ErrorIs(t, err, ErrIllegalOrderTransition)
+	require.NoError(t, log.AppendOrderCancelled(runID, "s2", OrderCancel{OrderID: "o2", Reason: "expired"}))
+	require.NoError(t, log.Close())
+
+	// Refused events leave no gap in the seq.
+	events, err := ReadEvents(runID, workspaceRoot)
+	require.NoError(t, err)
+	require.Len(t, events, 7)
+	for i, event := range events {
+		assert.Equal(t, int64(i+1), headerOf(event).Seq)
+	}
+	assert.Empty(t, ValidateEvents(events, ReplayModeInProgress))
+}
+
+// TestEventLog_LoadsOrdersFromSnapshot verifies a reopened log rebuilds
+// its orders from the latest snapshot and the events after it, without
+// reading the segments the snapshot covers.
+func TestEventLog_LoadsOrdersFromSnapshot(t *testing.T) {
+	workspaceRoot := t.TempDir()
+	runID := RunID("run-orders-snapshot")
+	options := testLogOptions()
+	options.SegmentEventsMax = 2
+	log, err := OpenEventLog(runID, workspaceRoot, options)
+	require.NoError(t, err)
+	require.NoError(t, log.AppendRunStarted(runID, workspaceRoot))
+	require.NoError(t, log.AppendStepStarted(runID, "s1", PhaseDataIngestion))
+	require.NoError(t, log.AppendOrderSubmitted(runID, "s1", testOrder("o1")))
+	require.NoError(t, log.AppendOrderSubmitted(runID, "s1", testOrder("o2")))
+
+	head, err := log.head()
+	require.NoError(t, err)
+	handle, err := Replay(runID, workspaceRoot)
+	require.NoError(t, err)
+	require.NoError(t, writeSnapshot(log.runDir, newRunSnapshot(handle, head)))
+	require.NoError(t, log.AppendOrderFilled(runID, "s1", testFill("o1", "f1", 10)))
+	require.NoError(t, log.Close())
+
+	// Damage the segments the snapshot covers. Reopening does not read them.
+	for number := 1; number <= 2; number++ {
+		path := segmentFilePath(runID, workspaceRoot, number)
+		require.NoError(t, os.WriteFile(path, []byte("not a log\n"), 0644))
+	}
+
+	log, err = OpenEventLog(runID, workspaceRoot, options)
+	require.NoError(t, err)
+	err = log.AppendOrderFilled(runID, "s1", testFill("o1", "f2", 10))
+	assert.ErrorIs(t, err, ErrIllegalOrderTransition)
+	require.NoError(t, log.AppendOrderCancelled(runID, "s1", OrderCancel{OrderID: "o2", Reason: "expired"}))
+	require.NoError(t, log.Close())
+}
+
+// TestValidateEvents_OrderLifecycle verifies replay reports T11 for an
+// illegal order transition, and for an order a finished run left open.
+func TestValidateEvents_OrderLifecycle(t *testing.T) {
+	start := FormatRunStarted(1, testTime, validatorRunID, "/w")
+	step := FormatStepStarted(2, testTime, validatorRunID, "s1", PhaseDataIngestion)
+
+	events := []Event{start, step, submitted(3, "o1"), filled(4, "o1", "f1", 10), cancelled(5, "o1")}
+	v := findViolation(t, ValidateEvents(events, ReplayModeInProgress), InvariantOrderLifecycle)
+	assert.Equal(t, int64(5), v.Seq)
+	assert.Equal(t, "s1", v.StepID)
+
+	events = []Event{start, step, submitted(3, "o1"), submitted(4, "o2"), filled(5, "o1", "f1", 4),
+		cancelled(6, "o2"),
+		FormatStepFinished(7, testTime, validatorRunID, "s1", PhaseDataIngestion),
+		FormatRunFinished(8, testTime, validatorRunID)}
+	assert.Empty(t, ValidateEvents(events, ReplayModeInProgress))
+	v = findViolation(t, ValidateEvents(events, ReplayModeComplete), InvariantOrderLifecycle)
+	assert.Zero(t, v.Seq)
+	assert.Contains(t, v.Message, "order o1 never terminated (partially_filled)")
+
+	// T11 holds for order events outside a running step too.
+	finished := FormatStepFinished(4, testTime, validatorRunID, "s1", PhaseDataIngestion)
+	events = []Event{start, step, submitted(3, "o1"), finished, filled(5, "o1", "f1", 10),
+		filled(6, "o1", "f2", 10)}
+	violations := ValidateEvents(events, ReplayModeInProgress)
+	v = findViolation(t, violations, InvariantOrderLifecycle)
+	assert.Equal(t, int64(6), v.Seq)
+	assert.Contains(t, v.Message, "for order o1 after it was filled")
+	v = findViolation(t, violations, InvariantStepLifecycle)
+	assert.Equal(t, int64(5), v.Seq)
+}
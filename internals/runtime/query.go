@@ -0,0 +1,183 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"sort"
+
+	"aiplatform/pkg/assert"
+)
+
+// RunState selects runs by lifecycle state in a RunFilter.
+// The zero value matches every state.
+type RunState int
+
+const (
+	RunStateActive   RunState = 1 // no terminal event yet
+	RunStateTerminal RunState = 2 // run.finished or run.failed written
+)
+
+// RunFilter selects runs for ListRuns. Zero-valued fields match everything.
+type RunFilter struct {
+	State RunState
+
+	// Phase matches runs whose current phase equals it.
+	Phase Phase
+
+	// WorkspaceRoot matches runs started in this workspace. It is
+	// normalized the same way StartRun normalizes its argument.
+	WorkspaceRoot string
+}
+
+// GetRunCmd reads a copy of one run's handle.
+type GetRunCmd struct {
+	RunID    RunID
+	ResultCh chan<- GetRunResult
+}
+
+func (GetRunCmd) command() {}
+
+// GetRunResult is the result of reading a run.
+type GetRunResult struct {
+	Run RunHandle
+	Err error
+}
+
+// ListRunsCmd reads copies of every run matching a filter.
+type ListRunsCmd struct {
+	Filter   RunFilter
+	ResultCh chan<- ListRunsResult
+}
+
+func (ListRunsCmd) command() {}
+
+// ListRunsResult is the result of listing runs.
+type ListRunsResult struct {
+	Runs []RunHandle
+	Err  error
+}
+
+// cloneRunHandle returns a deep copy of a handle, so callers outside the
+// run loop can never observe or cause a mutation of engine state.
+func cloneRunHandle(handle *RunHandle) RunHandle {
+	assert.Not_nil(handle, "handle must not be nil")
+
+	clone := *handle
+	clone.Attempts = maps.Clone(handle.Attempts)
+	clone.PhaseDone = maps.Clone(handle.PhaseDone)
+	clone.Steps = maps.Clone(handle.Steps)
+	if handle.TailRepair != nil {
+		repair := *handle.TailRepair
+		clone.TailRepair = &repair
+	}
+	return clone
+}
+
+// matches reports whether a handle satisfies every set field of the filter.
+// The filter's workspace root must already be normalized.
+func (f RunFilter) matches(handle *RunHandle) bool {
+	assert.Not_nil(handle, "handle must not be nil")
+
+	if f.State == RunStateActive && handle.Terminal {
+		return false
+	}
+	if f.State == RunStateTerminal && !handle.Terminal {
+		return false
+	}
+	if f.Phase != 0 && handle.Phase != f.Phase {
+		return false
+	}
+	if f.WorkspaceRoot != "" && handle.WorkspaceRoot != f.WorkspaceRoot {
+		return false
+	}
+	return true
+}
+
+// normalize validates the filter and resolves its workspace root.
+func (f RunFilter) normalize() (RunFilter, error) {
+	if f.State != 0 && f.State != RunStateActive && f.State != RunStateTerminal {
+		return RunFilter{}, fmt.Errorf("invalid run state filter: %d", f.State)
+	}
+	if f.Phase != 0 && !f.Phase.IsValid() {
+		return RunFilter{}, fmt.Errorf("invalid phase filter: %d", f.Phase)
+	}
+	if f.WorkspaceRoot != "" {
+		workspaceRoot, err := normalizeWorkspaceRoot(f.WorkspaceRoot)
+		if err != nil {
+			return RunFilter{}, err
+		}
+		f.WorkspaceRoot = workspaceRoot
+	}
+	return f, nil
+}
+
+// handleGetRun processes a GetRunCmd.
+func (e *Engine) handleGetRun(reg *registry, cmd GetRunCmd) {
+	assert.Not_nil(reg, "registry must not be nil")
+	assert.Not_nil(cmd.ResultCh, "result channel must not be nil")
+
+	handle, exists := reg.runs[cmd.RunID]
+	if !exists {
+		cmd.ResultCh <- GetRunResult{Err: fmt.Errorf("run %s: %w", cmd.RunID, ErrRunNotFound)}
+		return
+	}
+	cmd.ResultCh <- GetRunResult{Run: cloneRunHandle(handle)}
+}
+
+// handleListRuns processes a ListRunsCmd.
+func (e *Engine) handleListRuns(reg *registry, cmd ListRunsCmd) {
+	assert.Not_nil(reg, "registry must not be nil")
+	assert.Not_nil(cmd.ResultCh, "result channel must not be nil")
+
+	filter, err := cmd.Filter.normalize()
+	if err != nil {
+		cmd.ResultCh <- ListRunsResult{Err: err}
+		return
+	}
+
+	runs := make([]RunHandle, 0)
+	for _, handle := range reg.runs {
+		if filter.matches(handle) {
+			runs = append(runs, cloneRunHandle(handle))
+		}
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].ID < runs[j].ID })
+
+	cmd.ResultCh <- ListRunsResult{Runs: runs}
+}
+
+// GetRun returns a copy of the run's handle.
+// Returns an error wrapping ErrRunNotFound if the engine does not know the run.
+func (e *Engine) GetRun(ctx context.Context, runID RunID) (RunHandle, error) {
+	resultCh := make(chan GetRunResult, 1)
+	err := e.submit(ctx, GetRunCmd{
+		RunID:    runID,
+		ResultCh: resultCh,
+	})
+	if err != nil {
+		return RunHandle{}, err
+	}
+	result, err := await(ctx, e, resultCh)
+	if err != nil {
+		return RunHandle{}, err
+	}
+	return result.Run, result.Err
+}
+
+// ListRuns returns copies of every run matching filter, sorted by run ID.
+func (e *Engine) ListRuns(ctx context.Context, filter RunFilter) ([]RunHandle, error) {
+	resultCh := make(chan ListRunsResult, 1)
+	err := e.submit(ctx, ListRunsCmd{
+		Filter:   filter,
+		ResultCh: resultCh,
+	})
+	if err != nil {
+		return nil, err
+	}
+	result, err := await(ctx, e, resultCh)
+	if err != nil {
+		return nil, err
+	}
+	return result.Runs, result.Err
+}
@@ -0,0 +1,106 @@
+package runtime
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGetRun_ReturnsCopy verifies GetRun reflects engine state and that
+// mutating the returned handle does not leak back into the engine.
+func TestGetRun_ReturnsCopy(t *testing.T) {
+	engine, runID, workspaceRoot := startTestRun(t)
+	stepID := finishPhase(t, engine, runID, PhaseDataIngestion)
+
+	run, err := engine.GetRun(context.Background(), runID)
+	require.NoError(t, err)
+	assert.Equal(t, runID, run.ID)
+	assert.Equal(t, workspaceRoot, run.WorkspaceRoot)
+	assert.Equal(t, int64(3), run.LastSeq)
+	assert.Equal(t, StepStatusFinished, run.Steps[stepID].Status)
+
+	run.Terminal = true
+	run.Attempts[PhaseDataIngestion] = 99
+	run.Steps[stepID] = StepState{Phase: PhaseDataIngestion, Status: StepStatusRunning}
+
+	again, err := engine.GetRun(context.Background(), runID)
+	require.NoError(t, err)
+	assert.False(t, again.Terminal)
+	assert.Equal(t, 1, again.Attempts[PhaseDataIngestion])
+	assert.Equal(t, StepStatusFinished, again.Steps[stepID].Status)
+
+	_, err = engine.GetRun(context.Background(), RunID("run-missing"))
+	assert.ErrorIs(t, err, ErrRunNotFound)
+}
+
+// TestListRuns_Filters verifies each filter field narrows the listing and
+// that results are sorted by run ID.
+func TestListRuns_Filters(t *testing.T) {
+	engine := NewEngine()
+	workspaceA := resolvedTempDir(t)
+	workspaceB := resolvedTempDir(t)
+
+	active, err := engine.StartRun(context.Background(), workspaceA)
+	require.NoError(t, err)
+	advanced, err := engine.StartRun(context.Background(), workspaceA)
+	require.NoError(t, err)
+	finishPhase(t, engine, advanced, PhaseDataIngestion)
+	_, err = engine.StartStep(context.Background(), advanced, PhaseSignalGeneration)
+	require.NoError(t, err)
+	failed, err := engine.StartRun(context.Background(), workspaceB)
+	require.NoError(t, err)
+	require.NoError(t, engine.FailRun(context.Background(), failed, "broker unavailable"))
+
+	tests := []struct {
+		name   string
+		filter RunFilter
+		want   []RunID
+	}{
+		{"all", RunFilter{}, []RunID{active, advanced, failed}},
+		{"active", RunFilter{State: RunStateActive}, []RunID{active, advanced}},
+		{"terminal", RunFilter{State: RunStateTerminal}, []RunID{failed}},
+		{"phase", RunFilter{Phase: PhaseSignalGeneration}, []RunID{advanced}},
+		{"workspace", RunFilter{WorkspaceRoot: workspaceB}, []RunID{failed}},
+		{"combined", RunFilter{State: RunStateActive, Phase: PhaseDataIngestion, WorkspaceRoot: workspaceA}, []RunID{active}},
+		{"no_match", RunFilter{State: RunStateTerminal, WorkspaceRoot: workspaceA}, []RunID{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runs, err := engine.ListRuns(context.Background(), tt.filter)
+			require.NoError(t, err)
+
+			ids := make([]RunID, 0, len(runs))
+			for _, run := range runs {
+				ids = append(ids, run.ID)
+			}
+			assert.ElementsMatch(t, tt.want, ids)
+			assert.IsIncreasing(t, ids)
+		})
+	}
+}
+
+// TestListRuns_RejectsInvalidFilter verifies out-of-range filter values
+// are errors rather than silently matching nothing.
+func TestListRuns_RejectsInvalidFilter(t *testing.T) {
+	engine := NewEngine()
+
+	tests := []struct {
+		name   string
+		filter RunFilter
+	}{
+		{"state", RunFilter{State: 7}},
+		{"phase", RunFilter{Phase: Phase(9)}},
+		{"relative_workspace", RunFilter{WorkspaceRoot: "relative"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runs, err := engine.ListRuns(context.Background(), tt.filter)
+			assert.Error(t, err)
+			assert.Nil(t, runs)
+		})
+	}
+}
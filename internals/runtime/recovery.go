@@ -0,0 +1,257 @@
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"aiplatform/pkg/assert"
+	"aiplatform/pkg/validate"
+)
+
+// RunFailedReasonCrashed is the run.failed reason recovery records for
+// runs that were interrupted by a crash and are not resumed.
+const RunFailedReasonCrashed = "crashed"
+
+// runsRecoveredMax bounds how many run logs one workspace may hold.
+// Recovery keeps every run in memory, so the bound is explicit.
+const runsRecoveredMax = 10_000
+
+// IncompleteRunPolicy decides what recovery does with a run whose log has
+// no terminal event (ALGO.md Crash Recovery: "Incomplete runs can be
+// resumed or marked as failed"). The zero value is invalid.
+type IncompleteRunPolicy int
+
+const (
+	// IncompleteRunResume keeps the run open so new commands can continue it.
+	IncompleteRunResume IncompleteRunPolicy = 1
+
+	// IncompleteRunFail fails any running step and the run with reason
+	// "crashed" and keeps the run as terminal.
+	IncompleteRunFail IncompleteRunPolicy = 2
+)
+
+// EngineConfig configures an engine that recovers state from disk.
+// Every field must be set; there are no implicit defaults.
+type EngineConfig struct {
+	// WorkspaceRoot is scanned for run logs under .aiplatform/logs at startup.
+	WorkspaceRoot string
+
+	// IncompleteRuns decides the fate of runs interrupted by a crash.
+	IncompleteRuns IncompleteRunPolicy
+
+	// Log configures every event log the engine opens, including the logs
+	// of recovered runs. DefaultLogOptions is the safe choice.
+	Log LogOptions
+}
+
+// recoverRuns rebuilds the run registry from every event log in the workspace.
+//
+// Each log is decoded, validated against the replay invariants, and folded
+// into a RunHandle. A log that cannot be trusted stops startup: guessing at
+// the state of a trading run is worse than refusing to start.
+//
+// Every run that is still open after the incomplete-run policy is applied
+// gets its log reopened, so the engine can keep appending to it.
+func recoverRuns(config EngineConfig) (*registry, error) {
+	if err := validate.Workspace_root(config.WorkspaceRoot); err != nil {
+		return nil, err
+	}
+	if config.IncompleteRuns != IncompleteRunResume {
+		if config.IncompleteRuns != IncompleteRunFail {
+			return nil, fmt.Errorf("invalid incomplete run policy: %d", config.IncompleteRuns)
+		}
+	}
+	if err := config.Log.validate(); err != nil {
+		return nil, fmt.Errorf("invalid log options: %w", err)
+	}
+
+	workspaceRoot, err := normalizeWorkspaceRoot(config.WorkspaceRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	runIDs, err := listLoggedRuns(workspaceRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	reg := newRegistry(config.Log)
+	for _, runID := range runIDs {
+		if err := recoverIntoRegistry(reg, runID, workspaceRoot, config.IncompleteRuns); err != nil {
+			// The recovery error is already being returned; a close error
+			// here cannot change the outcome.
+			closeRegistryLogs(reg)
+			return nil, fmt.Errorf("failed to recover run %s: %w", runID, err)
+		}
+	}
+
+	assert.Is_true(len(reg.runs) == len(runIDs), "every logged run must be recovered")
+	return reg, nil
+}
+
+// recoverIntoRegistry recovers one run into the registry and reopens its
+// log if it is incomplete. The run's lock is held from replay until the
+// log is reopened, so no other writer can append in between.
+func recoverIntoRegistry(reg *registry, runID RunID, workspaceRoot string,
+	policy IncompleteRunPolicy) error {
+	lock, err := lockRunLog(runID, workspaceRoot)
+	if err != nil {
+		return err
+	}
+	handle, snapshotSeq, err := recoverLockedRun(runID, workspaceRoot)
+	if err != nil {
+		lock.release()
+		return err
+	}
+
+	reg.runs[runID] = handle
+	reg.snapshotSeqs[runID] = snapshotSeq
+	return reopenIncompleteRun(reg, handle, policy, lock)
+}
+
+// listLoggedRuns returns the run IDs of every log in the workspace, both
+// segment directories and single files written before segmentation,
+// sorted so recovery order is deterministic. A missing log directory
+// means no run was ever started here.
+func listLoggedRuns(workspaceRoot string) ([]RunID, error) {
+	assert.Not_empty(workspaceRoot, "workspaceRoot must not be empty")
+
+	logDir := logDirPath(workspaceRoot)
+	entries, err := os.ReadDir(logDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list log directory %s: %w", logDir, err)
+	}
+
+	runIDs := make([]RunID, 0, len(entries))
+	seen := make(map[RunID]bool, len(entries))
+	quarantineDir := filepath.Base(quarantineDirPath(workspaceRoot))
+	for _, entry := range entries {
+		name, isLog := strings.CutSuffix(entry.Name(), segmentFileSuffix)
+		if entry.IsDir() {
+			name, isLog = entry.Name(), entry.Name() != quarantineDir
+		}
+		if !isLog || seen[RunID(name)] {
+			continue
+		}
+		seen[RunID(name)] = true
+		runIDs = append(runIDs, RunID(name))
+	}
+
+	if len(runIDs) > runsRecoveredMax {
+		return nil, fmt.Errorf("workspace holds %d run logs, limit is %d", len(runIDs), runsRecoveredMax)
+	}
+	sort.Slice(runIDs, func(i, j int) bool { return runIDs[i] < runIDs[j] })
+	return runIDs, nil
+}
+
+// recoverLockedRun replays one run's log into a handle, and returns the
+// seq of the snapshot it started from, or 0 if it replayed the whole log.
+// A run without a terminal event comes back marked Incomplete.
+//
+// A final line torn by a crash is cut and quarantined first, and the
+// repair is recorded on the handle. Corruption anywhere else is an error.
+// The caller holds the run's lock throughout, so a run another process
+// has open fails with ErrLogLocked rather than being repaired under its
+// writer.
+func recoverLockedRun(runID RunID, workspaceRoot string) (*RunHandle, int64, error) {
+	assert.Is_true(runID != RunID(""), "runID must not be empty")
+	assert.Not_empty(workspaceRoot, "workspaceRoot must not be empty")
+
+	repair, err := repairTornTail(runID, workspaceRoot)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	handle, snapshotSeq, err := replayFromSnapshot(runID, workspaceRoot)
+	if err != nil {
+		return nil, 0, err
+	}
+	if handle == nil {
+		handle, err = replayFullLog(runID, workspaceRoot)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	if handle.WorkspaceRoot != workspaceRoot {
+		return nil, 0, fmt.Errorf("run was started in workspace %s, found under %s",
+			handle.WorkspaceRoot, workspaceRoot)
+	}
+
+	handle.Incomplete = !handle.Terminal
+	handle.TailRepair = repair
+	return handle, snapshotSeq, nil
+}
+
+// replayFullLog reads, validates, and replays a run's whole log.
+func replayFullLog(runID RunID, workspaceRoot string) (*RunHandle, error) {
+	events, err := ReadEvents(runID, workspaceRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	// The run may have crashed mid-flight, so a missing terminal event is
+	// expected here; everything else must hold.
+	if violations := ValidateEvents(events, ReplayModeInProgress); len(violations) > 0 {
+		return nil, fmt.Errorf("log violates %d invariant(s), first: %s",
+			len(violations), violations[0])
+	}
+	return ReplayEvents(events)
+}
+
+// reopenIncompleteRun reopens the log of an interrupted run and applies the
+// policy. Failing the run goes through the same path as FailRun, so steps
+// left running by the crash are failed before run.failed is written, and
+// the failed run is no longer Incomplete.
+//
+// lock is the run's lock, held since the run was replayed. The reopened
+// log takes it over; a terminal run has no log to reopen and releases it.
+func reopenIncompleteRun(reg *registry, handle *RunHandle, policy IncompleteRunPolicy,
+	lock *logLock) error {
+	assert.Not_nil(reg, "registry must not be nil")
+	assert.Not_nil(handle, "handle must not be nil")
+	assert.Not_nil(lock, "lock must not be nil")
+
+	if handle.Terminal {
+		return lock.release()
+	}
+
+	log, err := startLockedEventLog(handle.ID, handle.WorkspaceRoot, reg.logOptions,
+		appendBatchEventsMax, false, lock)
+	if err != nil {
+		return err
+	}
+	reg.logs[handle.ID] = log
+
+	if policy == IncompleteRunResume {
+		return nil
+	}
+
+	if err := failRun(reg, handle, RunFailedReasonCrashed); err != nil {
+		return err
+	}
+	// The run now ends in run.failed, so it is no longer incomplete.
+	handle.Incomplete = false
+	return nil
+}
+
+// closeRegistryLogs closes and removes every open log in the registry.
+// Every log is closed even if an earlier one fails.
+func closeRegistryLogs(reg *registry) error {
+	assert.Not_nil(reg, "registry must not be nil")
+
+	var errs []error
+	for runID, log := range reg.logs {
+		if err := log.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close log of run %s: %w", runID, err))
+		}
+		delete(reg.logs, runID)
+	}
+	return errors.Join(errs...)
+}
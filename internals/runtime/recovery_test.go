@@ -0,0 +1,229 @@
+package runtime
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeIncompleteRun appends a run that crashed inside its first step.
+func writeIncompleteRun(t *testing.T, runID RunID, workspaceRoot string) {
+	t.Helper()
+
+	log, err := OpenEventLog(runID, workspaceRoot, testLogOptions())
+	require.NoError(t, err)
+	require.NoError(t, log.AppendRunStarted(runID, workspaceRoot))
+	require.NoError(t, log.AppendStepStarted(runID, "step-1", PhaseDataIngestion))
+	require.NoError(t, log.Close())
+}
+
+// recoverRun recovers one run under its lock, as OpenEngine does, and
+// releases the lock again.
+func recoverRun(runID RunID, workspaceRoot string) (*RunHandle, int64, error) {
+	lock, err := lockRunLog(runID, workspaceRoot)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer lock.release()
+	return recoverLockedRun(runID, workspaceRoot)
+}
+
+// resolvedTempDir returns a temp dir with symlinks resolved, matching the
+// normalized workspace root the engine stores in run.started.
+func resolvedTempDir(t *testing.T) string {
+	t.Helper()
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	return dir
+}
+
+// TestRecovery_EmptyWorkspace verifies a workspace with no logs recovers
+// to an empty registry.
+func TestRecovery_EmptyWorkspace(t *testing.T) {
+	workspaceRoot := resolvedTempDir(t)
+
+	reg, err := recoverRuns(EngineConfig{
+		WorkspaceRoot:  workspaceRoot,
+		IncompleteRuns: IncompleteRunResume,
+		Log:            testLogOptions(),
+	})
+	require.NoError(t, err)
+	assert.Empty(t, reg.runs)
+	assert.Empty(t, reg.logs)
+}
+
+// TestRecovery_ResumeIncompleteRuns verifies that finished runs come back
+// terminal and interrupted runs come back open and marked incomplete.
+func TestRecovery_ResumeIncompleteRuns(t *testing.T) {
+	workspaceRoot := resolvedTempDir(t)
+	finished := RunID("run-recovery-finished")
+	crashed := RunID("run-recovery-crashed")
+	writeFullRun(t, finished, workspaceRoot)
+	writeIncompleteRun(t, crashed, workspaceRoot)
+
+	reg, err := recoverRuns(EngineConfig{
+		WorkspaceRoot:  workspaceRoot,
+		IncompleteRuns: IncompleteRunResume,
+		Log:            testLogOptions(),
+	})
+	require.NoError(t, err)
+	runs := reg.runs
+	require.Len(t, runs, 2)
+
+	// Only the open run keeps a log to append to.
+	require.Len(t, reg.logs, 1)
+	require.Contains(t, reg.logs, crashed)
+	closeRegistryLogs(reg)
+
+	assert.True(t, runs[finished].Terminal)
+	assert.False(t, runs[finished].Incomplete)
+
+	assert.False(t, runs[crashed].Terminal)
+	assert.True(t, runs[crashed].Incomplete)
+	assert.Equal(t, int64(2), runs[crashed].LastSeq)
+
+	// Resuming must not write anything to the log.
+	events, err := ReadEvents(crashed, workspaceRoot)
+	require.NoError(t, err)
+	assert.Len(t, events, 2)
+}
+
+// TestRecovery_FailIncompleteRuns verifies that the fail policy fails the
+// running step and the run with reason "crashed", leaving a complete log.
+func TestRecovery_FailIncompleteRuns(t *testing.T) {
+	workspaceRoot := resolvedTempDir(t)
+	crashed := RunID("run-recovery-crashed")
+	writeIncompleteRun(t, crashed, workspaceRoot)
+
+	reg, err := recoverRuns(EngineConfig{
+		WorkspaceRoot:  workspaceRoot,
+		IncompleteRuns: IncompleteRunFail,
+		Log:            testLogOptions(),
+	})
+	require.NoError(t, err)
+	assert.Empty(t, reg.logs, "failed runs must have their log closed")
+
+	handle := reg.runs[crashed]
+	require.NotNil(t, handle)
+	assert.True(t, handle.Terminal)
+	assert.False(t, handle.Incomplete, "a failed run is not incomplete")
+	assert.Equal(t, int64(4), handle.LastSeq)
+	assert.Equal(t, StepStatusFailed, handle.Steps["step-1"].Status)
+
+	events, err := ReadEvents(crashed, workspaceRoot)
+	require.NoError(t, err)
+	require.Len(t, events, 4)
+	assert.Equal(t, FormatStepFailed(3, testTime, crashed, "step-1", PhaseDataIngestion,
+		RunFailedReasonCrashed), events[2])
+	assert.Equal(t, FormatRunFailed(4, testTime, crashed, RunFailedReasonCrashed), events[3])
+	assert.Empty(t, ValidateEvents(events, ReplayModeComplete))
+
+	// A second startup sees a terminal run and must not append again.
+	reg, err = recoverRuns(EngineConfig{
+		WorkspaceRoot:  workspaceRoot,
+		IncompleteRuns: IncompleteRunFail,
+		Log:            testLogOptions(),
+	})
+	require.NoError(t, err)
+	assert.False(t, reg.runs[crashed].Incomplete)
+	assert.Equal(t, int64(4), reg.runs[crashed].LastSeq)
+}
+
+// TestRecovery_RejectsUntrustedLogs verifies startup fails loudly rather
+// than recovering from a log that breaks the replay invariants.
+func TestRecovery_RejectsUntrustedLogs(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{
+			name:    "corrupt_json",
+			content: "{oops\n",
+			wantErr: "invalid JSON",
+		},
+		{
+			name: "event_after_terminal",
+			content: `{"run_id":"run-bad","workspace_root":"WS","seq":1,"type":"run.started"}` + "\n" +
+				`{"run_id":"run-bad","seq":2,"type":"run.finished"}` + "\n" +
+				`{"run_id":"run-bad","seq":3,"type":"run.finished"}` + "\n",
+			wantErr: "invariant 2a.5",
+		},
+		{
+			name:    "foreign_workspace",
+			content: `{"run_id":"run-bad","workspace_root":"/elsewhere","seq":1,"type":"run.started"}` + "\n",
+			wantErr: "started in workspace /elsewhere",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workspaceRoot := resolvedTempDir(t)
+			require.NoError(t, os.MkdirAll(logDirPath(workspaceRoot), 0755))
+			content := []byte(strings.ReplaceAll(tt.content, "WS", workspaceRoot))
+			require.NoError(t, os.WriteFile(legacyLogFilePath("run-bad", workspaceRoot), content, 0644))
+
+			engine, err := OpenEngine(EngineConfig{
+				WorkspaceRoot:  workspaceRoot,
+				IncompleteRuns: IncompleteRunResume,
+				Log:            testLogOptions(),
+			})
+			require.Error(t, err)
+			assert.Nil(t, engine)
+			assert.Contains(t, err.Error(), "run-bad")
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+// TestRecovery_InvalidConfig verifies configuration is validated up front.
+func TestRecovery_InvalidConfig(t *testing.T) {
+	workspaceRoot := resolvedTempDir(t)
+	logOptions := testLogOptions()
+	tests := []struct {
+		name   string
+		config EngineConfig
+	}{
+		{"relative_root", EngineConfig{WorkspaceRoot: "relative", IncompleteRuns: IncompleteRunResume, Log: logOptions}},
+		{"empty_root", EngineConfig{WorkspaceRoot: "", IncompleteRuns: IncompleteRunResume, Log: logOptions}},
+		{"zero_policy", EngineConfig{WorkspaceRoot: workspaceRoot, Log: logOptions}},
+		{"unknown_policy", EngineConfig{WorkspaceRoot: workspaceRoot, IncompleteRuns: 7, Log: logOptions}},
+		{"zero_log_options", EngineConfig{WorkspaceRoot: workspaceRoot, IncompleteRuns: IncompleteRunResume}},
+		{"sync_every_zero", EngineConfig{
+			WorkspaceRoot:  workspaceRoot,
+			IncompleteRuns: IncompleteRunResume,
+			Log:            LogOptions{Sync: SyncEveryN, Clock: SystemClock{}},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine, err := OpenEngine(tt.config)
+			assert.Error(t, err)
+			assert.Nil(t, engine)
+		})
+	}
+}
+
+// TestOpenEngine_AcceptsNewRunsAfterRecovery verifies the engine is usable
+// once recovery completes.
+func TestOpenEngine_AcceptsNewRunsAfterRecovery(t *testing.T) {
+	workspaceRoot := resolvedTempDir(t)
+	writeIncompleteRun(t, RunID("run-recovery-crashed"), workspaceRoot)
+
+	engine, err := OpenEngine(EngineConfig{
+		WorkspaceRoot:  workspaceRoot,
+		IncompleteRuns: IncompleteRunResume,
+		Log:            testLogOptions(),
+	})
+	require.NoError(t, err)
+
+	id, err := engine.StartRun(context.Background(), workspaceRoot)
+	require.NoError(t, err)
+	assert.NotEqual(t, RunID("run-recovery-crashed"), id)
+}
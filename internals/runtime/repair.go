@@ -0,0 +1,200 @@
+package runtime
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"aiplatform/pkg/assert"
+)
+
+// errTornTail ends a log scan at an unterminated final line.
+//
+// The writer terminates every event with a newline and appends whole
+// batches, so a final line without one can only be a write that a crash
+// interrupted. Its event was never acknowledged to any caller.
+var errTornTail = errors.New("unterminated final line")
+
+// quarantineCopiesMax bounds how many torn lines are kept for one offset
+// of one log file. Each is the trace of a separate crash.
+const quarantineCopiesMax = 1000
+
+// TailRepair records a torn final line that was cut from a log on open.
+type TailRepair struct {
+	// LogPath is the repaired log.
+	LogPath string
+
+	// Offset is where the torn line started: the log's size after repair.
+	Offset int64
+
+	// TornBytes is how many bytes were cut.
+	TornBytes int
+
+	// QuarantinePath holds a copy of the cut bytes for inspection.
+	QuarantinePath string
+}
+
+// newLogScanner returns a scanner over complete JSONL lines. An
+// unterminated final line stops the scan with errTornTail, so callers can
+// tell a torn write apart from a corrupt line in the middle of the log.
+func newLogScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), eventLineBytesMax)
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			return i + 1, data[:i], nil
+		}
+		if atEOF && len(data) > 0 {
+			return 0, nil, errTornTail
+		}
+		return 0, nil, nil
+	})
+	return scanner
+}
+
+// quarantineDirPath returns the directory holding bytes cut from logs.
+func quarantineDirPath(workspaceRoot string) string {
+	return filepath.Join(logDirPath(workspaceRoot), "quarantine")
+}
+
+// repairTornTail cuts an unterminated final line from a log.
+//
+// The torn bytes are copied to the quarantine directory and synced before
+// the log is truncated, so nothing is lost if repair itself crashes.
+// Only the end of the tail segment is examined here; a corrupt line
+// anywhere else is left for the scan that follows to reject. Returns nil
+// if the log ends cleanly or has no file yet.
+func repairTornTail(runID RunID, workspaceRoot string) (*TailRepair, error) {
+	logPath, err := tailLogPath(runID, workspaceRoot)
+	if err != nil || logPath == "" {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(logPath, os.O_RDWR, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event log %s for repair: %w", logPath, err)
+	}
+	defer file.Close()
+
+	offset, torn, err := findTornTail(file)
+	if err != nil || torn == nil {
+		return nil, err
+	}
+
+	quarantineDir := quarantineDirPath(workspaceRoot)
+	if err := os.MkdirAll(quarantineDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create quarantine directory %s: %w", quarantineDir, err)
+	}
+	quarantinePath, err := writeQuarantine(quarantineDir, runID, logPath, offset, torn)
+	if err != nil {
+		return nil, err
+	}
+	if err := syncDirs(quarantineDir, logDirPath(workspaceRoot)); err != nil {
+		return nil, err
+	}
+
+	if err := file.Truncate(offset); err != nil {
+		return nil, fmt.Errorf("failed to truncate torn tail of %s: %w", logPath, err)
+	}
+	if err := file.Sync(); err != nil {
+		return nil, fmt.Errorf("failed to sync repaired log %s: %w", logPath, err)
+	}
+
+	return &TailRepair{
+		LogPath:        logPath,
+		Offset:         offset,
+		TornBytes:      len(torn),
+		QuarantinePath: quarantinePath,
+	}, nil
+}
+
+// writeQuarantine stores a torn line cut from a log file at offset in a
+// new file in dir, and returns its path. A line torn at the same offset
+// before is never overwritten: the copy gets the first free name.
+func writeQuarantine(dir string, runID RunID, logPath string, offset int64,
+	torn []byte) (string, error) {
+	for n := 1; n <= quarantineCopiesMax; n++ {
+		path := filepath.Join(dir, quarantineName(runID, logPath, offset, n))
+		err := writeSynced(path, torn)
+		if !errors.Is(err, os.ErrExist) {
+			return path, err
+		}
+	}
+	return "", fmt.Errorf("%d torn lines already quarantined for offset %d of %s",
+		quarantineCopiesMax, offset, logPath)
+}
+
+// quarantineName names the copy of a torn line cut from a log file:
+// <run_id>.<segment>.<offset>.torn, or <run_id>.<offset>.torn for a log
+// written before segmentation. The nth copy for the same offset, after
+// the first, adds ~<n> to the offset.
+func quarantineName(runID RunID, logPath string, offset int64, n int) string {
+	assert.Is_true(n > 0, "copy number must be positive")
+	at := strconv.FormatInt(offset, 10)
+	if n > 1 {
+		at += "~" + strconv.Itoa(n)
+	}
+	segment, _ := strings.CutSuffix(filepath.Base(logPath), segmentFileSuffix)
+	if segment == string(runID) {
+		return fmt.Sprintf("%s.%s.torn", runID, at)
+	}
+	return fmt.Sprintf("%s.%s.%s.torn", runID, segment, at)
+}
+
+// findTornTail returns the offset and bytes of an unterminated final line,
+// or nil bytes if the file ends with a newline or is empty. A torn write
+// is shorter than one line, so only the last eventLineBytesMax bytes are
+// read; a longer unterminated tail is corruption, not a torn write.
+func findTornTail(file *os.File) (int64, []byte, error) {
+	assert.Not_nil(file, "file must not be nil")
+
+	info, err := file.Stat()
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to stat event log: %w", err)
+	}
+	size := info.Size()
+	if size == 0 {
+		return 0, nil, nil
+	}
+
+	start := max(size-eventLineBytesMax, 0)
+	tail := make([]byte, size-start)
+	if _, err := file.ReadAt(tail, start); err != nil {
+		return 0, nil, fmt.Errorf("failed to read event log tail: %w", err)
+	}
+
+	newline := bytes.LastIndexByte(tail, '\n')
+	if newline == len(tail)-1 {
+		return 0, nil, nil
+	}
+	if newline < 0 && start > 0 {
+		return 0, nil, fmt.Errorf("final line exceeds %d bytes without a newline", eventLineBytesMax)
+	}
+
+	offset := start + int64(newline) + 1
+	return offset, tail[newline+1:], nil
+}
+
+// writeSynced writes data to a new file and syncs it before returning.
+// An existing file is left alone, and the error wraps os.ErrExist.
+func writeSynced(path string, data []byte) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to sync %s: %w", path, err)
+	}
+	return file.Close()
+}
@@ -0,0 +1,167 @@
+package runtime
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// appendTornLine simulates a crash mid-write by appending a partial event.
+func appendTornLine(t *testing.T, path string, torn string) int64 {
+	t.Helper()
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	require.NoError(t, err)
+	_, err = file.WriteString(torn)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+	return info.Size()
+}
+
+// TestOpenEventLog_RepairsTornTail verifies a partial final line is cut,
+// quarantined, and reported, and that appends resume with the next seq.
+func TestOpenEventLog_RepairsTornTail(t *testing.T) {
+	workspaceRoot := t.TempDir()
+	runID := RunID("run-torn-tail")
+	writeIncompleteRun(t, runID, workspaceRoot)
+
+	path := segmentFilePath(runID, workspaceRoot, 1)
+	torn := `{"seq":3,"type":"step.fin`
+	offset := appendTornLine(t, path, torn)
+
+	log, err := OpenEventLog(runID, workspaceRoot, testLogOptions())
+	require.NoError(t, err)
+	repair, repaired := log.TailRepair()
+	require.True(t, repaired)
+	assert.Equal(t, path, repair.LogPath)
+	assert.Equal(t, offset, repair.Offset)
+	assert.Equal(t, len(torn), repair.TornBytes)
+
+	quarantined, err := os.ReadFile(repair.QuarantinePath)
+	require.NoError(t, err)
+	assert.Equal(t, torn, string(quarantined))
+
+	require.NoError(t, log.AppendStepFinished(runID, "step-1", PhaseDataIngestion))
+	require.NoError(t, log.Close())
+
+	events, err := ReadEvents(runID, workspaceRoot)
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	assert.Equal(t, FormatStepFinished(3, testTime, runID, "step-1", PhaseDataIngestion), events[2])
+
+	// A clean log reports no repair.
+	log, err = OpenEventLog(runID, workspaceRoot, testLogOptions())
+	require.NoError(t, err)
+	defer log.Close()
+	_, repaired = log.TailRepair()
+	assert.False(t, repaired)
+}
+
+// TestOpenEventLog_KeepsEveryQuarantinedTail verifies a second line torn
+// at the same offset is quarantined beside the first, not over it.
+func TestOpenEventLog_KeepsEveryQuarantinedTail(t *testing.T) {
+	workspaceRoot := t.TempDir()
+	runID := RunID("run-torn-twice")
+	writeIncompleteRun(t, runID, workspaceRoot)
+	path := segmentFilePath(runID, workspaceRoot, 1)
+
+	var repairs []TailRepair
+	for _, torn := range []string{`{"seq":3,"type":"step.fin`, `{"seq":3,"ty`} {
+		appendTornLine(t, path, torn)
+		log, err := OpenEventLog(runID, workspaceRoot, testLogOptions())
+		require.NoError(t, err)
+		repair, repaired := log.TailRepair()
+		require.True(t, repaired)
+		repairs = append(repairs, repair)
+		require.NoError(t, log.Close())
+	}
+
+	assert.Equal(t, repairs[0].Offset, repairs[1].Offset)
+	assert.NotEqual(t, repairs[0].QuarantinePath, repairs[1].QuarantinePath)
+	assert.True(t, strings.HasSuffix(repairs[1].QuarantinePath, "~2.torn"), repairs[1].QuarantinePath)
+	for i, torn := range []string{`{"seq":3,"type":"step.fin`, `{"seq":3,"ty`} {
+		quarantined, err := os.ReadFile(repairs[i].QuarantinePath)
+		require.NoError(t, err)
+		assert.Equal(t, torn, string(quarantined))
+	}
+}
+
+// TestReadEvents_StopsAtTornTail verifies readers ignore an unterminated
+// final line, which was never acknowledged.
+func TestReadEvents_StopsAtTornTail(t *testing.T) {
+	workspaceRoot := t.TempDir()
+	runID := RunID("run-torn-read")
+	writeIncompleteRun(t, runID, workspaceRoot)
+	appendTornLine(t, segmentFilePath(runID, workspaceRoot, 1), `{"seq":3`)
+
+	events, err := ReadEvents(runID, workspaceRoot)
+	require.NoError(t, err)
+	assert.Len(t, events, 2)
+}
+
+// TestRecovery_RepairsTornTail verifies recovery resumes a run whose log
+// was torn by a crash and records the repair on the run.
+func TestRecovery_RepairsTornTail(t *testing.T) {
+	ctx := context.Background()
+	workspaceRoot := resolvedTempDir(t)
+	runID := RunID("run-torn-recovery")
+	writeIncompleteRun(t, runID, workspaceRoot)
+	offset := appendTornLine(t, segmentFilePath(runID, workspaceRoot, 1), `{"seq":3,"ty`)
+
+	engine, err := OpenEngine(EngineConfig{
+		WorkspaceRoot:  workspaceRoot,
+		IncompleteRuns: IncompleteRunResume,
+		Log:            testLogOptions(),
+	})
+	require.NoError(t, err)
+	defer engine.Shutdown(ctx)
+
+	run, err := engine.GetRun(ctx, runID)
+	require.NoError(t, err)
+	assert.True(t, run.Incomplete)
+	assert.Equal(t, int64(2), run.LastSeq)
+	require.NotNil(t, run.TailRepair)
+	assert.Equal(t, offset, run.TailRepair.Offset)
+	assert.FileExists(t, run.TailRepair.QuarantinePath)
+
+	require.NoError(t, engine.FinishStep(ctx, runID, "step-1"))
+	run, err = engine.GetRun(ctx, runID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), run.LastSeq)
+}
+
+// TestOpenEventLog_RejectsMidFileCorruption verifies only a torn final
+// line is repaired; a corrupt line followed by good ones is refused and
+// the log is left untouched.
+func TestOpenEventLog_RejectsMidFileCorruption(t *testing.T) {
+	workspaceRoot := t.TempDir()
+	runID := RunID("run-corrupt-middle")
+	writeIncompleteRun(t, runID, workspaceRoot)
+
+	path := segmentFilePath(runID, workspaceRoot, 1)
+	appendTornLine(t, path, "{\"seq\":3,\"ty\n")
+	appendTornLine(t, path, "{\"seq\":4}\n")
+	before, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	_, err = OpenEventLog(runID, workspaceRoot, testLogOptions())
+	assert.ErrorContains(t, err, "line 3")
+
+	_, err = recoverRuns(EngineConfig{
+		WorkspaceRoot:  workspaceRoot,
+		IncompleteRuns: IncompleteRunResume,
+		Log:            testLogOptions(),
+	})
+	assert.Error(t, err)
+
+	after, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, before, after)
+	assert.NoDirExists(t, quarantineDirPath(workspaceRoot))
+}
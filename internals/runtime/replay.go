@@ -0,0 +1,448 @@
+package runtime
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"aiplatform/pkg/assert"
+)
+
+const (
+	// eventLineBytesMax bounds a single JSONL line. A line longer than this
+	// is treated as corruption rather than buffered without limit.
+	eventLineBytesMax = 1024 * 1024
+
+	// eventsPerLogMax bounds how many events replay will read from one log,
+	// across all its segments. Every loop has a limit.
+	eventsPerLogMax = 16 * 1024 * 1024
+)
+
+// eventHeader holds the fields every event carries.
+// Replay folds and invariant checks work on the header so they can reason
+// about ordering without a case for every event type.
+type eventHeader struct {
+	RunID  RunID
+	StepID string // empty for run lifecycle events
+	Seq    int64
+	Type   EventType
+}
+
+// headerOf extracts the common header from a sealed event.
+// The switch is exhaustive on purpose: a new event type that is not listed
+// here is a programming error and panics loudly.
+func headerOf(event Event) eventHeader {
+	assert.Not_nil(event, "event must not be nil")
+
+	switch e := event.(type) {
+	case RunStartedEvent:
+		return eventHeader{RunID: e.RunID, Seq: e.Seq, Type: e.Type}
+	case RunFinishedEvent:
+		return eventHeader{RunID: e.RunID, Seq: e.Seq, Type: e.Type}
+	case RunFailedEvent:
+		return eventHeader{RunID: e.RunID, Seq: e.Seq, Type: e.Type}
+	case StepStartedEvent:
+		return eventHeader{RunID: e.RunID, StepID: e.StepID, Seq: e.Seq, Type: e.Type}
+	case StepFinishedEvent:
+		return eventHeader{RunID: e.RunID, StepID: e.StepID, Seq: e.Seq, Type: e.Type}
+	case StepFailedEvent:
+		return eventHeader{RunID: e.RunID, StepID: e.StepID, Seq: e.Seq, Type: e.Type}
+	case LLMRequestedEvent:
+		return eventHeader{RunID: e.RunID, StepID: e.StepID, Seq: e.Seq, Type: e.Type}
+	case LLMRespondedEvent:
+		return eventHeader{RunID: e.RunID, StepID: e.StepID, Seq: e.Seq, Type: e.Type}
+	case ToolCalledEvent:
+		return eventHeader{RunID: e.RunID, StepID: e.StepID, Seq: e.Seq, Type: e.Type}
+	case ToolReturnedEvent:
+		return eventHeader{RunID: e.RunID, StepID: e.StepID, Seq: e.Seq, Type: e.Type}
+	case ToolFailedEvent:
+		return eventHeader{RunID: e.RunID, StepID: e.StepID, Seq: e.Seq, Type: e.Type}
+	case ArtifactCreatedEvent:
+		return eventHeader{RunID: e.RunID, StepID: e.StepID, Seq: e.Seq, Type: e.Type}
+	case SignalGeneratedEvent:
+		return eventHeader{RunID: e.RunID, StepID: e.StepID, Seq: e.Seq, Type: e.Type}
+	case OrderSubmittedEvent:
+		return eventHeader{RunID: e.RunID, StepID: e.StepID, Seq: e.Seq, Type: e.Type}
+	case OrderAcknowledgedEvent:
+		return eventHeader{RunID: e.RunID, StepID: e.StepID, Seq: e.Seq, Type: e.Type}
+	case OrderFilledEvent:
+		return eventHeader{RunID: e.RunID, StepID: e.StepID, Seq: e.Seq, Type: e.Type}
+	case OrderCancelledEvent:
+		return eventHeader{RunID: e.RunID, StepID: e.StepID, Seq: e.Seq, Type: e.Type}
+	case OrderRejectedEvent:
+		return eventHeader{RunID: e.RunID, StepID: e.StepID, Seq: e.Seq, Type: e.Type}
+	}
+	panic(fmt.Sprintf("unknown event type: %T", event))
+}
+
+// decodeEvent parses one JSONL line back into its sealed Event type.
+// Dispatch is on the "type" field; an unknown type is an error because
+// Invariant 36 only allows the event types defined in events.go.
+// A line written at an older schema version is upcast first.
+// The line comes from disk, so a blank one is an error, not a panic.
+func decodeEvent(line []byte) (Event, error) {
+	if len(line) == 0 {
+		return nil, fmt.Errorf("invalid JSON: blank line")
+	}
+
+	var envelope struct {
+		Type          EventType `json:"type"`
+		SchemaVersion *int      `json:"schema_version"`
+	}
+	if err := json.Unmarshal(line, &envelope); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	version := schemaVersionUnversioned
+	if envelope.SchemaVersion != nil {
+		version = *envelope.SchemaVersion
+	}
+	line, err := upcastLine(line, envelope.Type, version)
+	if err != nil {
+		return nil, err
+	}
+
+	switch envelope.Type {
+	case EventTypeRunStarted:
+		return decodeEventAs[RunStartedEvent](line)
+	case EventTypeRunFinished:
+		return decodeEventAs[RunFinishedEvent](line)
+	case EventTypeRunFailed:
+		return decodeEventAs[RunFailedEvent](line)
+	case EventTypeStepStarted:
+		return decodeEventAs[StepStartedEvent](line)
+	case EventTypeStepFinished:
+		return decodeEventAs[StepFinishedEvent](line)
+	case EventTypeStepFailed:
+		return decodeEventAs[StepFailedEvent](line)
+	case EventTypeLLMRequested:
+		return decodeEventAs[LLMRequestedEvent](line)
+	case EventTypeLLMResponded:
+		return decodeEventAs[LLMRespondedEvent](line)
+	case EventTypeToolCalled:
+		return decodeEventAs[ToolCalledEvent](line)
+	case EventTypeToolReturned:
+		return decodeEventAs[ToolReturnedEvent](line)
+	case EventTypeToolFailed:
+		return decodeEventAs[ToolFailedEvent](line)
+	case EventTypeArtifactCreated:
+		return decodeEventAs[ArtifactCreatedEvent](line)
+	case EventTypeSignalGenerated:
+		return decodeEventAs[SignalGeneratedEvent](line)
+	case EventTypeOrderSubmitted:
+		return decodeEventAs[OrderSubmittedEvent](line)
+	case EventTypeOrderAcknowledged:
+		return decodeEventAs[OrderAcknowledgedEvent](line)
+	case EventTypeOrderFilled:
+		return decodeEventAs[OrderFilledEvent](line)
+	case EventTypeOrderCancelled:
+		return decodeEventAs[OrderCancelledEvent](line)
+	case EventTypeOrderRejected:
+		return decodeEventAs[OrderRejectedEvent](line)
+	}
+	return nil, fmt.Errorf("unknown event type %q (Invariant 36)", envelope.Type)
+}
+
+// decodeEventAs unmarshals a line into the concrete event type T.
+func decodeEventAs[T Event](line []byte) (Event, error) {
+	var event T
+	if err := json.Unmarshal(line, &event); err != nil {
+		return nil, fmt.Errorf("invalid %T payload: %w", event, err)
+	}
+	return event, nil
+}
+
+// readEventsFile decodes every line of one log file in seq order and
+// hands each event to visit. Streaming keeps memory flat for long runs;
+// callers that need the full slice use ReadEvents.
+func readEventsFile(path string, visit func(Event) error) error {
+	var reader logReader
+	return reader.readFile(path, visit, true)
+}
+
+// readRunLog decodes a run's whole log, segment by segment, in seq order
+// and hands each event to visit.
+func readRunLog(runID RunID, workspaceRoot string, visit func(Event) error) error {
+	paths, err := runLogPaths(runID, workspaceRoot)
+	if err != nil {
+		return err
+	}
+
+	var reader logReader
+	for i, path := range paths {
+		err := reader.readFile(path, visit, i == len(paths)-1)
+		if err != nil && len(paths) > 1 {
+			return fmt.Errorf("segment %s: %w", filepath.Base(path), err)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readRunLogFrom is readRunLog for the events with seq >= fromSeq.
+//
+// Closed segments that end before fromSeq are skipped using the segment
+// index; reading resumes the seq order and hash chain where the index
+// says the segment before the first one read ended. Without a usable
+// index the whole log is read.
+func readRunLogFrom(runID RunID, workspaceRoot string, fromSeq int64, visit func(Event) error) error {
+	assert.Gt(fromSeq, 0, "fromSeq must be positive")
+
+	paths, err := runLogPaths(runID, workspaceRoot)
+	if err != nil {
+		return err
+	}
+
+	reader, start, err := seekRunLog(paths, fromSeq)
+	if err != nil {
+		return err
+	}
+	for i := start; i < len(paths); i++ {
+		err := reader.readFile(paths[i], func(event Event) error {
+			if headerOf(event).Seq < fromSeq {
+				return nil
+			}
+			return visit(event)
+		}, i == len(paths)-1)
+		if err != nil && len(paths) > 1 {
+			return fmt.Errorf("segment %s: %w", filepath.Base(paths[i]), err)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// seekRunLog skips the closed segments among a log's paths that end
+// before fromSeq, using the segment index. It returns the index of the
+// first path to read, and a reader that resumes the seq order and hash
+// chain where the last skipped segment ended. Without a usable index
+// nothing is skipped.
+func seekRunLog(paths []string, fromSeq int64) (logReader, int, error) {
+	var reader logReader
+	start := 0
+	if len(paths) <= 1 {
+		return reader, start, nil
+	}
+	index, err := readSegmentIndex(filepath.Dir(paths[0]))
+	if err != nil {
+		return logReader{}, 0, err
+	}
+	for i, entry := range index.Segments {
+		if i >= len(paths)-1 || entry.Name != filepath.Base(paths[i]) || entry.LastSeq >= fromSeq {
+			break
+		}
+		reader = logReader{
+			chain:   chainVerifier{lastHash: entry.LastHash, sealed: entry.Sealed},
+			lastSeq: entry.LastSeq,
+		}
+		start = i + 1
+	}
+	return reader, start, nil
+}
+
+// logReader verifies and decodes the files of one log in order. The hash
+// chain and seq order carry over from one file to the next, so a log's
+// segments verify exactly as a single file would. The zero value is ready
+// to read a log from its first line.
+type logReader struct {
+	// chain verifies each line's checksum and chain link.
+	chain chainVerifier
+
+	// lastSeq is the seq of the last event read, or where the file before
+	// the first one read ended.
+	lastSeq int64
+
+	// events counts the events read, across files.
+	events int
+}
+
+// readFile decodes every complete line of the file at path and hands each
+// event to visit.
+//
+// Each line's checksum and chain link are verified before it is decoded;
+// a failure is reported as a *ChainError. Sequence ordering (Invariant 38)
+// is validated here, at read time, to pair with the write-time assertion
+// in encodeRequest.
+//
+// An unterminated final line is a write still in flight, or one a crash
+// tore; it was never acknowledged, so reading stops before it. Only the
+// tail of a log may end this way: with allowTorn false it is an error.
+func (r *logReader) readFile(path string, visit func(Event) error, allowTorn bool) error {
+	assert.Not_empty(path, "path must not be empty")
+	assert.Not_nil(visit, "visit must not be nil")
+
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open event log %s: %w", path, err)
+	}
+	defer file.Close()
+
+	scanner := newLogScanner(file)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		if r.events >= eventsPerLogMax {
+			return fmt.Errorf("log %s exceeds %d events", path, eventsPerLogMax)
+		}
+
+		line := scanner.Bytes()
+		if err := r.chain.verify(line); err != nil {
+			return &ChainError{Line: lineNum, Seq: peekSeq(line), Err: err}
+		}
+
+		event, err := decodeEvent(line)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", lineNum, err)
+		}
+
+		header := headerOf(event)
+		if header.Seq <= r.lastSeq {
+			return fmt.Errorf("line %d: sequence number %d is not strictly increasing (previous: %d)",
+				lineNum, header.Seq, r.lastSeq)
+		}
+		r.lastSeq = header.Seq
+		r.events++
+
+		if err := visit(event); err != nil {
+			return fmt.Errorf("line %d (seq %d): %w", lineNum, header.Seq, err)
+		}
+	}
+
+	err = scanner.Err()
+	if errors.Is(err, errTornTail) && !allowTorn {
+		return fmt.Errorf("event log %s ends with an unterminated line", path)
+	}
+	if err != nil && !errors.Is(err, errTornTail) {
+		return fmt.Errorf("failed to read event log %s: %w", path, err)
+	}
+	return nil
+}
+
+// peekSeq reads the seq of a line that failed verification, so the error
+// can name it. Returns 0 if the line is too damaged to tell.
+func peekSeq(line []byte) int64 {
+	var envelope struct {
+		Seq int64 `json:"seq"`
+	}
+	if json.Unmarshal(line, &envelope) != nil {
+		return 0
+	}
+	return envelope.Seq
+}
+
+// ReadEvents decodes the full event log of a run into its sealed Event types.
+// Events are returned in seq order. Every event must belong to runID.
+func ReadEvents(runID RunID, workspaceRoot string) ([]Event, error) {
+	assert.Is_true(runID != RunID(""), "runID must not be empty")
+	assert.Not_empty(workspaceRoot, "workspaceRoot must not be empty")
+
+	var events []Event
+	err := readRunLog(runID, workspaceRoot, func(event Event) error {
+		if header := headerOf(event); header.RunID != runID {
+			return fmt.Errorf("event belongs to run %s, expected %s", header.RunID, runID)
+		}
+		events = append(events, event)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// Replay rebuilds a RunHandle from the run's event log on disk.
+//
+// This is what makes "the event log is the source of truth" usable after
+// a restart: the handle returned here is derived purely from events.
+func Replay(runID RunID, workspaceRoot string) (*RunHandle, error) {
+	assert.Is_true(runID != RunID(""), "runID must not be empty")
+	assert.Not_empty(workspaceRoot, "workspaceRoot must not be empty")
+
+	var handle *RunHandle
+	err := readRunLog(runID, workspaceRoot, func(event Event) error {
+		if header := headerOf(event); header.RunID != runID {
+			return fmt.Errorf("event belongs to run %s, expected %s", header.RunID, runID)
+		}
+		next, err := applyEvent(handle, event)
+		handle = next
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if handle == nil {
+		return nil, fmt.Errorf("event log for run %s is empty", runID)
+	}
+	return handle, nil
+}
+
+// ReplayEvents folds already-decoded events into a RunHandle.
+// The events must be in seq order, as returned by ReadEvents.
+func ReplayEvents(events []Event) (*RunHandle, error) {
+	assert.Is_true(len(events) <= eventsPerLogMax, "events exceed replay limit")
+
+	var handle *RunHandle
+	for i, event := range events {
+		next, err := applyEvent(handle, event)
+		if err != nil {
+			return nil, fmt.Errorf("event %d (seq %d): %w", i, headerOf(event).Seq, err)
+		}
+		handle = next
+	}
+	if handle == nil {
+		return nil, fmt.Errorf("cannot replay an empty event stream")
+	}
+	return handle, nil
+}
+
+// applyEvent folds a single event into the handle and returns the result.
+// handle is nil until run.started has been applied.
+//
+// The fold only rejects streams it cannot represent (no run.started, or a
+// second one). Lifecycle invariants beyond that are the validator's job.
+func applyEvent(handle *RunHandle, event Event) (*RunHandle, error) {
+	assert.Not_nil(event, "event must not be nil")
+	header := headerOf(event)
+	assert.Gt(header.Seq, 0, "seq must be positive")
+
+	if started, ok := event.(RunStartedEvent); ok {
+		if handle != nil {
+			return nil, fmt.Errorf("duplicate %s for run %s", EventTypeRunStarted, header.RunID)
+		}
+		handle = &RunHandle{
+			ID:            started.RunID,
+			Phase:         PhaseDataIngestion,
+			WorkspaceRoot: started.WorkspaceRoot,
+			Attempts:      make(map[Phase]int),
+			PhaseDone:     make(map[Phase]bool),
+			Steps:         make(map[string]StepState),
+		}
+	}
+	if handle == nil {
+		return nil, fmt.Errorf("%s before %s", header.Type, EventTypeRunStarted)
+	}
+	if header.RunID != handle.ID {
+		return nil, fmt.Errorf("event belongs to run %s, expected %s", header.RunID, handle.ID)
+	}
+
+	switch e := event.(type) {
+	case StepStartedEvent:
+		handle.Phase = e.Phase
+		handle.Attempts[e.Phase]++
+		handle.Steps[e.StepID] = StepState{Phase: e.Phase, Status: StepStatusRunning}
+	case StepFinishedEvent:
+		handle.PhaseDone[e.Phase] = true
+		handle.Steps[e.StepID] = StepState{Phase: e.Phase, Status: StepStatusFinished}
+	case StepFailedEvent:
+		handle.Steps[e.StepID] = StepState{Phase: e.Phase, Status: StepStatusFailed}
+	case RunFinishedEvent, RunFailedEvent:
+		handle.Terminal = true
+	}
+
+	handle.LastSeq = header.Seq
+	return handle, nil
+}
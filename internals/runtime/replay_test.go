@@ -0,0 +1,210 @@
+package runtime
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeFullRun appends a complete four-phase run to a fresh log.
+func writeFullRun(t *testing.T, runID RunID, workspaceRoot string) {
+	t.Helper()
+
+	log, err := OpenEventLog(runID, workspaceRoot, testLogOptions())
+	require.NoError(t, err)
+
+	require.NoError(t, log.AppendRunStarted(runID, workspaceRoot))
+	phases := []Phase{
+		PhaseDataIngestion, PhaseSignalGeneration, PhaseRiskValidation, PhaseOrderExecution,
+	}
+	for _, phase := range phases {
+		stepID := "step-" + phase.String()
+		require.NoError(t, log.AppendStepStarted(runID, stepID, phase))
+		require.NoError(t, log.AppendToolCalled(runID, stepID, testToolCall("call-"+stepID)))
+		require.NoError(t, log.AppendToolReturned(runID, stepID, testToolResult("call-"+stepID)))
+		require.NoError(t, log.AppendStepFinished(runID, stepID, phase))
+	}
+	require.NoError(t, log.AppendRunFinished(runID))
+	require.NoError(t, log.Close())
+}
+
+// TestReadEvents_DecodesEveryType verifies that each line is decoded back
+// into its concrete sealed event type, dispatching on "type".
+func TestReadEvents_DecodesEveryType(t *testing.T) {
+	workspaceRoot := t.TempDir()
+	runID := RunID("test-read-events-001")
+
+	report, err := NewArtifactStore(workspaceRoot, runID).Put([]byte("# Report\n"), "text/markdown")
+	require.NoError(t, err)
+
+	log, err := OpenEventLog(runID, workspaceRoot, testLogOptions())
+	require.NoError(t, err)
+	require.NoError(t, log.AppendRunStarted(runID, workspaceRoot))
+	require.NoError(t, log.AppendStepStarted(runID, "step-1", PhaseDataIngestion))
+	require.NoError(t, log.AppendLLMRequested(runID, "step-1", testLLMRequest("call-step-1")))
+	require.NoError(t, log.AppendLLMResponded(runID, "step-1", testLLMResponse("call-step-1")))
+	require.NoError(t, log.AppendToolCalled(runID, "step-1", testToolCall("call-1")))
+	require.NoError(t, log.AppendToolReturned(runID, "step-1", testToolResult("call-1")))
+	require.NoError(t, log.AppendToolFailed(runID, "step-1", testToolFailure("call-2")))
+	require.NoError(t, log.AppendArtifactCreated(runID, "step-1", report))
+	require.NoError(t, log.AppendStepFailed(runID, "step-1", PhaseDataIngestion, "boom"))
+	require.NoError(t, log.AppendStepFinished(runID, "step-2", PhaseDataIngestion))
+	require.NoError(t, log.AppendRunFailed(runID, "gave up"))
+	require.NoError(t, log.AppendRunFinished(runID))
+	require.NoError(t, log.Close())
+
+	events, err := ReadEvents(runID, workspaceRoot)
+	require.NoError(t, err)
+	require.Len(t, events, 12)
+
+	assert.Equal(t, FormatRunStarted(1, testTime, runID, workspaceRoot), events[0])
+	assert.Equal(t, FormatStepStarted(2, testTime, runID, "step-1", PhaseDataIngestion), events[1])
+	assert.Equal(t, FormatLLMRequested(3, testTime, runID, "step-1", testLLMRequestPayload("call-step-1")), events[2])
+	assert.Equal(t, FormatLLMResponded(4, testTime, runID, "step-1", testLLMResponsePayload("call-step-1")), events[3])
+	assert.Equal(t, FormatToolCalled(5, testTime, runID, "step-1", testToolCallPayload("call-1")), events[4])
+	assert.Equal(t, FormatToolReturned(6, testTime, runID, "step-1", testToolResultPayload("call-1")), events[5])
+	assert.Equal(t, FormatToolFailed(7, testTime, runID, "step-1", testToolFailure("call-2").payload()), events[6])
+	assert.Equal(t, FormatArtifactCreated(8, testTime, runID, "step-1", report), events[7])
+	assert.Equal(t, FormatStepFailed(9, testTime, runID, "step-1", PhaseDataIngestion, "boom"), events[8])
+	assert.Equal(t, FormatStepFinished(10, testTime, runID, "step-2", PhaseDataIngestion), events[9])
+	assert.Equal(t, FormatRunFailed(11, testTime, runID, "gave up"), events[10])
+	assert.Equal(t, FormatRunFinished(12, testTime, runID), events[11])
+}
+
+// TestReplay_RebuildsRunHandle verifies the fold from events to RunHandle.
+func TestReplay_RebuildsRunHandle(t *testing.T) {
+	workspaceRoot := t.TempDir()
+	runID := RunID("test-replay-001")
+	writeFullRun(t, runID, workspaceRoot)
+
+	handle, err := Replay(runID, workspaceRoot)
+	require.NoError(t, err)
+
+	assert.Equal(t, runID, handle.ID)
+	assert.Equal(t, workspaceRoot, handle.WorkspaceRoot)
+	assert.Equal(t, int64(18), handle.LastSeq)
+	assert.Equal(t, PhaseOrderExecution, handle.Phase)
+	assert.True(t, handle.Terminal)
+	for phase := MinPhase; phase <= MaxPhase; phase++ {
+		assert.Equal(t, 1, handle.Attempts[phase], "attempts for %s", phase)
+		assert.True(t, handle.PhaseDone[phase], "phase done for %s", phase)
+	}
+}
+
+// TestReplay_IncompleteRun verifies a run without a terminal event replays
+// as non-terminal, with retries counted per phase.
+func TestReplay_IncompleteRun(t *testing.T) {
+	workspaceRoot := t.TempDir()
+	runID := RunID("test-replay-incomplete-001")
+
+	log, err := OpenEventLog(runID, workspaceRoot, testLogOptions())
+	require.NoError(t, err)
+	require.NoError(t, log.AppendRunStarted(runID, workspaceRoot))
+	require.NoError(t, log.AppendStepStarted(runID, "step-1", PhaseDataIngestion))
+	require.NoError(t, log.AppendStepFailed(runID, "step-1", PhaseDataIngestion, "timeout"))
+	require.NoError(t, log.AppendStepStarted(runID, "step-2", PhaseDataIngestion))
+	require.NoError(t, log.Close())
+
+	handle, err := Replay(runID, workspaceRoot)
+	require.NoError(t, err)
+
+	assert.False(t, handle.Terminal)
+	assert.Equal(t, int64(4), handle.LastSeq)
+	assert.Equal(t, PhaseDataIngestion, handle.Phase)
+	assert.Equal(t, 2, handle.Attempts[PhaseDataIngestion])
+	assert.False(t, handle.PhaseDone[PhaseDataIngestion])
+}
+
+// TestReplay_RejectsMalformedLogs verifies replay fails with a clear error
+// instead of building a handle from a log it cannot trust.
+func TestReplay_RejectsMalformedLogs(t *testing.T) {
+	runID := RunID("test-replay-bad-001")
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{
+			name:    "empty_log",
+			content: "",
+			wantErr: "empty",
+		},
+		{
+			name:    "invalid_json",
+			content: "{not json}\n",
+			wantErr: "invalid JSON",
+		},
+		{
+			name:    "blank_line",
+			content: "\n",
+			wantErr: "line 1: invalid JSON: blank line",
+		},
+		{
+			name: "blank_line_after_event",
+			content: `{"run_id":"test-replay-bad-001","workspace_root":"/w","seq":1,"type":"run.started"}` + "\n" +
+				"\n",
+			wantErr: "line 2: invalid JSON: blank line",
+		},
+		{
+			name:    "unknown_type",
+			content: `{"run_id":"test-replay-bad-001","seq":1,"type":"run.paused"}` + "\n",
+			wantErr: "unknown event type",
+		},
+		{
+			name: "seq_not_increasing",
+			content: `{"run_id":"test-replay-bad-001","workspace_root":"/w","seq":2,"type":"run.started"}` + "\n" +
+				`{"run_id":"test-replay-bad-001","seq":2,"type":"run.finished"}` + "\n",
+			wantErr: "not strictly increasing",
+		},
+		{
+			name:    "missing_run_started",
+			content: `{"run_id":"test-replay-bad-001","seq":1,"type":"run.finished"}` + "\n",
+			wantErr: "before run.started",
+		},
+		{
+			name: "duplicate_run_started",
+			content: `{"run_id":"test-replay-bad-001","workspace_root":"/w","seq":1,"type":"run.started"}` + "\n" +
+				`{"run_id":"test-replay-bad-001","workspace_root":"/w","seq":2,"type":"run.started"}` + "\n",
+			wantErr: "duplicate run.started",
+		},
+		{
+			name:    "foreign_run_id",
+			content: `{"run_id":"other-run","workspace_root":"/w","seq":1,"type":"run.started"}` + "\n",
+			wantErr: "belongs to run other-run",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workspaceRoot := t.TempDir()
+			require.NoError(t, os.MkdirAll(logDirPath(workspaceRoot), 0755))
+			path := legacyLogFilePath(runID, workspaceRoot)
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0644))
+
+			handle, err := Replay(runID, workspaceRoot)
+			require.Error(t, err)
+			assert.Nil(t, handle)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+// TestReplayEvents_MatchesReplayFromDisk verifies folding decoded events
+// gives the same handle as replaying straight from the log file.
+func TestReplayEvents_MatchesReplayFromDisk(t *testing.T) {
+	workspaceRoot := t.TempDir()
+	runID := RunID("test-replay-events-001")
+	writeFullRun(t, runID, workspaceRoot)
+
+	events, err := ReadEvents(runID, workspaceRoot)
+	require.NoError(t, err)
+
+	fromEvents, err := ReplayEvents(events)
+	require.NoError(t, err)
+	fromDisk, err := Replay(runID, workspaceRoot)
+	require.NoError(t, err)
+
+	assert.Equal(t, fromDisk, fromEvents)
+}
@@ -0,0 +1,209 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"aiplatform/pkg/assert"
+)
+
+// RunFailedReasonCancelledPrefix starts the run.failed reason of a run the
+// user cancelled, so a cancel can be told apart from a failure in the log.
+const RunFailedReasonCancelledPrefix = "cancelled: "
+
+// FinishRunCmd ends a run whose final phase has completed.
+type FinishRunCmd struct {
+	RunID    RunID
+	ResultCh chan<- RunResult
+}
+
+func (FinishRunCmd) command() {}
+
+// FailRunCmd ends a run as failed.
+type FailRunCmd struct {
+	RunID    RunID
+	Reason   string
+	ResultCh chan<- RunResult
+}
+
+func (FailRunCmd) command() {}
+
+// CancelRunCmd ends a run at the user's request.
+type CancelRunCmd struct {
+	RunID    RunID
+	Reason   string
+	ResultCh chan<- RunResult
+}
+
+func (CancelRunCmd) command() {}
+
+// RunResult is the result of finishing, failing, or cancelling a run.
+type RunResult struct {
+	Err error
+}
+
+// handleFinishRun processes a FinishRunCmd.
+//
+// A run may only finish once order execution has a finished step and no
+// step is left running; anything less is a failure, not a finish. The
+// log also refuses run.finished while an order is still open (T11).
+func (e *Engine) handleFinishRun(reg *registry, cmd FinishRunCmd) {
+	assert.Not_nil(reg, "registry must not be nil")
+	assert.Not_nil(cmd.ResultCh, "result channel must not be nil")
+
+	handle, err := activeRun(reg, cmd.RunID)
+	if err != nil {
+		cmd.ResultCh <- RunResult{Err: err}
+		return
+	}
+	if !handle.PhaseDone[PhaseOrderExecution] {
+		cmd.ResultCh <- RunResult{Err: fmt.Errorf("cannot finish run %s: %s has no finished step",
+			handle.ID, PhaseOrderExecution)}
+		return
+	}
+	if running := runningStepIDs(handle); len(running) > 0 {
+		cmd.ResultCh <- RunResult{Err: fmt.Errorf("cannot finish run %s: step %s is still running",
+			handle.ID, running[0])}
+		return
+	}
+
+	err = reg.appendEvent(handle,
+		func(log *EventLog) error { return log.AppendRunFinished(handle.ID) },
+		func(handle *RunHandle) { handle.Terminal = true })
+	cmd.ResultCh <- RunResult{Err: err}
+}
+
+// handleFailRun processes a FailRunCmd.
+func (e *Engine) handleFailRun(reg *registry, cmd FailRunCmd) {
+	assert.Not_nil(reg, "registry must not be nil")
+	assert.Not_nil(cmd.ResultCh, "result channel must not be nil")
+
+	if cmd.Reason == "" {
+		cmd.ResultCh <- RunResult{Err: fmt.Errorf("reason must not be empty")}
+		return
+	}
+	handle, err := activeRun(reg, cmd.RunID)
+	if err != nil {
+		cmd.ResultCh <- RunResult{Err: err}
+		return
+	}
+	cmd.ResultCh <- RunResult{Err: failRun(reg, handle, cmd.Reason)}
+}
+
+// handleCancelRun processes a CancelRunCmd.
+func (e *Engine) handleCancelRun(reg *registry, cmd CancelRunCmd) {
+	assert.Not_nil(reg, "registry must not be nil")
+	assert.Not_nil(cmd.ResultCh, "result channel must not be nil")
+
+	if cmd.Reason == "" {
+		cmd.ResultCh <- RunResult{Err: fmt.Errorf("reason must not be empty")}
+		return
+	}
+	handle, err := activeRun(reg, cmd.RunID)
+	if err != nil {
+		cmd.ResultCh <- RunResult{Err: err}
+		return
+	}
+	reason := RunFailedReasonCancelledPrefix + cmd.Reason
+	cmd.ResultCh <- RunResult{Err: failRun(reg, handle, reason)}
+}
+
+// failRun fails every running step with reason, then appends run.failed.
+//
+// Every step must terminate before its run does, so a failed run still
+// leaves a log that passes the complete-replay invariants.
+func failRun(reg *registry, handle *RunHandle, reason string) error {
+	assert.Not_nil(reg, "registry must not be nil")
+	assert.Not_nil(handle, "handle must not be nil")
+	assert.Not_empty(reason, "reason must not be empty")
+
+	for _, stepID := range runningStepIDs(handle) {
+		step := handle.Steps[stepID]
+		err := reg.appendEvent(handle,
+			func(log *EventLog) error {
+				return log.AppendStepFailed(handle.ID, stepID, step.Phase, reason)
+			},
+			func(handle *RunHandle) {
+				handle.Steps[stepID] = StepState{Phase: step.Phase, Status: StepStatusFailed}
+			})
+		if err != nil {
+			return err
+		}
+	}
+
+	return reg.appendEvent(handle,
+		func(log *EventLog) error { return log.AppendRunFailed(handle.ID, reason) },
+		func(handle *RunHandle) { handle.Terminal = true })
+}
+
+// runningStepIDs returns the IDs of the run's running steps in lexical
+// order, so events appended on their behalf are deterministic.
+func runningStepIDs(handle *RunHandle) []string {
+	assert.Not_nil(handle, "handle must not be nil")
+
+	var running []string
+	for stepID, step := range handle.Steps {
+		if step.Status == StepStatusRunning {
+			running = append(running, stepID)
+		}
+	}
+	slices.Sort(running)
+	return running
+}
+
+// FinishRun ends a run whose order execution phase has completed.
+// Returns an error wrapping ErrRunTerminal if the run has already ended.
+func (e *Engine) FinishRun(ctx context.Context, runID RunID) error {
+	resultCh := make(chan RunResult, 1)
+	err := e.submit(ctx, FinishRunCmd{
+		RunID:    runID,
+		ResultCh: resultCh,
+	})
+	if err != nil {
+		return err
+	}
+	result, err := await(ctx, e, resultCh)
+	if err != nil {
+		return err
+	}
+	return result.Err
+}
+
+// FailRun ends a run as failed, failing any step still running with the
+// same reason.
+func (e *Engine) FailRun(ctx context.Context, runID RunID, reason string) error {
+	resultCh := make(chan RunResult, 1)
+	err := e.submit(ctx, FailRunCmd{
+		RunID:    runID,
+		Reason:   reason,
+		ResultCh: resultCh,
+	})
+	if err != nil {
+		return err
+	}
+	result, err := await(ctx, e, resultCh)
+	if err != nil {
+		return err
+	}
+	return result.Err
+}
+
+// CancelRun ends a run at the user's request. The log records it as
+// run.failed with a reason starting with RunFailedReasonCancelledPrefix.
+func (e *Engine) CancelRun(ctx context.Context, runID RunID, reason string) error {
+	resultCh := make(chan RunResult, 1)
+	err := e.submit(ctx, CancelRunCmd{
+		RunID:    runID,
+		Reason:   reason,
+		ResultCh: resultCh,
+	})
+	if err != nil {
+		return err
+	}
+	result, err := await(ctx, e, resultCh)
+	if err != nil {
+		return err
+	}
+	return result.Err
+}
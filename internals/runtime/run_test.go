@@ -0,0 +1,154 @@
+package runtime
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// finishAllPhases drives a run through every phase with one finished step each.
+func finishAllPhases(t *testing.T, engine *Engine, runID RunID) {
+	t.Helper()
+	for _, phase := range []Phase{
+		PhaseDataIngestion, PhaseSignalGeneration, PhaseRiskValidation, PhaseOrderExecution,
+	} {
+		finishPhase(t, engine, runID, phase)
+	}
+}
+
+// TestFinishRun_CompletesLog verifies a finished run leaves a log that
+// passes complete replay and rejects every later command.
+func TestFinishRun_CompletesLog(t *testing.T) {
+	engine, runID, workspaceRoot := startTestRun(t)
+	finishAllPhases(t, engine, runID)
+
+	require.NoError(t, engine.FinishRun(context.Background(), runID))
+
+	violations, err := ValidateLog(runID, workspaceRoot, ReplayModeComplete)
+	require.NoError(t, err)
+	assert.Empty(t, violations)
+
+	handle, err := Replay(runID, workspaceRoot)
+	require.NoError(t, err)
+	assert.True(t, handle.Terminal)
+	assert.Equal(t, int64(10), handle.LastSeq)
+
+	_, err = engine.StartStep(context.Background(), runID, PhaseOrderExecution)
+	assert.ErrorIs(t, err, ErrRunTerminal)
+	assert.ErrorIs(t, engine.FinishRun(context.Background(), runID), ErrRunTerminal)
+	assert.ErrorIs(t, engine.FailRun(context.Background(), runID, "late"), ErrRunTerminal)
+	assert.ErrorIs(t, engine.CancelRun(context.Background(), runID, "late"), ErrRunTerminal)
+
+	events, err := ReadEvents(runID, workspaceRoot)
+	require.NoError(t, err)
+	assert.Len(t, events, 10, "rejected commands must not append")
+}
+
+// TestFinishRun_RequiresOrderExecution verifies a run cannot finish before
+// its last phase completes or while a step is still running.
+func TestFinishRun_RequiresOrderExecution(t *testing.T) {
+	engine, runID, _ := startTestRun(t)
+
+	finishPhase(t, engine, runID, PhaseDataIngestion)
+	assert.ErrorContains(t, engine.FinishRun(context.Background(), runID), "order_execution has no finished step")
+
+	finishAllPhases(t, engine, runID)
+	stepID, err := engine.StartStep(context.Background(), runID, PhaseOrderExecution)
+	require.NoError(t, err)
+	assert.ErrorContains(t, engine.FinishRun(context.Background(), runID), stepID)
+
+	require.NoError(t, engine.FinishStep(context.Background(), runID, stepID))
+	assert.NoError(t, engine.FinishRun(context.Background(), runID))
+}
+
+// TestFinishRun_RequiresClosedOrders verifies a run with an order still
+// open cannot finish, so every finished run passes complete replay.
+func TestFinishRun_RequiresClosedOrders(t *testing.T) {
+	ctx := context.Background()
+	engine, runID, workspaceRoot := startTestRun(t)
+	for _, phase := range []Phase{PhaseDataIngestion, PhaseSignalGeneration, PhaseRiskValidation} {
+		finishPhase(t, engine, runID, phase)
+	}
+	stepID, err := engine.StartStep(ctx, runID, PhaseOrderExecution)
+	require.NoError(t, err)
+	require.NoError(t, engine.Shutdown(ctx))
+
+	// The strategy submits an order through the run's log.
+	log, err := OpenEventLog(runID, workspaceRoot, testLogOptions())
+	require.NoError(t, err)
+	require.NoError(t, log.AppendOrderSubmitted(runID, stepID, testOrder("order-1")))
+	require.NoError(t, log.Close())
+
+	engine, err = OpenEngine(EngineConfig{
+		WorkspaceRoot:  workspaceRoot,
+		IncompleteRuns: IncompleteRunResume,
+		Log:            testLogOptions(),
+	})
+	require.NoError(t, err)
+	defer engine.Shutdown(ctx)
+	require.NoError(t, engine.FinishStep(ctx, runID, stepID))
+	assert.ErrorContains(t, engine.FinishRun(ctx, runID), "order order-1 is still open (submitted, T11)")
+
+	handle, err := engine.GetRun(ctx, runID)
+	require.NoError(t, err)
+	assert.False(t, handle.Terminal)
+	assert.Equal(t, int64(10), handle.LastSeq)
+}
+
+// TestFailRun_FailsRunningSteps verifies failing and cancelling a run
+// terminates its running steps first, with the run's reason.
+func TestFailRun_FailsRunningSteps(t *testing.T) {
+	tests := []struct {
+		name   string
+		end    func(*Engine, RunID) error
+		reason string
+	}{
+		{
+			name:   "fail",
+			end:    func(e *Engine, id RunID) error { return e.FailRun(context.Background(), id, "broker unavailable") },
+			reason: "broker unavailable",
+		},
+		{
+			name:   "cancel",
+			end:    func(e *Engine, id RunID) error { return e.CancelRun(context.Background(), id, "user stop") },
+			reason: RunFailedReasonCancelledPrefix + "user stop",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine, runID, workspaceRoot := startTestRun(t)
+			stepID, err := engine.StartStep(context.Background(), runID, PhaseDataIngestion)
+			require.NoError(t, err)
+
+			require.NoError(t, tt.end(engine, runID))
+
+			events, err := ReadEvents(runID, workspaceRoot)
+			require.NoError(t, err)
+			require.Len(t, events, 4)
+			assert.Equal(t, FormatStepFailed(3, testTime, runID, stepID, PhaseDataIngestion, tt.reason), events[2])
+			assert.Equal(t, FormatRunFailed(4, testTime, runID, tt.reason), events[3])
+			assert.Empty(t, ValidateEvents(events, ReplayModeComplete))
+
+			assert.ErrorIs(t, engine.FinishStep(context.Background(), runID, stepID), ErrRunTerminal)
+		})
+	}
+}
+
+// TestRunCommands_RejectInvalidInput verifies unknown runs and empty
+// reasons are rejected.
+func TestRunCommands_RejectInvalidInput(t *testing.T) {
+	engine, runID, _ := startTestRun(t)
+	missing := RunID("run-missing")
+
+	assert.ErrorIs(t, engine.FinishRun(context.Background(), missing), ErrRunNotFound)
+	assert.ErrorIs(t, engine.FailRun(context.Background(), missing, "reason"), ErrRunNotFound)
+	assert.ErrorIs(t, engine.CancelRun(context.Background(), missing, "reason"), ErrRunNotFound)
+	_, err := engine.StartStep(context.Background(), missing, PhaseDataIngestion)
+	assert.ErrorIs(t, err, ErrRunNotFound)
+
+	assert.ErrorContains(t, engine.FailRun(context.Background(), runID, ""), "reason")
+	assert.ErrorContains(t, engine.CancelRun(context.Background(), runID, ""), "reason")
+}
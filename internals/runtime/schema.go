@@ -0,0 +1,100 @@
+package runtime
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"aiplatform/pkg/assert"
+)
+
+const (
+	// schemaVersionUnversioned is the version of lines written before
+	// events carried schema_version. Such lines decode as this version.
+	schemaVersionUnversioned = 1
+
+	// SchemaVersionCurrent is the version the writer stamps on every event.
+	// Bump it, and register upcasters from the old version, whenever an
+	// event's encoding changes in a way old structs cannot decode.
+	SchemaVersionCurrent = 2
+)
+
+// upcaster migrates one event's fields from one schema version to the
+// next, in place. It sees the raw fields so it can rename, convert, or
+// fill in what the current struct expects.
+type upcaster func(fields map[string]json.RawMessage) error
+
+// upcasterKey selects the upcaster for an event type at a version.
+type upcasterKey struct {
+	eventType EventType
+	from      int
+}
+
+// upcasters holds every migration between adjacent schema versions.
+// An event type with no entry at a version decodes unchanged into the
+// next one.
+var upcasters = map[upcasterKey]upcaster{
+	// Version 1 logs may hold numeric phases, from before phases were
+	// serialized as strings.
+	{EventTypeStepStarted, 1}:  upcastPhaseToString,
+	{EventTypeStepFinished, 1}: upcastPhaseToString,
+	{EventTypeStepFailed, 1}:   upcastPhaseToString,
+}
+
+func init() {
+	for key := range upcasters {
+		assert.Is_true(key.from >= schemaVersionUnversioned && key.from < SchemaVersionCurrent,
+			fmt.Sprintf("upcaster for %s must start from an old version, got %d", key.eventType, key.from))
+	}
+}
+
+// upcastLine migrates a line written at an older schema version to the
+// current one and returns the re-encoded line. Lines already at the
+// current version are returned unchanged.
+func upcastLine(line []byte, eventType EventType, version int) ([]byte, error) {
+	if version == SchemaVersionCurrent {
+		return line, nil
+	}
+	if version < schemaVersionUnversioned || version > SchemaVersionCurrent {
+		return nil, fmt.Errorf("unsupported schema version %d (this build reads %d to %d)",
+			version, schemaVersionUnversioned, SchemaVersionCurrent)
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(line, &fields); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	for from := version; from < SchemaVersionCurrent; from++ {
+		if upcast, ok := upcasters[upcasterKey{eventType, from}]; ok {
+			if err := upcast(fields); err != nil {
+				return nil, fmt.Errorf("failed to upcast %s from schema version %d: %w",
+					eventType, from, err)
+			}
+		}
+	}
+
+	current, err := json.Marshal(SchemaVersionCurrent)
+	assert.No_err(err, "schema version must marshal")
+	fields["schema_version"] = current
+	return json.Marshal(fields)
+}
+
+// upcastPhaseToString rewrites a numeric phase as its string name.
+func upcastPhaseToString(fields map[string]json.RawMessage) error {
+	raw, ok := fields["phase"]
+	if !ok {
+		return fmt.Errorf("missing phase")
+	}
+	var number int
+	if json.Unmarshal(raw, &number) != nil {
+		return nil // Already a string; Phase.UnmarshalJSON checks it.
+	}
+
+	phase := Phase(number)
+	if !phase.IsValid() {
+		return fmt.Errorf("invalid phase number: %d", number)
+	}
+	name, err := json.Marshal(phase.String())
+	assert.No_err(err, "phase name must marshal")
+	fields["phase"] = name
+	return nil
+}
@@ -0,0 +1,197 @@
+package runtime
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// updateGolden rewrites the current-version golden log instead of
+// comparing against it: go test ./internals/runtime -run WriterMatchesGolden -update
+var updateGolden = flag.Bool("update", false, "rewrite golden files in testdata")
+
+// goldenCurrentPath is the log writeGoldenRun must reproduce byte for byte.
+// When the encoding changes, keep this file as an old-version corpus entry
+// and bump SchemaVersionCurrent.
+var goldenCurrentPath = filepath.Join("testdata", "schema", "v2.jsonl")
+
+// writeGoldenRun writes a complete run touching every event type and
+// returns the log's path.
+func writeGoldenRun(t *testing.T) string {
+	t.Helper()
+	workspaceRoot := t.TempDir()
+	runID := RunID("run-golden")
+
+	orders, err := NewArtifactStore(workspaceRoot, runID).Put(
+		[]byte(`{"orders":[{"symbol":"AAPL","qty":10}]}`), "application/json")
+	require.NoError(t, err)
+	riskCall := ToolCall{CallID: "call-2", ToolName: "risk", Arguments: json.RawMessage(`{"symbol":"AAPL","qty":10}`)}
+	riskFailure := ToolFailure{CallID: "call-2", ToolName: "risk", Reason: "timeout", Duration: 5 * time.Second}
+
+	log, err := OpenEventLog(runID, workspaceRoot, testLogOptions())
+	require.NoError(t, err)
+	require.NoError(t, log.AppendRunStarted(runID, "/w"))
+	require.NoError(t, log.AppendStepStarted(runID, "s1", PhaseDataIngestion))
+	require.NoError(t, log.AppendToolCalled(runID, "s1", testToolCall("call-1")))
+	require.NoError(t, log.AppendToolReturned(runID, "s1", testToolResult("call-1")))
+	require.NoError(t, log.AppendStepFinished(runID, "s1", PhaseDataIngestion))
+	require.NoError(t, log.AppendStepStarted(runID, "s2", PhaseSignalGeneration))
+	require.NoError(t, log.AppendLLMRequested(runID, "s2", testLLMRequest("llm-1")))
+	require.NoError(t, log.AppendLLMResponded(runID, "s2", testLLMResponse("llm-1")))
+	require.NoError(t, log.AppendSignalGenerated(runID, "s2", testSignal("signal-1")))
+	require.NoError(t, log.AppendStepFinished(runID, "s2", PhaseSignalGeneration))
+	require.NoError(t, log.AppendStepStarted(runID, "s3", PhaseRiskValidation))
+	require.NoError(t, log.AppendToolCalled(runID, "s3", riskCall))
+	require.NoError(t, log.AppendToolFailed(runID, "s3", riskFailure))
+	require.NoError(t, log.AppendStepFailed(runID, "s3", PhaseRiskValidation, "timeout"))
+	require.NoError(t, log.AppendStepStarted(runID, "s4", PhaseRiskValidation))
+	require.NoError(t, log.AppendStepFinished(runID, "s4", PhaseRiskValidation))
+	require.NoError(t, log.AppendStepStarted(runID, "s5", PhaseOrderExecution))
+	require.NoError(t, log.AppendArtifactCreated(runID, "s5", orders))
+	require.NoError(t, log.AppendOrderSubmitted(runID, "s5", testOrder("order-1")))
+	require.NoError(t, log.AppendOrderAcknowledged(runID, "s5", OrderAck{OrderID: "order-1", BrokerOrderID: "B-1"}))
+	require.NoError(t, log.AppendOrderFilled(runID, "s5", testFill("order-1", "fill-1", 4)))
+	require.NoError(t, log.AppendOrderCancelled(runID, "s5", OrderCancel{OrderID: "order-1", Reason: "expired"}))
+	require.NoError(t, log.AppendOrderSubmitted(runID, "s5", testOrder("order-2")))
+	require.NoError(t, log.AppendOrderRejected(runID, "s5", OrderReject{OrderID: "order-2", Reason: "halted"}))
+	require.NoError(t, log.AppendStepFinished(runID, "s5", PhaseOrderExecution))
+	require.NoError(t, log.AppendRunFinished(runID))
+	require.NoError(t, log.Close())
+	return log.Path()
+}
+
+// readGoldenLog decodes a corpus log.
+func readGoldenLog(t *testing.T, path string) []Event {
+	t.Helper()
+	var events []Event
+	require.NoError(t, readEventsFile(path, func(event Event) error {
+		events = append(events, event)
+		return nil
+	}))
+	return events
+}
+
+// TestSchema_WriterMatchesGolden verifies the writer's encoding has not
+// drifted from the current schema version's golden log.
+func TestSchema_WriterMatchesGolden(t *testing.T) {
+	written, err := os.ReadFile(writeGoldenRun(t))
+	require.NoError(t, err)
+
+	if *updateGolden {
+		require.NoError(t, os.WriteFile(goldenCurrentPath, written, 0644))
+	}
+	golden, err := os.ReadFile(goldenCurrentPath)
+	require.NoError(t, err)
+	assert.Equal(t, string(golden), string(written),
+		"event encoding changed: bump SchemaVersionCurrent and add an upcaster")
+}
+
+// goldenCorpusEvents is how many events each corpus log holds. Version 1
+// predates trading events, so only the current log carries them.
+var goldenCorpusEvents = map[string]int{
+	"v1_sealed.jsonl":   19,
+	"v1_unsealed.jsonl": 19,
+	"v2.jsonl":          26,
+}
+
+// TestSchema_GoldenCorpusReplays verifies every log in the corpus, from
+// every schema version, still decodes into valid current-version events.
+func TestSchema_GoldenCorpusReplays(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "schema", "*.jsonl"))
+	require.NoError(t, err)
+	require.Len(t, paths, len(goldenCorpusEvents))
+
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			events := readGoldenLog(t, path)
+			require.Len(t, events, goldenCorpusEvents[filepath.Base(path)])
+			assert.Empty(t, ValidateEvents(events, ReplayModeComplete))
+
+			for _, event := range events {
+				data, err := json.Marshal(event)
+				require.NoError(t, err)
+				var envelope struct {
+					SchemaVersion int `json:"schema_version"`
+				}
+				require.NoError(t, json.Unmarshal(data, &envelope))
+				assert.Equal(t, SchemaVersionCurrent, envelope.SchemaVersion, "seq %d", headerOf(event).Seq)
+			}
+		})
+	}
+}
+
+// TestSchema_DecodesTradingEvents verifies the signal and order events in
+// the current golden log decode with their payloads intact.
+func TestSchema_DecodesTradingEvents(t *testing.T) {
+	events := readGoldenLog(t, goldenCurrentPath)
+	runID := RunID("run-golden")
+
+	signal := events[8].(SignalGeneratedEvent)
+	assert.Equal(t, FormatSignalGenerated(9, testTime, runID, "s2", testSignal("signal-1")), signal)
+
+	var types []EventType
+	for _, event := range events[18:24] {
+		types = append(types, headerOf(event).Type)
+	}
+	assert.Equal(t, []EventType{
+		EventTypeOrderSubmitted, EventTypeOrderAcknowledged, EventTypeOrderFilled,
+		EventTypeOrderCancelled, EventTypeOrderSubmitted, EventTypeOrderRejected,
+	}, types)
+
+	assert.Equal(t, FormatOrderSubmitted(19, testTime, runID, "s5", testOrder("order-1")), events[18])
+	assert.Equal(t, FormatOrderFilled(21, testTime, runID, "s5", testFill("order-1", "fill-1", 4)), events[20])
+	assert.Equal(t, FormatOrderRejected(24, testTime, runID, "s5",
+		OrderReject{OrderID: "order-2", Reason: "halted"}), events[23])
+}
+
+// TestSchema_UpcastsUnversionedLog verifies the oldest log format, with
+// numeric phases and no timestamps or payloads, upcasts field by field.
+func TestSchema_UpcastsUnversionedLog(t *testing.T) {
+	events := readGoldenLog(t, filepath.Join("testdata", "schema", "v1_unsealed.jsonl"))
+	require.Len(t, events, 19)
+
+	runID := RunID("run-golden-unsealed")
+	assert.Equal(t, StepStartedEvent{
+		RunID: runID, StepID: "s1", Phase: PhaseDataIngestion,
+		Seq: 2, Type: EventTypeStepStarted, SchemaVersion: SchemaVersionCurrent,
+	}, events[1])
+	assert.Equal(t, StepFailedEvent{
+		RunID: runID, StepID: "s3", Phase: PhaseRiskValidation, Reason: "timeout",
+		Seq: 13, Type: EventTypeStepFailed, SchemaVersion: SchemaVersionCurrent,
+	}, events[12])
+
+	called := events[2].(ToolCalledEvent)
+	assert.Equal(t, "quote", called.ToolName)
+	assert.Empty(t, called.CallID)
+	assert.False(t, called.Arguments.IsSet())
+	assert.True(t, called.Timestamp.IsZero())
+
+	artifact := events[16].(ArtifactCreatedEvent)
+	assert.Equal(t, "out/orders.json", artifact.Path)
+	assert.Empty(t, artifact.SHA256)
+}
+
+// TestSchema_RejectsUnsupportedVersions verifies a line from a newer build,
+// or with a nonsense version, is refused rather than misread.
+func TestSchema_RejectsUnsupportedVersions(t *testing.T) {
+	lines := map[string]string{
+		"future":    `{"run_id":"r","seq":1,"type":"run.finished","schema_version":3}`,
+		"zero":      `{"run_id":"r","seq":1,"type":"run.finished","schema_version":0}`,
+		"bad_phase": `{"run_id":"r","step_id":"s","phase":9,"seq":1,"type":"step.started"}`,
+		"no_phase":  `{"run_id":"r","step_id":"s","seq":1,"type":"step.started"}`,
+	}
+	for name, line := range lines {
+		_, err := decodeEvent([]byte(line))
+		assert.Error(t, err, name)
+	}
+
+	event, err := decodeEvent([]byte(`{"run_id":"r","seq":1,"type":"run.finished","schema_version":2}`))
+	require.NoError(t, err)
+	assert.Equal(t, RunFinishedEvent{RunID: "r", Seq: 1, Type: EventTypeRunFinished, SchemaVersion: 2}, event)
+}
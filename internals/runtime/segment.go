@@ -0,0 +1,372 @@
+package runtime
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"aiplatform/pkg/assert"
+)
+
+// A run's log is a directory of segments,
+//
+//	.aiplatform/logs/<run_id>/000001.jsonl
+//	.aiplatform/logs/<run_id>/000002.jsonl
+//	.aiplatform/logs/<run_id>/index.json
+//
+// Events are appended to the highest-numbered segment, the tail. Once the
+// tail reaches LogOptions.SegmentBytesMax or SegmentEventsMax, it is
+// synced, recorded in index.json, and a new tail is started. The hash
+// chain and seq order carry over from one segment to the next, so the
+// segments read in order are exactly one log.
+//
+// The index records where each closed segment ends, so reopening a log
+// only has to scan the tail. Logs written before segmentation are a
+// single .aiplatform/logs/<run_id>.jsonl; readers accept them, and
+// OpenEventLog moves them into a run directory as segment 1.
+
+const (
+	// segmentsPerLogMax bounds how many segments one run's log may hold.
+	// Segment names have six digits.
+	segmentsPerLogMax = 999_999
+
+	// segmentIndexName is the index file inside a run's log directory.
+	segmentIndexName = "index.json"
+
+	// segmentFileSuffix ends every segment's file name.
+	segmentFileSuffix = ".jsonl"
+)
+
+// segmentIndex lists a run's closed segments in order. The tail segment
+// is never listed: it is still being appended to.
+type segmentIndex struct {
+	Segments []segmentEntry `json:"segments"`
+}
+
+// segmentEntry records where a closed segment ends, which is where the
+// next segment's seq order and hash chain resume.
+type segmentEntry struct {
+	Name     string `json:"name"`
+	FirstSeq int64  `json:"first_seq"`
+	LastSeq  int64  `json:"last_seq"`
+	Events   int    `json:"events"`
+
+	// LastHash is the hash the next segment's first line must chain to.
+	LastHash string `json:"last_hash"`
+
+	// Sealed is set if the segment held a sealed line, after which every
+	// line must be sealed.
+	Sealed bool `json:"sealed"`
+}
+
+// last returns the entry of the last closed segment, or the zero entry,
+// from which a log starts, if no segment is closed.
+func (x segmentIndex) last() segmentEntry {
+	if len(x.Segments) == 0 {
+		return segmentEntry{}
+	}
+	return x.Segments[len(x.Segments)-1]
+}
+
+// segmentName returns the file name of the n-th segment, counting from 1.
+func segmentName(n int) string {
+	assert.Is_true(n >= 1 && n <= segmentsPerLogMax, "segment number must be in range")
+	return fmt.Sprintf("%06d%s", n, segmentFileSuffix)
+}
+
+// parseSegmentName returns the number of a segment file name. The second
+// result is false for any other name.
+func parseSegmentName(name string) (int, bool) {
+	digits, ok := strings.CutSuffix(name, segmentFileSuffix)
+	if !ok || len(digits) != 6 {
+		return 0, false
+	}
+	n, err := strconv.Atoi(digits)
+	if err != nil || n < 1 {
+		return 0, false
+	}
+	return n, true
+}
+
+// runLogDirPath returns the directory holding a run's log segments.
+func runLogDirPath(runID RunID, workspaceRoot string) string {
+	assert.Is_true(runID != RunID(""), "runID must not be empty")
+	return filepath.Join(logDirPath(workspaceRoot), string(runID))
+}
+
+// segmentFilePath returns the path of a run's n-th log segment.
+func segmentFilePath(runID RunID, workspaceRoot string, n int) string {
+	return filepath.Join(runLogDirPath(runID, workspaceRoot), segmentName(n))
+}
+
+// legacyLogFilePath returns the single JSONL file a run was logged to
+// before logs were segmented.
+func legacyLogFilePath(runID RunID, workspaceRoot string) string {
+	assert.Is_true(runID != RunID(""), "runID must not be empty")
+	return filepath.Join(logDirPath(workspaceRoot), string(runID)+segmentFileSuffix)
+}
+
+// listSegmentPaths returns the segment files of a run's log directory in
+// order. Segments must be numbered from 1 without gaps: a missing segment
+// would silently drop events from the middle of the run.
+func listSegmentPaths(runDir string) ([]string, error) {
+	assert.Not_empty(runDir, "runDir must not be empty")
+
+	entries, err := os.ReadDir(runDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list log segments in %s: %w", runDir, err)
+	}
+
+	// ReadDir sorts by name, and zero-padded names sort by number.
+	var paths []string
+	for _, entry := range entries {
+		n, ok := parseSegmentName(entry.Name())
+		if !ok || entry.IsDir() {
+			continue
+		}
+		if n != len(paths)+1 {
+			return nil, fmt.Errorf("log %s is missing segment %s", runDir, segmentName(len(paths)+1))
+		}
+		paths = append(paths, filepath.Join(runDir, entry.Name()))
+	}
+	return paths, nil
+}
+
+// runLogPaths returns the files of a run's log in read order: its
+// segments, or the single file of a log written before segmentation.
+func runLogPaths(runID RunID, workspaceRoot string) ([]string, error) {
+	runDir := runLogDirPath(runID, workspaceRoot)
+	legacyPath := legacyLogFilePath(runID, workspaceRoot)
+
+	paths, err := listSegmentPaths(runDir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	_, legacyErr := os.Stat(legacyPath)
+	hasLegacy := legacyErr == nil
+
+	if len(paths) > 0 {
+		if hasLegacy {
+			return nil, fmt.Errorf("run %s has both %s and segments in %s", runID, legacyPath, runDir)
+		}
+		return paths, nil
+	}
+	if hasLegacy {
+		return []string{legacyPath}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event log %s: %w", runDir, os.ErrNotExist)
+	}
+	// A run directory without segments was created by an open that did
+	// not finish; it holds no events.
+	return nil, nil
+}
+
+// tailLogPath returns the file appends go to: the last segment, or a
+// legacy single-file log. Returns "" if the run has no log file yet.
+func tailLogPath(runID RunID, workspaceRoot string) (string, error) {
+	paths, err := runLogPaths(runID, workspaceRoot)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil || len(paths) == 0 {
+		return "", err
+	}
+	return paths[len(paths)-1], nil
+}
+
+// migrateLegacyLog moves a log written before segmentation into the run's
+// log directory as its first segment. The rename is atomic, so a crash
+// leaves either the old layout or the new one; an empty run directory
+// left by a crash before the rename is migrated again.
+func migrateLegacyLog(runID RunID, workspaceRoot string, options LogOptions) error {
+	legacyPath := legacyLogFilePath(runID, workspaceRoot)
+	if _, err := os.Stat(legacyPath); os.IsNotExist(err) {
+		return nil
+	}
+
+	runDir := runLogDirPath(runID, workspaceRoot)
+	if err := os.MkdirAll(runDir, 0755); err != nil {
+		return fmt.Errorf("failed to create log directory %s: %w", runDir, err)
+	}
+	paths, err := listSegmentPaths(runDir)
+	if err != nil {
+		return err
+	}
+	if len(paths) > 0 {
+		return fmt.Errorf("run %s has both %s and segments in %s", runID, legacyPath, runDir)
+	}
+
+	segmentPath := filepath.Join(runDir, segmentName(1))
+	if err := os.Rename(legacyPath, segmentPath); err != nil {
+		return fmt.Errorf("failed to move %s to %s: %w", legacyPath, segmentPath, err)
+	}
+	if options.Sync == SyncNone {
+		return nil
+	}
+	return syncDirs(runDir, logDirPath(workspaceRoot))
+}
+
+// readSegmentIndex reads a run's segment index. A missing index is an
+// empty one: it is rebuilt from the segments it does not cover.
+func readSegmentIndex(runDir string) (segmentIndex, error) {
+	path := filepath.Join(runDir, segmentIndexName)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return segmentIndex{}, nil
+	}
+	if err != nil {
+		return segmentIndex{}, fmt.Errorf("failed to read segment index %s: %w", path, err)
+	}
+
+	var index segmentIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return segmentIndex{}, fmt.Errorf("invalid segment index %s: %w", path, err)
+	}
+	if len(index.Segments) > segmentsPerLogMax {
+		return segmentIndex{}, fmt.Errorf("segment index %s lists %d segments, limit is %d",
+			path, len(index.Segments), segmentsPerLogMax)
+	}
+	return index, nil
+}
+
+// writeSegmentIndex replaces a run's segment index atomically.
+func writeSegmentIndex(runDir string, index segmentIndex) error {
+	data, err := json.MarshalIndent(index, "", "  ")
+	assert.No_err(err, "segment index must marshal")
+	return writeFileAtomic(runDir, filepath.Join(runDir, segmentIndexName), append(data, '\n'))
+}
+
+// segmentTail is where a reopened log resumes appending.
+type segmentTail struct {
+	// index lists every closed segment.
+	index segmentIndex
+
+	// number is the tail segment's number. Its file may not exist yet.
+	number int
+
+	// firstSeq is the seq of the tail segment's first event, or 0 if it
+	// holds none.
+	firstSeq int64
+
+	// events is how many events the tail segment already holds.
+	events int
+
+	// lastSeq is the seq of the last event in the log, or 0 if empty.
+	lastSeq int64
+
+	// lastHash is the hash the next appended line must chain to.
+	lastHash string
+
+	// sealed is set if the log holds a sealed line.
+	sealed bool
+}
+
+// loadSegments finds where a run's log resumes, reading only the tail
+// segment and any closed segment the index does not yet cover.
+//
+// Tiger Beetle Principle: Validate everything during recovery.
+// Every line of the tail is decoded and its checksum and chain link
+// checked before we resume, starting from the chain state the index
+// recorded for the segment before it. If the log is corrupt, we fail fast
+// with a clear error.
+func loadSegments(runDir string) (segmentTail, error) {
+	paths, err := listSegmentPaths(runDir)
+	if err != nil {
+		return segmentTail{}, err
+	}
+	index, err := readSegmentIndex(runDir)
+	if err != nil {
+		return segmentTail{}, err
+	}
+
+	// The index lists a prefix of the segments. It lists them all only if
+	// a crash came between closing a segment and creating the next.
+	if len(index.Segments) > len(paths) {
+		return segmentTail{}, fmt.Errorf("segment index in %s lists %d segments, found %d",
+			runDir, len(index.Segments), len(paths))
+	}
+	for i, entry := range index.Segments {
+		if entry.Name != filepath.Base(paths[i]) {
+			return segmentTail{}, fmt.Errorf("segment index in %s lists %s at position %d, found %s",
+				runDir, entry.Name, i+1, filepath.Base(paths[i]))
+		}
+	}
+
+	// Closed segments written after the index was last updated, or before
+	// it was lost, are indexed now, so the next open can skip them.
+	indexed := len(index.Segments)
+	for len(index.Segments) < len(paths)-1 {
+		entry, err := indexSegment(paths[len(index.Segments)], index.last())
+		if err != nil {
+			return segmentTail{}, err
+		}
+		index.Segments = append(index.Segments, entry)
+	}
+	if len(index.Segments) > indexed {
+		if err := writeSegmentIndex(runDir, index); err != nil {
+			return segmentTail{}, err
+		}
+	}
+
+	last := index.last()
+	tail := segmentTail{
+		index:    index,
+		number:   len(index.Segments) + 1,
+		lastSeq:  last.LastSeq,
+		lastHash: last.LastHash,
+		sealed:   last.Sealed,
+	}
+	if tail.number > segmentsPerLogMax {
+		return segmentTail{}, fmt.Errorf("log %s exceeds %d segments", runDir, segmentsPerLogMax)
+	}
+	if len(paths) == len(index.Segments) {
+		return tail, nil
+	}
+
+	// A torn tail is cut before scanning; one left here would sit between
+	// the last event and the next one appended.
+	entry, err := indexSegment(paths[len(paths)-1], last)
+	if err != nil {
+		return segmentTail{}, err
+	}
+	tail.firstSeq = entry.FirstSeq
+	tail.events = entry.Events
+	tail.lastSeq = entry.LastSeq
+	tail.lastHash = entry.LastHash
+	tail.sealed = entry.Sealed
+	return tail, nil
+}
+
+// indexSegment verifies a whole segment, continuing the seq order and
+// hash chain from the segment before it, and returns its index entry.
+// The segment must end with a complete line.
+func indexSegment(path string, prev segmentEntry) (segmentEntry, error) {
+	reader := logReader{
+		chain:   chainVerifier{lastHash: prev.LastHash, sealed: prev.Sealed},
+		lastSeq: prev.LastSeq,
+	}
+	var firstSeq int64
+	err := reader.readFile(path, func(event Event) error {
+		if firstSeq == 0 {
+			firstSeq = headerOf(event).Seq
+		}
+		return nil
+	}, false)
+	if err != nil {
+		return segmentEntry{}, err
+	}
+
+	return segmentEntry{
+		Name:     filepath.Base(path),
+		FirstSeq: firstSeq,
+		LastSeq:  reader.lastSeq,
+		Events:   reader.events,
+		LastHash: reader.chain.lastHash,
+		Sealed:   reader.chain.sealed,
+	}, nil
+}
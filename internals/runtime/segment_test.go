@@ -0,0 +1,243 @@
+package runtime
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withLimits fills in the default segment and snapshot limits for tests
+// that spell out every other option.
+func withLimits(options LogOptions) LogOptions {
+	options.SegmentBytesMax = segmentBytesDefault
+	options.SegmentEventsMax = segmentEventsDefault
+	options.SnapshotEveryEvents = snapshotEveryEventsDefault
+	return options
+}
+
+// writeSegmentedRun appends run.started and then events-1 step events to
+// a log that rolls over as options say.
+func writeSegmentedRun(t *testing.T, runID RunID, workspaceRoot string, options LogOptions, events int) {
+	t.Helper()
+
+	log, err := OpenEventLog(runID, workspaceRoot, options)
+	require.NoError(t, err)
+	require.NoError(t, log.AppendRunStarted(runID, workspaceRoot))
+	for i := 1; i < events; i++ {
+		stepID := "step-" + string(rune('a'+i/2))
+		if i%2 == 1 {
+			require.NoError(t, log.AppendStepStarted(runID, stepID, PhaseDataIngestion))
+		} else {
+			require.NoError(t, log.AppendStepFinished(runID, stepID, PhaseDataIngestion))
+		}
+	}
+	require.NoError(t, log.Close())
+}
+
+// TestEventLog_RollsOverByEventCount verifies the log starts a new
+// segment every SegmentEventsMax events, indexes the closed ones, and
+// still reads and verifies as one log.
+func TestEventLog_RollsOverByEventCount(t *testing.T) {
+	workspaceRoot := t.TempDir()
+	runID := RunID("run-segments")
+	options := testLogOptions()
+	options.SegmentEventsMax = 3
+	writeSegmentedRun(t, runID, workspaceRoot, options, 8)
+
+	runDir := runLogDirPath(runID, workspaceRoot)
+	paths, err := listSegmentPaths(runDir)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		segmentFilePath(runID, workspaceRoot, 1),
+		segmentFilePath(runID, workspaceRoot, 2),
+		segmentFilePath(runID, workspaceRoot, 3),
+	}, paths)
+
+	index, err := readSegmentIndex(runDir)
+	require.NoError(t, err)
+	require.Len(t, index.Segments, 2)
+	first := readLogLines(t, paths[0])
+	assert.Equal(t, segmentEntry{
+		Name: "000001.jsonl", FirstSeq: 1, LastSeq: 3, Events: 3,
+		LastHash: lineHash([]byte(first[2])), Sealed: true,
+	}, index.Segments[0])
+	assert.Equal(t, int64(4), index.Segments[1].FirstSeq)
+	assert.Equal(t, int64(6), index.Segments[1].LastSeq)
+
+	// The chain carries over: a segment's first line links to the last
+	// line of the one before.
+	second := readLogLines(t, paths[1])
+	assert.Contains(t, second[0], `"prev_hash":"`+index.Segments[0].LastHash+`"`)
+
+	events, err := ReadEvents(runID, workspaceRoot)
+	require.NoError(t, err)
+	require.Len(t, events, 8)
+	for i, event := range events {
+		assert.Equal(t, int64(i+1), headerOf(event).Seq)
+	}
+
+	result, err := VerifyLog(runDir)
+	require.NoError(t, err)
+	assert.Equal(t, 8, result.Events)
+	assert.Equal(t, int64(8), result.LastSeq)
+
+	runIDs, err := listLoggedRuns(workspaceRoot)
+	require.NoError(t, err)
+	assert.Equal(t, []RunID{runID}, runIDs)
+}
+
+// TestEventLog_RollsOverBySize verifies a segment that reaches
+// SegmentBytesMax is closed before the next event is written.
+func TestEventLog_RollsOverBySize(t *testing.T) {
+	workspaceRoot := t.TempDir()
+	runID := RunID("run-segment-bytes")
+	options := testLogOptions()
+	options.SegmentBytesMax = 1
+	writeSegmentedRun(t, runID, workspaceRoot, options, 4)
+
+	paths, err := listSegmentPaths(runLogDirPath(runID, workspaceRoot))
+	require.NoError(t, err)
+	require.Len(t, paths, 4)
+	for _, path := range paths {
+		assert.Len(t, readLogLines(t, path), 1)
+	}
+
+	events, err := ReadEvents(runID, workspaceRoot)
+	require.NoError(t, err)
+	assert.Len(t, events, 4)
+}
+
+// TestOpenEventLog_ScansOnlyTailSegment verifies reopening a log trusts
+// the index for closed segments and resumes the seq and chain from it.
+func TestOpenEventLog_ScansOnlyTailSegment(t *testing.T) {
+	workspaceRoot := t.TempDir()
+	runID := RunID("run-segment-tail")
+	options := testLogOptions()
+	options.SegmentEventsMax = 3
+	writeSegmentedRun(t, runID, workspaceRoot, options, 8)
+
+	// Damage a closed segment. Reopening does not read it.
+	first := segmentFilePath(runID, workspaceRoot, 1)
+	require.NoError(t, os.WriteFile(first, []byte("not a log\n"), 0644))
+
+	log, err := OpenEventLog(runID, workspaceRoot, options)
+	require.NoError(t, err)
+	require.NoError(t, log.AppendStepStarted(runID, "step-z", PhaseDataIngestion))
+	assert.Equal(t, segmentFilePath(runID, workspaceRoot, 3), log.Path())
+	require.NoError(t, log.Close())
+
+	tail := readLogLines(t, segmentFilePath(runID, workspaceRoot, 3))
+	require.Len(t, tail, 3)
+	assert.Contains(t, tail[2], `"seq":9`)
+	assert.Contains(t, tail[2], `"prev_hash":"`+lineHash([]byte(tail[1]))+`"`)
+
+	// Full readers and verification still see the damage.
+	_, err = VerifyLog(runLogDirPath(runID, workspaceRoot))
+	assert.ErrorContains(t, err, "segment 000001.jsonl")
+	_, err = ReadEvents(runID, workspaceRoot)
+	assert.Error(t, err)
+}
+
+// TestOpenEventLog_RebuildsSegmentIndex verifies a lost index is rebuilt
+// from the closed segments, and that an index listing every segment, left
+// by a crash during rollover, resumes in a new tail segment.
+func TestOpenEventLog_RebuildsSegmentIndex(t *testing.T) {
+	workspaceRoot := t.TempDir()
+	runID := RunID("run-segment-index")
+	runDir := runLogDirPath(runID, workspaceRoot)
+	options := testLogOptions()
+	options.SegmentEventsMax = 3
+	writeSegmentedRun(t, runID, workspaceRoot, options, 9)
+
+	want, err := readSegmentIndex(runDir)
+	require.NoError(t, err)
+	require.Len(t, want.Segments, 2)
+
+	require.NoError(t, os.Remove(filepath.Join(runDir, segmentIndexName)))
+	log, err := OpenEventLog(runID, workspaceRoot, options)
+	require.NoError(t, err)
+	require.NoError(t, log.Close())
+	got, err := readSegmentIndex(runDir)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+
+	// The full third segment was indexed but its successor never created.
+	third, err := indexSegment(segmentFilePath(runID, workspaceRoot, 3), got.last())
+	require.NoError(t, err)
+	got.Segments = append(got.Segments, third)
+	require.NoError(t, writeSegmentIndex(runDir, got))
+
+	log, err = OpenEventLog(runID, workspaceRoot, options)
+	require.NoError(t, err)
+	assert.Equal(t, segmentFilePath(runID, workspaceRoot, 4), log.Path())
+	require.NoError(t, log.AppendStepStarted(runID, "step-z", PhaseDataIngestion))
+	require.NoError(t, log.Close())
+
+	result, err := VerifyLog(runDir)
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), result.LastSeq)
+}
+
+// TestSubscribe_AcrossSegments verifies a subscriber reads history that
+// spans segments, then follows live events through a rollover.
+func TestSubscribe_AcrossSegments(t *testing.T) {
+	workspaceRoot := t.TempDir()
+	runID := RunID("run-segment-subscribe")
+	options := testLogOptions()
+	options.SegmentEventsMax = 2
+
+	log, err := OpenEventLog(runID, workspaceRoot, options)
+	require.NoError(t, err)
+	require.NoError(t, log.AppendRunStarted(runID, workspaceRoot))
+	require.NoError(t, log.AppendStepStarted(runID, "s1", PhaseDataIngestion))
+	require.NoError(t, log.AppendStepFinished(runID, "s1", PhaseDataIngestion))
+	require.NoError(t, log.AppendStepStarted(runID, "s2", PhaseSignalGeneration))
+
+	sub, err := log.Subscribe(2)
+	require.NoError(t, err)
+	for seq := int64(2); seq <= 4; seq++ {
+		assert.Equal(t, seq, headerOf(nextEvent(t, sub)).Seq)
+	}
+
+	require.NoError(t, log.AppendStepFinished(runID, "s2", PhaseSignalGeneration))
+	assert.Equal(t, FormatStepFinished(5, testTime, runID, "s2", PhaseSignalGeneration), nextEvent(t, sub))
+	require.NoError(t, log.Close())
+	assert.Empty(t, collectEvents(t, sub))
+	assert.NoError(t, sub.Err())
+	assert.Equal(t, segmentFilePath(runID, workspaceRoot, 3), log.Path())
+}
+
+// TestSubscribe_StartsAtSegmentHoldingFromSeq verifies history catch-up
+// skips the closed segments that end before fromSeq.
+func TestSubscribe_StartsAtSegmentHoldingFromSeq(t *testing.T) {
+	workspaceRoot := t.TempDir()
+	runID := RunID("run-segment-from-seq")
+	options := testLogOptions()
+	options.SegmentEventsMax = 3
+	writeSegmentedRun(t, runID, workspaceRoot, options, 8)
+
+	// Damage the first segment. Catch-up from seq 5 does not read it.
+	first := segmentFilePath(runID, workspaceRoot, 1)
+	require.NoError(t, os.WriteFile(first, []byte("not a log\n"), 0644))
+
+	log, err := OpenEventLog(runID, workspaceRoot, options)
+	require.NoError(t, err)
+	sub, err := log.Subscribe(5)
+	require.NoError(t, err)
+	for seq := int64(5); seq <= 8; seq++ {
+		assert.Equal(t, seq, headerOf(nextEvent(t, sub)).Seq)
+	}
+
+	// Catch-up from the first segment still sees the damage.
+	damaged, err := log.Subscribe(1)
+	require.NoError(t, err)
+	assert.Empty(t, collectEvents(t, damaged))
+	assert.ErrorContains(t, damaged.Err(), "segment 000001.jsonl")
+
+	require.NoError(t, log.Close())
+	assert.Empty(t, collectEvents(t, sub))
+	assert.NoError(t, sub.Err())
+}
@@ -0,0 +1,94 @@
+package runtime
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestShutdown_ClosesLogsAndRejectsCommands verifies Shutdown closes every
+// open log, rejects later commands, and leaves open runs recoverable.
+func TestShutdown_ClosesLogsAndRejectsCommands(t *testing.T) {
+	ctx := context.Background()
+	engine, runID, workspaceRoot := startTestRun(t)
+	_, err := engine.StartStep(ctx, runID, PhaseDataIngestion)
+	require.NoError(t, err)
+
+	require.NoError(t, engine.Shutdown(ctx))
+	require.NoError(t, engine.Shutdown(ctx), "a second Shutdown must return the same result")
+
+	_, err = engine.StartRun(ctx, workspaceRoot)
+	assert.ErrorIs(t, err, ErrEngineClosed)
+	_, err = engine.GetRun(ctx, runID)
+	assert.ErrorIs(t, err, ErrEngineClosed)
+	assert.ErrorIs(t, engine.FailRun(ctx, runID, "late"), ErrEngineClosed)
+
+	// The open run was interrupted, not failed; recovery must see it so.
+	reopened, err := OpenEngine(EngineConfig{
+		WorkspaceRoot:  workspaceRoot,
+		IncompleteRuns: IncompleteRunResume,
+		Log:            testLogOptions(),
+	})
+	require.NoError(t, err)
+	run, err := reopened.GetRun(ctx, runID)
+	require.NoError(t, err)
+	assert.True(t, run.Incomplete)
+	assert.Equal(t, int64(2), run.LastSeq)
+	require.NoError(t, reopened.Shutdown(ctx))
+}
+
+// TestShutdown_DrainsQueuedCommands verifies commands queued before
+// Shutdown began are still handled.
+func TestShutdown_DrainsQueuedCommands(t *testing.T) {
+	workspaceRoot := resolvedTempDir(t)
+	reg := newRegistry(testLogOptions())
+	engine := &Engine{
+		cmdCh:   make(chan Command, 1),
+		closing: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	// Queue a command, then begin Shutdown before the run loop exists.
+	resultCh := make(chan StartRunResult, 1)
+	engine.cmdCh <- StartRunCmd{WorkspaceRoot: workspaceRoot, ResultCh: resultCh}
+	engine.closeOnce.Do(func() { close(engine.closing) })
+	go engine.runLoop(reg)
+
+	require.NoError(t, engine.Shutdown(context.Background()))
+
+	result := <-resultCh
+	require.NoError(t, result.Err)
+	assert.Contains(t, reg.runs, result.ID)
+	assert.Empty(t, reg.logs, "the drained run's log must be closed")
+}
+
+// TestCommands_HonorContext verifies callers stop waiting on an engine
+// whose run loop is not making progress.
+func TestCommands_HonorContext(t *testing.T) {
+	// No run loop: nothing ever receives from the unbuffered queue.
+	stuck := &Engine{
+		cmdCh:   make(chan Command),
+		closing: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := stuck.StartRun(ctx, resolvedTempDir(t))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = stuck.ListRuns(cancelled, RunFilter{})
+	assert.ErrorIs(t, err, context.Canceled)
+
+	// Shutdown itself gives up when the drain cannot finish in time.
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, stuck.Shutdown(ctx), context.DeadlineExceeded)
+	_, err = stuck.StartRun(context.Background(), resolvedTempDir(t))
+	assert.ErrorIs(t, err, ErrEngineClosed)
+}
@@ -0,0 +1,381 @@
+package runtime
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"aiplatform/pkg/assert"
+)
+
+// A snapshot is a run's derived state as of one seq, written next to the
+// run's log segments as snapshot.<seq>.json. Recovery loads the latest
+// valid snapshot and replays only the events after it, instead of the
+// whole log. Besides the run's handle, a snapshot carries what the
+// validator had learned, including every order's state, so the events
+// after it are validated as strictly as a full replay would.
+//
+// A snapshot names the seq it covers and the hash of that event's line,
+// so it can only be applied to the log it was taken from. Its state is
+// checksummed; a snapshot that fails either check is skipped in favor of
+// an older one, and without any, the run is replayed in full.
+
+const (
+	// snapshotVersionCurrent is the encoding of snapshot state. Snapshots
+	// of another version are skipped, not migrated: the log can always
+	// rebuild what they held.
+	snapshotVersionCurrent = 2
+
+	// snapshotsKept is how many snapshots a run keeps. The one before the
+	// latest is a fallback if the latest turns out to be damaged.
+	snapshotsKept = 2
+
+	// snapshotsPerRunMax bounds how many snapshot files recovery considers.
+	snapshotsPerRunMax = 64
+
+	snapshotFilePrefix = "snapshot."
+	snapshotFileSuffix = ".json"
+)
+
+// errSnapshotMismatch means a snapshot does not describe the log on disk:
+// the seq it covers is missing, or its line hashes differently.
+var errSnapshotMismatch = errors.New("snapshot does not match the log")
+
+// snapshotFile is the on-disk form of a snapshot. SHA256 covers State
+// exactly as stored.
+type snapshotFile struct {
+	Version int             `json:"snapshot_version"`
+	SHA256  string          `json:"sha256"`
+	State   json.RawMessage `json:"state"`
+}
+
+// runSnapshot is the derived state of a run as of Seq.
+type runSnapshot struct {
+	RunID RunID `json:"run_id"`
+
+	// Seq is the last event the state covers.
+	Seq int64 `json:"seq"`
+
+	// LastHash is the hash of the line holding Seq.
+	LastHash string `json:"last_hash"`
+
+	WorkspaceRoot string               `json:"workspace_root"`
+	Terminal      bool                 `json:"terminal"`
+	Phase         Phase                `json:"phase"`
+	Attempts      map[Phase]int        `json:"attempts"`
+	PhaseDone     map[Phase]bool       `json:"phase_done"`
+	Steps         map[string]StepState `json:"steps"`
+
+	// Validation is the validator's state after Seq.
+	Validation validatorState `json:"validation"`
+}
+
+// newRunSnapshot captures a handle's state at the head of its log.
+func newRunSnapshot(handle *RunHandle, head logHead) runSnapshot {
+	assert.Not_nil(handle, "handle must not be nil")
+	assert.Is_true(head.lastSeq == handle.LastSeq, "handle must be up to date with its log")
+	assert.Not_empty(head.lastHash, "a snapshot must cover at least one line")
+
+	return runSnapshot{
+		RunID:         handle.ID,
+		Seq:           handle.LastSeq,
+		LastHash:      head.lastHash,
+		WorkspaceRoot: handle.WorkspaceRoot,
+		Terminal:      handle.Terminal,
+		Phase:         handle.Phase,
+		Attempts:      handle.Attempts,
+		PhaseDone:     handle.PhaseDone,
+		Steps:         handle.Steps,
+		Validation:    head.validation,
+	}
+}
+
+// handle returns a new RunHandle holding the snapshot's state.
+func (s runSnapshot) handle() *RunHandle {
+	handle := &RunHandle{
+		ID:            s.RunID,
+		LastSeq:       s.Seq,
+		Terminal:      s.Terminal,
+		Phase:         s.Phase,
+		WorkspaceRoot: s.WorkspaceRoot,
+		Attempts:      make(map[Phase]int, len(s.Attempts)),
+		PhaseDone:     make(map[Phase]bool, len(s.PhaseDone)),
+		Steps:         make(map[string]StepState, len(s.Steps)),
+	}
+	for phase, attempts := range s.Attempts {
+		handle.Attempts[phase] = attempts
+	}
+	for phase, done := range s.PhaseDone {
+		handle.PhaseDone[phase] = done
+	}
+	for stepID, step := range s.Steps {
+		handle.Steps[stepID] = step
+	}
+	return handle
+}
+
+// validate checks a decoded snapshot is complete.
+func (s runSnapshot) validate(runID RunID) error {
+	if s.RunID != runID {
+		return fmt.Errorf("snapshot belongs to run %s, expected %s", s.RunID, runID)
+	}
+	if s.Seq < 1 {
+		return fmt.Errorf("snapshot seq must be positive, got %d", s.Seq)
+	}
+	if s.LastHash == "" {
+		return fmt.Errorf("snapshot has no line hash")
+	}
+	if !s.Phase.IsValid() {
+		return fmt.Errorf("snapshot has invalid phase %d", s.Phase)
+	}
+	if len(s.Steps) > stepsPerRunMax {
+		return fmt.Errorf("snapshot holds %d steps, limit is %d", len(s.Steps), stepsPerRunMax)
+	}
+	if err := s.Validation.validate(); err != nil {
+		return fmt.Errorf("snapshot validation state: %w", err)
+	}
+	return nil
+}
+
+// snapshotFileName names the snapshot covering seq.
+func snapshotFileName(seq int64) string {
+	assert.Gt(seq, 0, "seq must be positive")
+	return snapshotFilePrefix + strconv.FormatInt(seq, 10) + snapshotFileSuffix
+}
+
+// listSnapshotSeqs returns the seqs of a run's snapshots, newest first.
+// A run without a log directory has no snapshots.
+func listSnapshotSeqs(runDir string) ([]int64, error) {
+	entries, err := os.ReadDir(runDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots in %s: %w", runDir, err)
+	}
+
+	var seqs []int64
+	for _, entry := range entries {
+		digits, ok := strings.CutPrefix(entry.Name(), snapshotFilePrefix)
+		digits, isSnapshot := strings.CutSuffix(digits, snapshotFileSuffix)
+		if !ok || !isSnapshot || entry.IsDir() {
+			continue
+		}
+		seq, err := strconv.ParseInt(digits, 10, 64)
+		if err != nil || seq < 1 {
+			continue
+		}
+		seqs = append(seqs, seq)
+	}
+	slices.Sort(seqs)
+	slices.Reverse(seqs)
+	if len(seqs) > snapshotsPerRunMax {
+		seqs = seqs[:snapshotsPerRunMax]
+	}
+	return seqs, nil
+}
+
+// writeSnapshot atomically writes a snapshot of a run's state at the head
+// of its log, then removes all but the newest snapshotsKept snapshots.
+func writeSnapshot(runDir string, snapshot runSnapshot) error {
+	state, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+	sum := sha256.Sum256(state)
+	data, err := json.Marshal(snapshotFile{
+		Version: snapshotVersionCurrent,
+		SHA256:  hex.EncodeToString(sum[:]),
+		State:   state,
+	})
+	assert.No_err(err, "snapshot file must marshal")
+
+	path := filepath.Join(runDir, snapshotFileName(snapshot.Seq))
+	if err := writeFileAtomic(runDir, path, append(data, '\n')); err != nil {
+		return err
+	}
+
+	seqs, err := listSnapshotSeqs(runDir)
+	if err != nil {
+		return err
+	}
+	for _, seq := range seqs[min(snapshotsKept, len(seqs)):] {
+		old := filepath.Join(runDir, snapshotFileName(seq))
+		if err := os.Remove(old); err != nil {
+			return fmt.Errorf("failed to remove old snapshot %s: %w", old, err)
+		}
+	}
+	return nil
+}
+
+// readSnapshot reads and checks the snapshot covering seq.
+func readSnapshot(runID RunID, runDir string, seq int64) (runSnapshot, error) {
+	path := filepath.Join(runDir, snapshotFileName(seq))
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return runSnapshot{}, fmt.Errorf("failed to read snapshot %s: %w", path, err)
+	}
+
+	var file snapshotFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return runSnapshot{}, fmt.Errorf("invalid snapshot %s: %w", path, err)
+	}
+	if file.Version != snapshotVersionCurrent {
+		return runSnapshot{}, fmt.Errorf("snapshot %s has version %d, this build reads %d",
+			path, file.Version, snapshotVersionCurrent)
+	}
+	sum := sha256.Sum256(file.State)
+	if hex.EncodeToString(sum[:]) != file.SHA256 {
+		return runSnapshot{}, fmt.Errorf("snapshot %s: %w", path, ErrChecksumMismatch)
+	}
+
+	var snapshot runSnapshot
+	if err := json.Unmarshal(file.State, &snapshot); err != nil {
+		return runSnapshot{}, fmt.Errorf("invalid snapshot state %s: %w", path, err)
+	}
+	if err := snapshot.validate(runID); err != nil {
+		return runSnapshot{}, fmt.Errorf("snapshot %s: %w", path, err)
+	}
+	if snapshot.Seq != seq {
+		return runSnapshot{}, fmt.Errorf("snapshot %s covers seq %d", path, snapshot.Seq)
+	}
+	return snapshot, nil
+}
+
+// replayFromSnapshot rebuilds a run's handle from its latest valid
+// snapshot and the events after it, and returns the seq the snapshot
+// covered. Returns a nil handle if the run has no usable snapshot.
+//
+// Events the snapshot covers were validated before it was written. The
+// events after it are validated by a validator resumed from the
+// snapshot, and a violation among them is an error, as in a full replay.
+func replayFromSnapshot(runID RunID, workspaceRoot string) (*RunHandle, int64, error) {
+	resumed, err := resumeFromSnapshot(runID, workspaceRoot)
+	if err != nil || resumed == nil {
+		return nil, 0, err
+	}
+
+	// The run may have crashed mid-flight, so a missing terminal event is
+	// expected here; everything else must hold.
+	resumed.validator.finish()
+	if violations := resumed.validator.violations; len(violations) > 0 {
+		return nil, 0, fmt.Errorf("log after snapshot at seq %d violates %d invariant(s), first: %s",
+			resumed.seq, len(violations), violations[0])
+	}
+	return resumed.handle, resumed.seq, nil
+}
+
+// resumedRun is a run's state rebuilt from a snapshot and the events
+// after it.
+type resumedRun struct {
+	handle    *RunHandle
+	validator *eventValidator
+
+	// seq is the last event the snapshot covered.
+	seq int64
+}
+
+// resumeFromSnapshot folds the events after a run's latest valid snapshot
+// into the snapshot's handle and into a validator resumed from its state.
+// Snapshots that are damaged, or do not match the log, are skipped in
+// favor of older ones. Returns nil if the run has no usable snapshot.
+//
+// Only the events after the snapshot are read. Corruption in those is an
+// error, not a reason to try an older snapshot. The validator's
+// violations are left for the caller to judge.
+func resumeFromSnapshot(runID RunID, workspaceRoot string) (*resumedRun, error) {
+	runDir := runLogDirPath(runID, workspaceRoot)
+	seqs, err := listSnapshotSeqs(runDir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, seq := range seqs {
+		snapshot, err := readSnapshot(runID, runDir, seq)
+		if err != nil {
+			continue
+		}
+
+		handle := snapshot.handle()
+		validator := resumeEventValidator(ReplayModeInProgress, runID, snapshot.Seq, snapshot.Validation)
+		err = readRunLogAfter(runID, workspaceRoot, snapshot.Seq, snapshot.LastHash, func(event Event) error {
+			validator.check(event)
+			next, err := applyEvent(handle, event)
+			handle = next
+			return err
+		})
+		if errors.Is(err, errSnapshotMismatch) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &resumedRun{handle: handle, validator: validator, seq: snapshot.Seq}, nil
+	}
+	return nil, nil
+}
+
+// readRunLogAfter decodes the events of a run's log with seq after
+// afterSeq and hands each to visit. afterHash must be the hash of the
+// line holding afterSeq, or errSnapshotMismatch is returned.
+//
+// Closed segments that end at or before afterSeq are skipped by
+// seekRunLog, as readRunLogFrom skips them; lines up to afterSeq in the
+// first segment read are verified but not visited. Without a usable index
+// the whole log is read.
+func readRunLogAfter(runID RunID, workspaceRoot string, afterSeq int64, afterHash string,
+	visit func(Event) error) error {
+	assert.Gt(afterSeq, 0, "afterSeq must be positive")
+	assert.Not_empty(afterHash, "afterHash must not be empty")
+
+	paths, err := runLogPaths(runID, workspaceRoot)
+	if err != nil {
+		return err
+	}
+
+	reader, start, err := seekRunLog(paths, afterSeq+1)
+	if err != nil {
+		return err
+	}
+	// A skipped segment may end exactly at afterSeq.
+	found := start > 0 && reader.lastSeq == afterSeq
+	if found && reader.chain.lastHash != afterHash {
+		return errSnapshotMismatch
+	}
+
+	for i := start; i < len(paths); i++ {
+		err := reader.readFile(paths[i], func(event Event) error {
+			seq := headerOf(event).Seq
+			switch {
+			case seq < afterSeq:
+				return nil
+			case seq == afterSeq:
+				if reader.chain.lastHash != afterHash {
+					return errSnapshotMismatch
+				}
+				found = true
+				return nil
+			case !found:
+				return errSnapshotMismatch
+			}
+			return visit(event)
+		}, i == len(paths)-1)
+		if err != nil && len(paths) > 1 && !errors.Is(err, errSnapshotMismatch) {
+			return fmt.Errorf("segment %s: %w", filepath.Base(paths[i]), err)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if !found {
+		return errSnapshotMismatch
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package runtime
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeSnapshottedRun writes six events through an engine using options,
+// and leaves the run open with a step running.
+func writeSnapshottedRun(t *testing.T, options LogOptions) (RunID, string) {
+	t.Helper()
+	ctx := context.Background()
+	workspaceRoot := resolvedTempDir(t)
+
+	engine := startEngine(newRegistry(options))
+	runID, err := engine.StartRun(ctx, workspaceRoot)
+	require.NoError(t, err)
+	finishPhase(t, engine, runID, PhaseDataIngestion)
+	finishPhase(t, engine, runID, PhaseSignalGeneration)
+	_, err = engine.StartStep(ctx, runID, PhaseRiskValidation)
+	require.NoError(t, err)
+	require.NoError(t, engine.Shutdown(ctx))
+	return runID, workspaceRoot
+}
+
+// TestSnapshot_PlusTailMatchesFullReplay verifies a snapshot plus the
+// events after it rebuild exactly the state a full replay does, whether
+// or not the snapshot's seq falls inside a closed segment.
+func TestSnapshot_PlusTailMatchesFullReplay(t *testing.T) {
+	for name, segmentEvents := range map[string]int{"one_segment": segmentEventsDefault, "segmented": 2} {
+		t.Run(name, func(t *testing.T) {
+			options := testLogOptions()
+			options.SnapshotEveryEvents = 4
+			options.SegmentEventsMax = segmentEvents
+			runID, workspaceRoot := writeSnapshottedRun(t, options)
+
+			seqs, err := listSnapshotSeqs(runLogDirPath(runID, workspaceRoot))
+			require.NoError(t, err)
+			assert.Equal(t, []int64{4}, seqs)
+
+			full, err := Replay(runID, workspaceRoot)
+			require.NoError(t, err)
+			fromSnapshot, snapshotSeq, err := replayFromSnapshot(runID, workspaceRoot)
+			require.NoError(t, err)
+			assert.Equal(t, int64(4), snapshotSeq)
+			assert.Equal(t, int64(6), fromSnapshot.LastSeq)
+			assert.Equal(t, full, fromSnapshot)
+
+			recovered, snapshotSeq, err := recoverRun(runID, workspaceRoot)
+			require.NoError(t, err)
+			assert.Equal(t, int64(4), snapshotSeq)
+			assert.True(t, recovered.Incomplete)
+			recovered.Incomplete = false
+			assert.Equal(t, full, recovered)
+		})
+	}
+}
+
+// TestSnapshot_TakenWhenRunEnds verifies a terminal run is snapshotted at
+// its last event, so recovering it reads no events at all.
+func TestSnapshot_TakenWhenRunEnds(t *testing.T) {
+	ctx := context.Background()
+	engine, runID, workspaceRoot := startTestRun(t)
+	require.NoError(t, engine.FailRun(ctx, runID, "broker unavailable"))
+	require.NoError(t, engine.Shutdown(ctx))
+
+	handle, snapshotSeq, err := replayFromSnapshot(runID, workspaceRoot)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), snapshotSeq)
+	assert.True(t, handle.Terminal)
+
+	full, err := Replay(runID, workspaceRoot)
+	require.NoError(t, err)
+	assert.Equal(t, full, handle)
+}
+
+// TestSnapshot_FailureCountedOnRun verifies a snapshot that cannot be
+// written leaves the append acknowledged and is counted on the run.
+func TestSnapshot_FailureCountedOnRun(t *testing.T) {
+	ctx := context.Background()
+	options := testLogOptions()
+	options.SnapshotEveryEvents = 2
+	engine := startEngine(newRegistry(options))
+	defer engine.Shutdown(ctx)
+	workspaceRoot := resolvedTempDir(t)
+	runID, err := engine.StartRun(ctx, workspaceRoot)
+	require.NoError(t, err)
+
+	// A directory in the snapshot's place makes its rename fail.
+	runDir := runLogDirPath(runID, workspaceRoot)
+	require.NoError(t, os.Mkdir(filepath.Join(runDir, snapshotFileName(2)), 0755))
+	_, err = engine.StartStep(ctx, runID, PhaseDataIngestion)
+	require.NoError(t, err)
+
+	handle, err := engine.GetRun(ctx, runID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), handle.LastSeq)
+	assert.Equal(t, 1, handle.SnapshotFailures)
+	assert.ErrorContains(t, handle.SnapshotErr, "snapshot of run "+string(runID)+" at seq 2")
+}
+
+// TestSnapshot_SkipsInvalidSnapshots verifies recovery falls back to an
+// older snapshot when the latest is damaged, and to a full replay when
+// no snapshot matches the log.
+func TestSnapshot_SkipsInvalidSnapshots(t *testing.T) {
+	options := testLogOptions()
+	options.SnapshotEveryEvents = 2
+	runID, workspaceRoot := writeSnapshottedRun(t, options)
+	runDir := runLogDirPath(runID, workspaceRoot)
+
+	// Only the newest snapshotsKept snapshots are kept.
+	seqs, err := listSnapshotSeqs(runDir)
+	require.NoError(t, err)
+	require.Equal(t, []int64{6, 4}, seqs)
+
+	full, err := Replay(runID, workspaceRoot)
+	require.NoError(t, err)
+
+	// A snapshot whose state fails its checksum is skipped.
+	latest := filepath.Join(runDir, snapshotFileName(6))
+	data, err := os.ReadFile(latest)
+	require.NoError(t, err)
+	var file snapshotFile
+	require.NoError(t, json.Unmarshal(data, &file))
+	file.State = bytes.Replace(file.State, []byte(`"terminal":false`), []byte(`"terminal":true`), 1)
+	data, err = json.Marshal(file)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(latest, data, 0644))
+	_, err = readSnapshot(runID, runDir, 6)
+	assert.ErrorIs(t, err, ErrChecksumMismatch)
+
+	handle, snapshotSeq, err := replayFromSnapshot(runID, workspaceRoot)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), snapshotSeq)
+	assert.Equal(t, full, handle)
+
+	// A snapshot taken from a different log does not match this one.
+	older, err := readSnapshot(runID, runDir, 4)
+	require.NoError(t, err)
+	older.LastHash = lineHash([]byte("another log"))
+	require.NoError(t, writeSnapshot(runDir, older))
+
+	handle, snapshotSeq, err = replayFromSnapshot(runID, workspaceRoot)
+	require.NoError(t, err)
+	assert.Nil(t, handle)
+	assert.Zero(t, snapshotSeq)
+
+	recovered, snapshotSeq, err := recoverRun(runID, workspaceRoot)
+	require.NoError(t, err)
+	assert.Zero(t, snapshotSeq)
+	assert.Equal(t, full.LastSeq, recovered.LastSeq)
+	assert.Equal(t, full.Steps, recovered.Steps)
+}
+
+// TestSnapshot_ValidatesTail verifies the events after a snapshot are
+// validated against the calls and orders it recorded: a tail that answers
+// a call or fills an order opened before the snapshot is accepted, and a
+// tail that breaks an invariant fails recovery as a full replay would.
+func TestSnapshot_ValidatesTail(t *testing.T) {
+	workspaceRoot := resolvedTempDir(t)
+	runID := RunID("run-snapshot-tail")
+	log, err := OpenEventLog(runID, workspaceRoot, testLogOptions())
+	require.NoError(t, err)
+	require.NoError(t, log.AppendRunStarted(runID, workspaceRoot))
+	require.NoError(t, log.AppendStepStarted(runID, "s1", PhaseDataIngestion))
+	require.NoError(t, log.AppendLLMRequested(runID, "s1", testLLMRequest("llm-1")))
+	require.NoError(t, log.AppendOrderSubmitted(runID, "s1", testOrder("order-1")))
+
+	head, err := log.head()
+	require.NoError(t, err)
+	handle, err := Replay(runID, workspaceRoot)
+	require.NoError(t, err)
+	require.NoError(t, writeSnapshot(log.runDir, newRunSnapshot(handle, head)))
+
+	require.NoError(t, log.AppendLLMResponded(runID, "s1", testLLMResponse("llm-1")))
+	require.NoError(t, log.AppendOrderFilled(runID, "s1", testFill("order-1", "fill-1", 10)))
+	require.NoError(t, log.Close())
+
+	full, err := Replay(runID, workspaceRoot)
+	require.NoError(t, err)
+	fromSnapshot, snapshotSeq, err := replayFromSnapshot(runID, workspaceRoot)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), snapshotSeq)
+	assert.Equal(t, full, fromSnapshot)
+
+	// A reopened log resumes its validator from the snapshot.
+	log, err = OpenEventLog(runID, workspaceRoot, testLogOptions())
+	require.NoError(t, err)
+	head, err = log.head()
+	require.NoError(t, err)
+	order, ok := head.validation.Orders.Order("order-1")
+	require.True(t, ok)
+	assert.Equal(t, OrderStatusFilled, order.Status)
+	assert.True(t, head.validation.LLMCalls["llm-1"].Responded)
+
+	// The log accepts a result for a call never made; replay does not.
+	require.NoError(t, log.AppendToolReturned(runID, "s1", testToolResult("call-1")))
+	require.NoError(t, log.Close())
+
+	_, _, err = replayFromSnapshot(runID, workspaceRoot)
+	assert.ErrorContains(t, err, "log after snapshot at seq 4 violates")
+	_, _, err = recoverRun(runID, workspaceRoot)
+	assert.ErrorContains(t, err, InvariantToolLifecycle)
+}
@@ -0,0 +1,284 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+
+	"aiplatform/pkg/assert"
+)
+
+// stepsPerRunMax bounds the steps one run may start, so a strategy stuck
+// in a retry loop fails fast instead of growing its handle without limit.
+const stepsPerRunMax = 10_000
+
+// StepStatus is the derived lifecycle state of a step.
+// The zero value is invalid.
+type StepStatus int
+
+const (
+	StepStatusRunning  StepStatus = 1
+	StepStatusFinished StepStatus = 2
+	StepStatusFailed   StepStatus = 3
+)
+
+// StepState is what a RunHandle remembers about one step.
+type StepState struct {
+	Phase  Phase      `json:"phase"`
+	Status StepStatus `json:"status"`
+}
+
+// StartStepCmd starts a new step in the given phase of a run.
+type StartStepCmd struct {
+	RunID    RunID
+	Phase    Phase
+	ResultCh chan<- StartStepResult
+}
+
+func (StartStepCmd) command() {}
+
+// StartStepResult is the result of starting a step.
+type StartStepResult struct {
+	StepID string
+	Err    error
+}
+
+// FinishStepCmd marks a running step as finished.
+type FinishStepCmd struct {
+	RunID    RunID
+	StepID   string
+	ResultCh chan<- StepResult
+}
+
+func (FinishStepCmd) command() {}
+
+// FailStepCmd marks a running step as failed.
+type FailStepCmd struct {
+	RunID    RunID
+	StepID   string
+	Reason   string
+	ResultCh chan<- StepResult
+}
+
+func (FailStepCmd) command() {}
+
+// StepResult is the result of finishing or failing a step.
+type StepResult struct {
+	Err error
+}
+
+// activeRun looks up a run that may still accept events.
+func activeRun(reg *registry, runID RunID) (*RunHandle, error) {
+	assert.Not_nil(reg, "registry must not be nil")
+
+	handle, exists := reg.runs[runID]
+	if !exists {
+		return nil, fmt.Errorf("run %s: %w", runID, ErrRunNotFound)
+	}
+	if handle.Terminal {
+		return nil, fmt.Errorf("run %s: %w", runID, ErrRunTerminal)
+	}
+	return handle, nil
+}
+
+// checkStepStart decides whether a new step may start in phase.
+//
+// Retrying the current phase is always allowed. Moving forward additionally
+// requires the current phase to have a finished step and no running ones:
+// a later phase must never act on data an earlier phase has not produced.
+func checkStepStart(handle *RunHandle, phase Phase) error {
+	assert.Not_nil(handle, "handle must not be nil")
+	assert.Is_true(handle.Phase.IsValid(), "run phase must be valid")
+
+	if !phase.IsValid() {
+		return fmt.Errorf("invalid phase: %d", phase)
+	}
+	if len(handle.Steps) >= stepsPerRunMax {
+		return fmt.Errorf("run %s reached the limit of %d steps", handle.ID, stepsPerRunMax)
+	}
+	if !IsValidTransition(handle.Phase, phase) {
+		return fmt.Errorf("illegal phase transition %s -> %s", handle.Phase, phase)
+	}
+	if phase == handle.Phase {
+		return nil
+	}
+
+	if !handle.PhaseDone[handle.Phase] {
+		return fmt.Errorf("cannot enter %s: %s has no finished step", phase, handle.Phase)
+	}
+	for stepID, step := range handle.Steps {
+		if step.Status == StepStatusRunning {
+			return fmt.Errorf("cannot enter %s: step %s is still running in %s",
+				phase, stepID, step.Phase)
+		}
+	}
+	return nil
+}
+
+// runningStep looks up a step that has started and not yet terminated.
+func runningStep(handle *RunHandle, stepID string) (StepState, error) {
+	assert.Not_nil(handle, "handle must not be nil")
+	assert.Is_true(handle.Steps != nil, "steps map must not be nil")
+
+	step, exists := handle.Steps[stepID]
+	if !exists {
+		return StepState{}, fmt.Errorf("step %s not found in run %s", stepID, handle.ID)
+	}
+	if step.Status != StepStatusRunning {
+		return StepState{}, fmt.Errorf("step %s in run %s has already terminated", stepID, handle.ID)
+	}
+
+	// The engine never leaves a step running across a phase change, but a
+	// recovered log may have; terminating it now would move phases backward.
+	if !IsValidTransition(handle.Phase, step.Phase) {
+		return StepState{}, fmt.Errorf("step %s is in %s but run %s has moved to %s",
+			stepID, step.Phase, handle.ID, handle.Phase)
+	}
+	return step, nil
+}
+
+// handleStartStep processes a StartStepCmd.
+func (e *Engine) handleStartStep(reg *registry, cmd StartStepCmd) {
+	assert.Not_nil(reg, "registry must not be nil")
+	assert.Not_nil(cmd.ResultCh, "result channel must not be nil")
+
+	handle, err := activeRun(reg, cmd.RunID)
+	if err == nil {
+		err = checkStepStart(handle, cmd.Phase)
+	}
+	if err != nil {
+		cmd.ResultCh <- StartStepResult{Err: err}
+		return
+	}
+
+	uuid, err := generateUUID()
+	assert.No_err(err, "failed to generate step ID")
+	stepID := "step-" + uuid
+	_, collision := handle.Steps[stepID]
+	assert.Is_true(!collision, fmt.Sprintf("step ID collision: %s", stepID))
+
+	err = reg.appendEvent(handle,
+		func(log *EventLog) error { return log.AppendStepStarted(handle.ID, stepID, cmd.Phase) },
+		func(handle *RunHandle) {
+			handle.Phase = cmd.Phase
+			handle.Attempts[cmd.Phase]++
+			handle.Steps[stepID] = StepState{Phase: cmd.Phase, Status: StepStatusRunning}
+		})
+	if err != nil {
+		cmd.ResultCh <- StartStepResult{Err: err}
+		return
+	}
+
+	cmd.ResultCh <- StartStepResult{StepID: stepID}
+}
+
+// handleFinishStep processes a FinishStepCmd.
+func (e *Engine) handleFinishStep(reg *registry, cmd FinishStepCmd) {
+	assert.Not_nil(reg, "registry must not be nil")
+	assert.Not_nil(cmd.ResultCh, "result channel must not be nil")
+
+	handle, err := activeRun(reg, cmd.RunID)
+	if err != nil {
+		cmd.ResultCh <- StepResult{Err: err}
+		return
+	}
+	step, err := runningStep(handle, cmd.StepID)
+	if err != nil {
+		cmd.ResultCh <- StepResult{Err: err}
+		return
+	}
+
+	err = reg.appendEvent(handle,
+		func(log *EventLog) error { return log.AppendStepFinished(handle.ID, cmd.StepID, step.Phase) },
+		func(handle *RunHandle) {
+			handle.PhaseDone[step.Phase] = true
+			handle.Steps[cmd.StepID] = StepState{Phase: step.Phase, Status: StepStatusFinished}
+		})
+	cmd.ResultCh <- StepResult{Err: err}
+}
+
+// handleFailStep processes a FailStepCmd.
+func (e *Engine) handleFailStep(reg *registry, cmd FailStepCmd) {
+	assert.Not_nil(reg, "registry must not be nil")
+	assert.Not_nil(cmd.ResultCh, "result channel must not be nil")
+
+	if cmd.Reason == "" {
+		cmd.ResultCh <- StepResult{Err: fmt.Errorf("reason must not be empty")}
+		return
+	}
+	handle, err := activeRun(reg, cmd.RunID)
+	if err != nil {
+		cmd.ResultCh <- StepResult{Err: err}
+		return
+	}
+	step, err := runningStep(handle, cmd.StepID)
+	if err != nil {
+		cmd.ResultCh <- StepResult{Err: err}
+		return
+	}
+
+	err = reg.appendEvent(handle,
+		func(log *EventLog) error {
+			return log.AppendStepFailed(handle.ID, cmd.StepID, step.Phase, cmd.Reason)
+		},
+		func(handle *RunHandle) {
+			handle.Steps[cmd.StepID] = StepState{Phase: step.Phase, Status: StepStatusFailed}
+		})
+	cmd.ResultCh <- StepResult{Err: err}
+}
+
+// StartStep starts a new step in the given phase and returns its step ID.
+// Attempts for the phase are counted per started step, so retries show up
+// as repeated attempts in the same phase.
+func (e *Engine) StartStep(ctx context.Context, runID RunID, phase Phase) (string, error) {
+	resultCh := make(chan StartStepResult, 1)
+	err := e.submit(ctx, StartStepCmd{
+		RunID:    runID,
+		Phase:    phase,
+		ResultCh: resultCh,
+	})
+	if err != nil {
+		return "", err
+	}
+	result, err := await(ctx, e, resultCh)
+	if err != nil {
+		return "", err
+	}
+	return result.StepID, result.Err
+}
+
+// FinishStep marks a running step as finished, completing its phase.
+func (e *Engine) FinishStep(ctx context.Context, runID RunID, stepID string) error {
+	resultCh := make(chan StepResult, 1)
+	err := e.submit(ctx, FinishStepCmd{
+		RunID:    runID,
+		StepID:   stepID,
+		ResultCh: resultCh,
+	})
+	if err != nil {
+		return err
+	}
+	result, err := await(ctx, e, resultCh)
+	if err != nil {
+		return err
+	}
+	return result.Err
+}
+
+// FailStep marks a running step as failed. The phase stays open for retries.
+func (e *Engine) FailStep(ctx context.Context, runID RunID, stepID string, reason string) error {
+	resultCh := make(chan StepResult, 1)
+	err := e.submit(ctx, FailStepCmd{
+		RunID:    runID,
+		StepID:   stepID,
+		Reason:   reason,
+		ResultCh: resultCh,
+	})
+	if err != nil {
+		return err
+	}
+	result, err := await(ctx, e, resultCh)
+	if err != nil {
+		return err
+	}
+	return result.Err
+}
@@ -0,0 +1,151 @@
+package runtime
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startTestRun starts a run on a fresh engine in a temp workspace.
+func startTestRun(t *testing.T) (*Engine, RunID, string) {
+	t.Helper()
+	workspaceRoot := resolvedTempDir(t)
+	engine := startEngine(newRegistry(testLogOptions()))
+	runID, err := engine.StartRun(context.Background(), workspaceRoot)
+	require.NoError(t, err)
+	return engine, runID, workspaceRoot
+}
+
+// finishPhase starts and finishes one step in phase.
+func finishPhase(t *testing.T, engine *Engine, runID RunID, phase Phase) string {
+	t.Helper()
+	stepID, err := engine.StartStep(context.Background(), runID, phase)
+	require.NoError(t, err)
+	require.NoError(t, engine.FinishStep(context.Background(), runID, stepID))
+	return stepID
+}
+
+// TestStep_LifecycleIsLogged verifies step commands append the matching
+// events and leave a log that satisfies the replay invariants.
+func TestStep_LifecycleIsLogged(t *testing.T) {
+	engine, runID, workspaceRoot := startTestRun(t)
+
+	failed, err := engine.StartStep(context.Background(), runID, PhaseDataIngestion)
+	require.NoError(t, err)
+	require.NoError(t, engine.FailStep(context.Background(), runID, failed, "quote feed timeout"))
+	finished := finishPhase(t, engine, runID, PhaseDataIngestion)
+	assert.NotEqual(t, failed, finished, "every attempt gets its own step ID")
+
+	events, err := ReadEvents(runID, workspaceRoot)
+	require.NoError(t, err)
+	require.Len(t, events, 5)
+	assert.Equal(t, FormatStepStarted(2, testTime, runID, failed, PhaseDataIngestion), events[1])
+	assert.Equal(t, FormatStepFailed(3, testTime, runID, failed, PhaseDataIngestion, "quote feed timeout"), events[2])
+	assert.Equal(t, FormatStepStarted(4, testTime, runID, finished, PhaseDataIngestion), events[3])
+	assert.Equal(t, FormatStepFinished(5, testTime, runID, finished, PhaseDataIngestion), events[4])
+
+	assert.Empty(t, ValidateEvents(events, ReplayModeInProgress))
+}
+
+// TestStep_ReplayMatchesEngine verifies the handle rebuilt from the log
+// carries the same step state the engine derived while appending.
+func TestStep_ReplayMatchesEngine(t *testing.T) {
+	engine, runID, workspaceRoot := startTestRun(t)
+
+	retried, err := engine.StartStep(context.Background(), runID, PhaseDataIngestion)
+	require.NoError(t, err)
+	require.NoError(t, engine.FailStep(context.Background(), runID, retried, "retry"))
+	ingested := finishPhase(t, engine, runID, PhaseDataIngestion)
+	running, err := engine.StartStep(context.Background(), runID, PhaseSignalGeneration)
+	require.NoError(t, err)
+
+	handle, err := Replay(runID, workspaceRoot)
+	require.NoError(t, err)
+	assert.Equal(t, int64(6), handle.LastSeq)
+	assert.Equal(t, PhaseSignalGeneration, handle.Phase)
+	assert.Equal(t, map[Phase]int{PhaseDataIngestion: 2, PhaseSignalGeneration: 1}, handle.Attempts)
+	assert.Equal(t, map[Phase]bool{PhaseDataIngestion: true}, handle.PhaseDone)
+	assert.Equal(t, map[string]StepState{
+		retried:  {Phase: PhaseDataIngestion, Status: StepStatusFailed},
+		ingested: {Phase: PhaseDataIngestion, Status: StepStatusFinished},
+		running:  {Phase: PhaseSignalGeneration, Status: StepStatusRunning},
+	}, handle.Steps)
+
+	// The engine must agree: finishing the running step succeeds, and the
+	// terminated ones are rejected.
+	assert.NoError(t, engine.FinishStep(context.Background(), runID, running))
+	assert.Error(t, engine.FinishStep(context.Background(), runID, ingested))
+	assert.Error(t, engine.FailStep(context.Background(), runID, retried, "again"))
+}
+
+// TestStep_PhaseGating verifies a step may only start in the current phase
+// or the next one, and only once the current phase is done.
+func TestStep_PhaseGating(t *testing.T) {
+	engine, runID, _ := startTestRun(t)
+
+	_, err := engine.StartStep(context.Background(), runID, PhaseSignalGeneration)
+	assert.ErrorContains(t, err, "has no finished step")
+
+	_, err = engine.StartStep(context.Background(), runID, PhaseRiskValidation)
+	assert.ErrorContains(t, err, "illegal phase transition")
+
+	_, err = engine.StartStep(context.Background(), runID, Phase(0))
+	assert.ErrorContains(t, err, "invalid phase")
+
+	ingested := finishPhase(t, engine, runID, PhaseDataIngestion)
+
+	// A step still running in the current phase blocks moving on.
+	straggler, err := engine.StartStep(context.Background(), runID, PhaseDataIngestion)
+	require.NoError(t, err)
+	_, err = engine.StartStep(context.Background(), runID, PhaseSignalGeneration)
+	assert.ErrorContains(t, err, straggler)
+	require.NoError(t, engine.FailStep(context.Background(), runID, straggler, "superseded"))
+
+	finishPhase(t, engine, runID, PhaseSignalGeneration)
+
+	_, err = engine.StartStep(context.Background(), runID, PhaseDataIngestion)
+	assert.ErrorContains(t, err, "illegal phase transition")
+	assert.Error(t, engine.FinishStep(context.Background(), runID, ingested))
+}
+
+// TestStep_RejectsInvalidInput verifies step commands reject unknown runs,
+// unknown steps, and empty failure reasons without writing anything.
+func TestStep_RejectsInvalidInput(t *testing.T) {
+	engine, runID, workspaceRoot := startTestRun(t)
+	stepID, err := engine.StartStep(context.Background(), runID, PhaseDataIngestion)
+	require.NoError(t, err)
+
+	_, err = engine.StartStep(context.Background(), RunID("run-missing"), PhaseDataIngestion)
+	assert.ErrorContains(t, err, "not found")
+	assert.ErrorContains(t, engine.FinishStep(context.Background(), RunID("run-missing"), stepID), "not found")
+	assert.ErrorContains(t, engine.FinishStep(context.Background(), runID, "step-missing"), "not found")
+	assert.ErrorContains(t, engine.FailStep(context.Background(), runID, stepID, ""), "reason")
+
+	events, err := ReadEvents(runID, workspaceRoot)
+	require.NoError(t, err)
+	assert.Len(t, events, 2, "rejected commands must not append")
+}
+
+// TestStep_ResumedRunContinues verifies a run recovered with the resume
+// policy accepts step commands on top of its replayed state.
+func TestStep_ResumedRunContinues(t *testing.T) {
+	workspaceRoot := resolvedTempDir(t)
+	runID := RunID("run-step-resumed")
+	writeIncompleteRun(t, runID, workspaceRoot)
+
+	engine, err := OpenEngine(EngineConfig{
+		WorkspaceRoot:  workspaceRoot,
+		IncompleteRuns: IncompleteRunResume,
+		Log:            testLogOptions(),
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, engine.FinishStep(context.Background(), runID, "step-1"))
+	finishPhase(t, engine, runID, PhaseSignalGeneration)
+
+	violations, err := ValidateLog(runID, workspaceRoot, ReplayModeInProgress)
+	require.NoError(t, err)
+	assert.Empty(t, violations)
+}
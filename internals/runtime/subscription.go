@@ -0,0 +1,327 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"aiplatform/pkg/assert"
+)
+
+const (
+	// subscriptionEventsMax bounds the events buffered for one subscriber,
+	// both between the writer and the forwarder and between the forwarder
+	// and the consumer.
+	subscriptionEventsMax = 1024
+
+	// subscribersPerLogMax bounds the live subscribers of one log, so the
+	// writer's per-event fan-out stays bounded.
+	subscribersPerLogMax = 64
+)
+
+// ErrSubscriberOverflow ends a subscription whose consumer fell more than
+// subscriptionEventsMax events behind the writer. The writer never blocks
+// on a subscriber; the consumer may resubscribe from the last seq it saw.
+var ErrSubscriberOverflow = errors.New("subscriber fell behind the event log")
+
+// errStopReading aborts a history read when the subscriber closes.
+var errStopReading = errors.New("subscription closed")
+
+// Subscription streams a run's events in seq order: first the history
+// already on disk, then events as they are appended.
+//
+// Events is closed when the run's log closes, when the subscriber falls
+// behind, when history cannot be read, or after Close. Err tells these
+// apart once Events is closed.
+type Subscription struct {
+	events chan Event
+
+	// stop is closed by Close. The forwarder and the log writer both watch it.
+	stop     chan struct{}
+	stopOnce sync.Once
+
+	// err is written by the forwarder before events is closed.
+	err error
+}
+
+// subscriber is the writer's side of a subscription.
+// Only the log writer goroutine sends on live or closes it.
+type subscriber struct {
+	live chan Event
+
+	// stop is the subscription's stop channel. It is created before the
+	// subscriber is registered, so the writer never sees it change.
+	stop chan struct{}
+
+	// overflowed is set by the writer before closing live.
+	overflowed bool
+}
+
+// subscribeRequest registers a subscriber with the log writer.
+// The writer replies with the last seq already written, so the forwarder
+// knows where history ends and live events begin.
+type subscribeRequest struct {
+	sub      *subscriber
+	resultCh chan<- subscribeResult
+}
+
+type subscribeResult struct {
+	lastSeq int64
+	err     error
+}
+
+// Events returns the channel of events. It is closed when the
+// subscription ends; check Err afterwards.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Err reports why the subscription ended. It is nil if the run's log was
+// closed or the subscriber called Close, and must only be read after
+// Events is closed.
+func (s *Subscription) Err() error {
+	return s.err
+}
+
+// Close stops the subscription. It is safe to call more than once.
+func (s *Subscription) Close() {
+	s.stopOnce.Do(func() { close(s.stop) })
+}
+
+// newSubscription creates a subscription and starts its forwarder.
+// History is read from the run's log for seq in [fromSeq, lastSeq]; live,
+// if not nil, supplies events after lastSeq.
+func newSubscription(runID RunID, workspaceRoot string, fromSeq int64, lastSeq int64,
+	live *subscriber) *Subscription {
+	assert.Is_true(runID != RunID(""), "runID must not be empty")
+	assert.Not_empty(workspaceRoot, "workspaceRoot must not be empty")
+	assert.Gt(fromSeq, 0, "fromSeq must be positive")
+
+	s := &Subscription{
+		events: make(chan Event, subscriptionEventsMax),
+		stop:   make(chan struct{}),
+	}
+	if live != nil {
+		s.stop = live.stop
+	}
+	go s.forward(runID, workspaceRoot, fromSeq, lastSeq, live)
+	return s
+}
+
+// forward replays history from disk, then relays live events.
+// It is the only goroutine that sends on or closes s.events.
+func (s *Subscription) forward(runID RunID, workspaceRoot string, fromSeq int64, lastSeq int64,
+	live *subscriber) {
+	defer close(s.events)
+
+	if fromSeq <= lastSeq {
+		err := readRunLogFrom(runID, workspaceRoot, fromSeq, func(event Event) error {
+			if headerOf(event).Seq > lastSeq {
+				// Written after the subscriber registered; it arrives live.
+				return errStopReading
+			}
+			return s.send(event)
+		})
+		if err != nil && !errors.Is(err, errStopReading) {
+			s.err = fmt.Errorf("failed to replay history: %w", err)
+			return
+		}
+	}
+
+	if live == nil {
+		return
+	}
+	for {
+		select {
+		case event, ok := <-live.live:
+			if !ok {
+				if live.overflowed {
+					s.err = ErrSubscriberOverflow
+				}
+				return
+			}
+			if headerOf(event).Seq < fromSeq {
+				continue
+			}
+			if s.send(event) != nil {
+				return
+			}
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// send delivers one event, giving up if the subscriber closes.
+func (s *Subscription) send(event Event) error {
+	select {
+	case s.events <- event:
+		return nil
+	case <-s.stop:
+		return errStopReading
+	}
+}
+
+// Subscribe streams this log's events with seq >= fromSeq: first those
+// already written, then those appended while the subscription is open.
+// Returns an error if the log is closed.
+func (l *EventLog) Subscribe(fromSeq int64) (*Subscription, error) {
+	if fromSeq < 1 {
+		return nil, fmt.Errorf("fromSeq must be positive, got %d", fromSeq)
+	}
+	if l.closed.Load() {
+		return nil, fmt.Errorf("cannot subscribe to closed log")
+	}
+
+	sub := &subscriber{
+		live: make(chan Event, subscriptionEventsMax),
+		stop: make(chan struct{}),
+	}
+	resultCh := make(chan subscribeResult, 1)
+
+	select {
+	case l.subscribeCh <- subscribeRequest{sub: sub, resultCh: resultCh}:
+	case <-l.closeCh:
+		return nil, fmt.Errorf("log is closing")
+	}
+
+	result := <-resultCh
+	if result.err != nil {
+		return nil, result.err
+	}
+	return newSubscription(l.runID, l.workspaceRoot, fromSeq, result.lastSeq, sub), nil
+}
+
+// registerSubscriber adds a subscriber to the fan-out list.
+// This is only called from the writer goroutine.
+func (l *EventLog) registerSubscriber(req subscribeRequest) {
+	if len(l.subscribers) >= subscribersPerLogMax {
+		req.resultCh <- subscribeResult{
+			err: fmt.Errorf("log already has %d subscribers", subscribersPerLogMax),
+		}
+		return
+	}
+	l.subscribers = append(l.subscribers, req.sub)
+	req.resultCh <- subscribeResult{lastSeq: l.nextSeq - 1}
+}
+
+// publish hands a written event to every live subscriber without blocking.
+// A subscriber whose buffer is full is dropped and told it overflowed;
+// one that has closed is dropped quietly.
+// This is only called from the writer goroutine.
+func (l *EventLog) publish(event Event) {
+	kept := l.subscribers[:0]
+	for _, sub := range l.subscribers {
+		select {
+		case <-sub.stop:
+			close(sub.live)
+			continue
+		default:
+		}
+
+		select {
+		case sub.live <- event:
+			kept = append(kept, sub)
+		default:
+			sub.overflowed = true
+			close(sub.live)
+		}
+	}
+	clear(l.subscribers[len(kept):])
+	l.subscribers = kept
+}
+
+// closeSubscribers ends every live subscription when the writer exits.
+// Subscribers still receive the events already buffered for them.
+func (l *EventLog) closeSubscribers() {
+	for _, sub := range l.subscribers {
+		close(sub.live)
+	}
+	l.subscribers = nil
+}
+
+// SubscribeCmd subscribes to a run's events.
+type SubscribeCmd struct {
+	RunID    RunID
+	FromSeq  int64
+	ResultCh chan<- SubscribeResult
+}
+
+func (SubscribeCmd) command() {}
+
+// SubscribeResult is the result of subscribing to a run.
+type SubscribeResult struct {
+	Subscription *Subscription
+	Err          error
+}
+
+// handleSubscribe processes a SubscribeCmd.
+//
+// An open run subscribes through its log. A terminal run's log is already
+// closed, so its subscription replays history and ends.
+func (e *Engine) handleSubscribe(reg *registry, cmd SubscribeCmd) {
+	assert.Not_nil(reg, "registry must not be nil")
+	assert.Not_nil(cmd.ResultCh, "result channel must not be nil")
+
+	handle, exists := reg.runs[cmd.RunID]
+	if !exists {
+		cmd.ResultCh <- SubscribeResult{Err: fmt.Errorf("run %s: %w", cmd.RunID, ErrRunNotFound)}
+		return
+	}
+	if cmd.FromSeq < 1 {
+		cmd.ResultCh <- SubscribeResult{Err: fmt.Errorf("fromSeq must be positive, got %d", cmd.FromSeq)}
+		return
+	}
+
+	if log, open := reg.logs[handle.ID]; open {
+		sub, err := log.Subscribe(cmd.FromSeq)
+		cmd.ResultCh <- SubscribeResult{Subscription: sub, Err: err}
+		return
+	}
+
+	assert.Is_true(handle.Terminal, "a run without an open log must be terminal")
+	cmd.ResultCh <- SubscribeResult{
+		Subscription: newSubscription(handle.ID, handle.WorkspaceRoot, cmd.FromSeq, handle.LastSeq, nil),
+	}
+}
+
+// Subscribe streams a run's events with seq >= fromSeq: first the history
+// on disk, then, while the run is open, each event as it is appended.
+// The subscriber must call Close when done, unless Events has closed.
+func (e *Engine) Subscribe(ctx context.Context, runID RunID, fromSeq int64) (*Subscription, error) {
+	resultCh := make(chan SubscribeResult, 1)
+	err := e.submit(ctx, SubscribeCmd{
+		RunID:    runID,
+		FromSeq:  fromSeq,
+		ResultCh: resultCh,
+	})
+	if err != nil {
+		return nil, err
+	}
+	result, err := await(ctx, e, resultCh)
+	if err != nil {
+		go e.closeLateSubscription(resultCh)
+		return nil, err
+	}
+	return result.Subscription, result.Err
+}
+
+// closeLateSubscription closes the subscription a SubscribeCmd produces
+// after its caller stopped waiting. If the run loop exits without
+// handling the command, nothing is ever sent, so it waits no longer.
+func (e *Engine) closeLateSubscription(resultCh <-chan SubscribeResult) {
+	var result SubscribeResult
+	select {
+	case result = <-resultCh:
+	case <-e.done:
+		// A result sent before the loop exited is already buffered.
+		select {
+		case result = <-resultCh:
+		default:
+		}
+	}
+	if result.Subscription != nil {
+		result.Subscription.Close()
+	}
+}
@@ -0,0 +1,191 @@
+package runtime
+
+import (
+	"context"
+	goruntime "runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// collectEvents reads a subscription until it ends.
+func collectEvents(t *testing.T, sub *Subscription) []Event {
+	t.Helper()
+	var events []Event
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case event, ok := <-sub.Events():
+			if !ok {
+				return events
+			}
+			events = append(events, event)
+		case <-timeout:
+			require.FailNow(t, "subscription did not end")
+		}
+	}
+}
+
+// nextEvent reads one event from a subscription that must stay open.
+func nextEvent(t *testing.T, sub *Subscription) Event {
+	t.Helper()
+	select {
+	case event, ok := <-sub.Events():
+		require.True(t, ok, "subscription ended early: %v", sub.Err())
+		return event
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "no event arrived")
+		return nil
+	}
+}
+
+// TestSubscribe_HistoryThenLive verifies a subscriber sees the history on
+// disk followed by live events, in seq order, until the run ends.
+func TestSubscribe_HistoryThenLive(t *testing.T) {
+	ctx := context.Background()
+	engine, runID, workspaceRoot := startTestRun(t)
+	stepID, err := engine.StartStep(ctx, runID, PhaseDataIngestion)
+	require.NoError(t, err)
+
+	sub, err := engine.Subscribe(ctx, runID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, EventTypeRunStarted, headerOf(nextEvent(t, sub)).Type)
+	assert.Equal(t, EventTypeStepStarted, headerOf(nextEvent(t, sub)).Type)
+
+	require.NoError(t, engine.FinishStep(ctx, runID, stepID))
+	assert.Equal(t, FormatStepFinished(3, testTime, runID, stepID, PhaseDataIngestion), nextEvent(t, sub))
+
+	require.NoError(t, engine.FailRun(ctx, runID, "stopped"))
+	rest := collectEvents(t, sub)
+	require.NoError(t, sub.Err())
+	assert.Equal(t, []Event{FormatRunFailed(4, testTime, runID, "stopped")}, rest)
+
+	all, err := ReadEvents(runID, workspaceRoot)
+	require.NoError(t, err)
+	assert.Len(t, all, 4)
+}
+
+// TestSubscribe_FromSeq verifies history before fromSeq is skipped, for
+// open and terminal runs alike.
+func TestSubscribe_FromSeq(t *testing.T) {
+	ctx := context.Background()
+	engine, runID, workspaceRoot := startTestRun(t)
+	finishAllPhases(t, engine, runID)
+
+	open, err := engine.Subscribe(ctx, runID, 8)
+	require.NoError(t, err)
+	require.NoError(t, engine.FinishRun(ctx, runID))
+
+	events, err := ReadEvents(runID, workspaceRoot)
+	require.NoError(t, err)
+	require.Len(t, events, 10)
+	assert.Equal(t, events[7:], collectEvents(t, open))
+	assert.NoError(t, open.Err())
+
+	// The log is closed now; the subscription replays history and ends.
+	terminal, err := engine.Subscribe(ctx, runID, 9)
+	require.NoError(t, err)
+	assert.Equal(t, events[8:], collectEvents(t, terminal))
+	assert.NoError(t, terminal.Err())
+
+	// Subscribing past the end of a terminal run yields nothing.
+	past, err := engine.Subscribe(ctx, runID, 11)
+	require.NoError(t, err)
+	assert.Empty(t, collectEvents(t, past))
+}
+
+// TestSubscribe_SlowSubscriberOverflows verifies the writer never blocks on
+// a subscriber that stops reading; the subscriber is cut off instead.
+func TestSubscribe_SlowSubscriberOverflows(t *testing.T) {
+	workspaceRoot := t.TempDir()
+	runID := RunID("run-subscribe-overflow")
+	log, err := OpenEventLog(runID, workspaceRoot, testLogOptions())
+	require.NoError(t, err)
+	defer log.Close()
+	require.NoError(t, log.AppendRunStarted(runID, workspaceRoot))
+
+	sub, err := log.Subscribe(1)
+	require.NoError(t, err)
+
+	// More events than both buffers hold; none of these appends may block.
+	appended := 3 * subscriptionEventsMax
+	for i := 0; i < appended; i++ {
+		require.NoError(t, log.AppendToolCalled(runID, "s1", testToolCall("call-1")))
+	}
+
+	events := collectEvents(t, sub)
+	assert.ErrorIs(t, sub.Err(), ErrSubscriberOverflow)
+	assert.Less(t, len(events), appended)
+	for i, event := range events {
+		require.Equal(t, int64(i+1), headerOf(event).Seq, "delivered events must have no gaps")
+	}
+
+	// The writer dropped the subscriber, so new subscribers are unaffected.
+	again, err := log.Subscribe(int64(appended + 1))
+	require.NoError(t, err)
+	defer again.Close()
+	assert.Equal(t, int64(appended+1), headerOf(nextEvent(t, again)).Seq)
+}
+
+// TestSubscribe_CloseStopsDelivery verifies Close ends a subscription
+// without an error and releases the writer's side.
+func TestSubscribe_CloseStopsDelivery(t *testing.T) {
+	ctx := context.Background()
+	engine, runID, _ := startTestRun(t)
+
+	sub, err := engine.Subscribe(ctx, runID, 1)
+	require.NoError(t, err)
+	sub.Close()
+	sub.Close()
+	collectEvents(t, sub)
+	assert.NoError(t, sub.Err())
+
+	// Appends keep succeeding after the subscriber went away.
+	finishPhase(t, engine, runID, PhaseDataIngestion)
+}
+
+// TestSubscribe_RejectsInvalidInput verifies unknown runs and non-positive
+// seqs are rejected.
+func TestSubscribe_RejectsInvalidInput(t *testing.T) {
+	ctx := context.Background()
+	engine, runID, _ := startTestRun(t)
+
+	_, err := engine.Subscribe(ctx, RunID("run-missing"), 1)
+	assert.ErrorIs(t, err, ErrRunNotFound)
+	_, err = engine.Subscribe(ctx, runID, 0)
+	assert.ErrorContains(t, err, "fromSeq")
+}
+
+// TestSubscribe_CancelDuringShutdownDoesNotLeak verifies a Subscribe whose
+// ctx ends while the engine shuts down leaves no goroutine waiting for a
+// result the run loop will never send.
+func TestSubscribe_CancelDuringShutdownDoesNotLeak(t *testing.T) {
+	// An engine whose run loop never takes the command, as when Shutdown
+	// wins the race with submit.
+	engine := &Engine{
+		cmdCh:   make(chan Command, 1),
+		closing: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	before := goruntime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		for len(engine.cmdCh) == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		cancel()
+	}()
+	_, err := engine.Subscribe(ctx, "run-1", 1)
+	require.ErrorIs(t, err, context.Canceled)
+
+	close(engine.closing)
+	close(engine.done)
+	deadline := time.Now().Add(5 * time.Second)
+	for goruntime.NumGoroutine() > before {
+		require.True(t, time.Now().Before(deadline), "cleanup goroutine did not exit")
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,166 @@
+package runtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	// toolCallIDBytesMax bounds a tool call ID.
+	toolCallIDBytesMax = 128
+
+	// toolNameBytesMax bounds a tool name.
+	toolNameBytesMax = 128
+
+	// toolDurationMax bounds how long one call may be recorded as taking.
+	toolDurationMax = time.Hour
+)
+
+// ToolCall describes a tool invocation as it is made.
+type ToolCall struct {
+	// CallID identifies the call within its run. The result or failure
+	// carries the same ID.
+	CallID string
+
+	ToolName string
+
+	// Arguments is the JSON the tool was invoked with.
+	Arguments json.RawMessage
+}
+
+// ToolResult describes a tool call that returned.
+type ToolResult struct {
+	// CallID is the ID of the call this completes.
+	CallID   string
+	ToolName string
+
+	// Result is the JSON the tool returned.
+	Result json.RawMessage
+
+	// Duration is how long the tool took.
+	Duration time.Duration
+}
+
+// ToolFailure describes a tool call that failed.
+type ToolFailure struct {
+	// CallID is the ID of the call this completes.
+	CallID   string
+	ToolName string
+	Reason   string
+
+	// Duration is how long the tool ran before failing.
+	Duration time.Duration
+}
+
+// ToolCallPayload is the tool.called payload as it is logged.
+type ToolCallPayload struct {
+	CallID    string `json:"call_id"`
+	ToolName  string `json:"tool_name"`
+	Arguments Body   `json:"arguments"`
+}
+
+// ToolResultPayload is the tool.returned payload as it is logged.
+type ToolResultPayload struct {
+	CallID         string `json:"call_id"`
+	ToolName       string `json:"tool_name"`
+	Result         Body   `json:"result"`
+	DurationMillis int64  `json:"duration_ms"`
+}
+
+// ToolFailurePayload is the tool.failed payload as it is logged.
+type ToolFailurePayload struct {
+	CallID         string `json:"call_id"`
+	ToolName       string `json:"tool_name"`
+	Reason         string `json:"reason"`
+	DurationMillis int64  `json:"duration_ms"`
+}
+
+// validate checks a call before it is logged.
+func (c ToolCall) validate() error {
+	if err := validateToolIdentity(c.CallID, c.ToolName); err != nil {
+		return err
+	}
+	if !json.Valid(c.Arguments) {
+		return fmt.Errorf("tool arguments must be valid JSON")
+	}
+	return nil
+}
+
+// validate checks a result before it is logged.
+func (r ToolResult) validate() error {
+	if err := validateToolIdentity(r.CallID, r.ToolName); err != nil {
+		return err
+	}
+	if !json.Valid(r.Result) {
+		return fmt.Errorf("tool result must be valid JSON")
+	}
+	return validateToolDuration(r.Duration)
+}
+
+// validate checks a failure before it is logged.
+func (f ToolFailure) validate() error {
+	if err := validateToolIdentity(f.CallID, f.ToolName); err != nil {
+		return err
+	}
+	if f.Reason == "" {
+		return fmt.Errorf("tool failure reason must not be empty")
+	}
+	return validateToolDuration(f.Duration)
+}
+
+// validateToolIdentity checks the fields that link events of one call.
+func validateToolIdentity(callID string, toolName string) error {
+	if callID == "" || len(callID) > toolCallIDBytesMax {
+		return fmt.Errorf("call ID must be 1 to %d bytes", toolCallIDBytesMax)
+	}
+	if toolName == "" || len(toolName) > toolNameBytesMax {
+		return fmt.Errorf("tool name must be 1 to %d bytes", toolNameBytesMax)
+	}
+	return nil
+}
+
+// validateToolDuration checks a recorded call duration.
+func validateToolDuration(duration time.Duration) error {
+	if duration < 0 || duration > toolDurationMax {
+		return fmt.Errorf("duration must be in [0, %s], got %s", toolDurationMax, duration)
+	}
+	return nil
+}
+
+// payload stores the arguments as a Body and returns the payload to log.
+func (c ToolCall) payload(workspaceRoot string, runID RunID) (ToolCallPayload, error) {
+	arguments, err := newBody(workspaceRoot, runID, string(c.Arguments))
+	if err != nil {
+		return ToolCallPayload{}, fmt.Errorf("failed to record tool arguments: %w", err)
+	}
+	return ToolCallPayload{
+		CallID:    c.CallID,
+		ToolName:  c.ToolName,
+		Arguments: arguments,
+	}, nil
+}
+
+// payload stores the result as a Body and returns the payload to log.
+func (r ToolResult) payload(workspaceRoot string, runID RunID) (ToolResultPayload, error) {
+	result, err := newBody(workspaceRoot, runID, string(r.Result))
+	if err != nil {
+		return ToolResultPayload{}, fmt.Errorf("failed to record tool result: %w", err)
+	}
+	return ToolResultPayload{
+		CallID:         r.CallID,
+		ToolName:       r.ToolName,
+		Result:         result,
+		DurationMillis: r.Duration.Milliseconds(),
+	}, nil
+}
+
+// payload returns the payload to log. A failure carries no body.
+func (f ToolFailure) payload() ToolFailurePayload {
+	return ToolFailurePayload{
+		CallID:         f.CallID,
+		ToolName:       f.ToolName,
+		Reason:         f.Reason,
+		DurationMillis: f.Duration.Milliseconds(),
+	}
+}
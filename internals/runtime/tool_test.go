@@ -0,0 +1,282 @@
+package runtime
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testToolCall returns a small, valid tool call.
+func testToolCall(callID string) ToolCall {
+	return ToolCall{
+		CallID:    callID,
+		ToolName:  "quote",
+		Arguments: json.RawMessage(`{"symbol":"AAPL"}`),
+	}
+}
+
+// testToolResult returns a small, valid result for testToolCall.
+func testToolResult(callID string) ToolResult {
+	return ToolResult{
+		CallID:   callID,
+		ToolName: "quote",
+		Result:   json.RawMessage(`{"bid":189.25,"ask":189.27}`),
+		Duration: 42 * time.Millisecond,
+	}
+}
+
+// testToolFailure returns a valid failure for testToolCall.
+func testToolFailure(callID string) ToolFailure {
+	return ToolFailure{
+		CallID:   callID,
+		ToolName: "quote",
+		Reason:   "timeout",
+		Duration: 5 * time.Second,
+	}
+}
+
+// testToolCallPayload returns the payload testToolCall is logged with.
+// The arguments are small enough to inline, so nothing touches the disk.
+func testToolCallPayload(callID string) ToolCallPayload {
+	payload, err := testToolCall(callID).payload("/w", validatorRunID)
+	if err != nil {
+		panic(err)
+	}
+	return payload
+}
+
+// testToolResultPayload returns the payload testToolResult is logged with.
+func testToolResultPayload(callID string) ToolResultPayload {
+	payload, err := testToolResult(callID).payload("/w", validatorRunID)
+	if err != nil {
+		panic(err)
+	}
+	return payload
+}
+
+// TestEventLog_ToolPayloads verifies a tool call round-trips with its call
+// ID, arguments, result, and duration, and that a large result is stored
+// as an artifact and referenced by path.
+func TestEventLog_ToolPayloads(t *testing.T) {
+	workspaceRoot := t.TempDir()
+	runID := RunID("run-tool-payloads")
+	log, err := OpenEventLog(runID, workspaceRoot, testLogOptions())
+	require.NoError(t, err)
+
+	result := testToolResult("call-1")
+	result.Result = json.RawMessage(`[` + strings.Repeat(`{"bid":189.25,"ask":189.27},`, 512) + `null]`)
+
+	require.NoError(t, log.AppendRunStarted(runID, workspaceRoot))
+	require.NoError(t, log.AppendStepStarted(runID, "s1", PhaseDataIngestion))
+	require.NoError(t, log.AppendToolCalled(runID, "s1", testToolCall("call-1")))
+	require.NoError(t, log.AppendToolReturned(runID, "s1", result))
+	require.NoError(t, log.AppendToolCalled(runID, "s1", testToolCall("call-2")))
+	require.NoError(t, log.AppendToolFailed(runID, "s1", testToolFailure("call-2")))
+	require.NoError(t, log.Close())
+
+	events, err := ReadEvents(runID, workspaceRoot)
+	require.NoError(t, err)
+	require.Len(t, events, 6)
+	assert.Empty(t, ValidateEvents(events, ReplayModeInProgress))
+
+	called := events[2].(ToolCalledEvent)
+	assert.Equal(t, FormatToolCalled(3, testTime, runID, "s1", testToolCallPayload("call-1")), called)
+	assert.Equal(t, `{"symbol":"AAPL"}`, called.Arguments.Inline)
+
+	returned := events[3].(ToolReturnedEvent)
+	assert.Equal(t, "call-1", returned.CallID)
+	assert.Equal(t, "quote", returned.ToolName)
+	assert.Equal(t, int64(42), returned.DurationMillis)
+	assert.Empty(t, returned.Result.Inline, "a large result must not be inlined")
+	assert.True(t, strings.HasPrefix(returned.Result.Path, ".aiplatform/artifacts/"))
+	stored, err := NewArtifactStore(workspaceRoot, runID).readBody(returned.Result)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(result.Result), stored)
+
+	failed := events[5].(ToolFailedEvent)
+	assert.Equal(t, "call-2", failed.CallID)
+	assert.Equal(t, "timeout", failed.Reason)
+	assert.Equal(t, int64(5000), failed.DurationMillis)
+}
+
+// TestEventLog_RejectsInvalidToolPayloads verifies bad calls, results,
+// and failures are refused before anything is written.
+func TestEventLog_RejectsInvalidToolPayloads(t *testing.T) {
+	workspaceRoot := t.TempDir()
+	runID := RunID("run-tool-invalid")
+	log, err := OpenEventLog(runID, workspaceRoot, testLogOptions())
+	require.NoError(t, err)
+	defer log.Close()
+
+	calls := map[string]func(*ToolCall){
+		"no_call_id":     func(c *ToolCall) { c.CallID = "" },
+		"long_call_id":   func(c *ToolCall) { c.CallID = strings.Repeat("x", toolCallIDBytesMax+1) },
+		"no_tool_name":   func(c *ToolCall) { c.ToolName = "" },
+		"no_arguments":   func(c *ToolCall) { c.Arguments = nil },
+		"bad_arguments":  func(c *ToolCall) { c.Arguments = json.RawMessage(`{"symbol":`) },
+		"text_arguments": func(c *ToolCall) { c.Arguments = json.RawMessage(`AAPL`) },
+	}
+	for name, mutate := range calls {
+		call := testToolCall("call-1")
+		mutate(&call)
+		assert.Error(t, log.AppendToolCalled(runID, "s1", call), name)
+	}
+
+	results := map[string]func(*ToolResult){
+		"no_call_id":        func(r *ToolResult) { r.CallID = "" },
+		"bad_result":        func(r *ToolResult) { r.Result = json.RawMessage(`{`) },
+		"negative_duration": func(r *ToolResult) { r.Duration = -time.Millisecond },
+		"excessive_duration": func(r *ToolResult) {
+			r.Duration = toolDurationMax + time.Second
+		},
+	}
+	for name, mutate := range results {
+		result := testToolResult("call-1")
+		mutate(&result)
+		assert.Error(t, log.AppendToolReturned(runID, "s1", result), name)
+	}
+
+	failures := map[string]func(*ToolFailure){
+		"no_call_id":        func(f *ToolFailure) { f.CallID = "" },
+		"no_reason":         func(f *ToolFailure) { f.Reason = "" },
+		"negative_duration": func(f *ToolFailure) { f.Duration = -time.Millisecond },
+	}
+	for name, mutate := range failures {
+		failure := testToolFailure("call-1")
+		mutate(&failure)
+		assert.Error(t, log.AppendToolFailed(runID, "s1", failure), name)
+	}
+
+	info, err := os.Stat(log.Path())
+	require.NoError(t, err)
+	assert.Zero(t, info.Size())
+}
+
+// TestEventLog_RejectsBadStepEventIDs verifies LLM, tool, and artifact
+// events with an empty step ID, or for another run, are refused with an
+// error instead of reaching the formatter's assertions on the writer.
+func TestEventLog_RejectsBadStepEventIDs(t *testing.T) {
+	workspaceRoot := t.TempDir()
+	runID := RunID("run-step-ids")
+	log, err := OpenEventLog(runID, workspaceRoot, testLogOptions())
+	require.NoError(t, err)
+	defer log.Close()
+
+	appends := map[string]func(runID RunID, stepID string) error{
+		"llm_requested": func(runID RunID, stepID string) error {
+			return log.AppendLLMRequested(runID, stepID, testLLMRequest("req-1"))
+		},
+		"llm_responded": func(runID RunID, stepID string) error {
+			return log.AppendLLMResponded(runID, stepID, testLLMResponse("req-1"))
+		},
+		"tool_called": func(runID RunID, stepID string) error {
+			return log.AppendToolCalled(runID, stepID, testToolCall("call-1"))
+		},
+		"tool_returned": func(runID RunID, stepID string) error {
+			return log.AppendToolReturned(runID, stepID, testToolResult("call-1"))
+		},
+		"tool_failed": func(runID RunID, stepID string) error {
+			return log.AppendToolFailed(runID, stepID, testToolFailure("call-1"))
+		},
+		"artifact_created": func(runID RunID, stepID string) error {
+			return log.AppendArtifactCreated(runID, stepID, testArtifact("orders.json"))
+		},
+	}
+	for name, appendEvent := range appends {
+		assert.ErrorContains(t, appendEvent(runID, ""), "stepID must not be empty", name)
+		assert.ErrorContains(t, appendEvent("", "s1"), "log is for run", name)
+		assert.ErrorContains(t, appendEvent("run-other", "s1"), "log is for run", name)
+	}
+
+	info, err := os.Stat(log.Path())
+	require.NoError(t, err)
+	assert.Zero(t, info.Size())
+}
+
+// TestValidateEvents_ToolLifecycle verifies each tool call ends exactly
+// once, by returning or failing, in the same step and for the same tool,
+// before the step finishes.
+func TestValidateEvents_ToolLifecycle(t *testing.T) {
+	start := FormatRunStarted(1, testTime, validatorRunID, "/w")
+	step := func(seq int64, stepID string) Event {
+		return FormatStepStarted(seq, testTime, validatorRunID, stepID, PhaseDataIngestion)
+	}
+	called := func(seq int64, stepID string, callID string) Event {
+		return FormatToolCalled(seq, testTime, validatorRunID, stepID, testToolCallPayload(callID))
+	}
+	returned := func(seq int64, stepID string, callID string) Event {
+		return FormatToolReturned(seq, testTime, validatorRunID, stepID, testToolResultPayload(callID))
+	}
+	failed := func(seq int64, stepID string, callID string) Event {
+		return FormatToolFailed(seq, testTime, validatorRunID, stepID, testToolFailure(callID).payload())
+	}
+
+	otherTool := testToolResultPayload("call-1")
+	otherTool.ToolName = "news"
+
+	tests := []struct {
+		name   string
+		events []Event
+		seq    int64
+	}{
+		{
+			name:   "return_without_call",
+			events: []Event{start, step(2, "s1"), returned(3, "s1", "call-1")},
+			seq:    3,
+		},
+		{
+			name:   "failure_without_call",
+			events: []Event{start, step(2, "s1"), failed(3, "s1", "call-1")},
+			seq:    3,
+		},
+		{
+			name: "duplicate_call",
+			events: []Event{start, step(2, "s1"),
+				called(3, "s1", "call-1"), called(4, "s1", "call-1")},
+			seq: 4,
+		},
+		{
+			name: "returned_then_failed",
+			events: []Event{start, step(2, "s1"), called(3, "s1", "call-1"),
+				returned(4, "s1", "call-1"), failed(5, "s1", "call-1")},
+			seq: 5,
+		},
+		{
+			name: "return_in_other_step",
+			events: []Event{start, step(2, "s1"), step(3, "s2"),
+				called(4, "s1", "call-1"), returned(5, "s2", "call-1")},
+			seq: 5,
+		},
+		{
+			name: "return_from_other_tool",
+			events: []Event{start, step(2, "s1"), called(3, "s1", "call-1"),
+				FormatToolReturned(4, testTime, validatorRunID, "s1", otherTool)},
+			seq: 4,
+		},
+		{
+			name: "step_finished_outstanding",
+			events: []Event{start, step(2, "s1"), called(3, "s1", "call-1"),
+				FormatStepFinished(4, testTime, validatorRunID, "s1", PhaseDataIngestion)},
+			seq: 4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations := ValidateEvents(tt.events, ReplayModeInProgress)
+			v := findViolation(t, violations, InvariantToolLifecycle)
+			assert.Equal(t, tt.seq, v.Seq)
+		})
+	}
+
+	// A failed call terminates it, and a failed step may leave calls open.
+	ok := []Event{start, step(2, "s1"), called(3, "s1", "call-1"), failed(4, "s1", "call-1"),
+		called(5, "s1", "call-2"),
+		FormatStepFailed(6, testTime, validatorRunID, "s1", PhaseDataIngestion, "timeout")}
+	assert.Empty(t, ValidateEvents(ok, ReplayModeInProgress))
+}
@@ -0,0 +1,321 @@
+package runtime
+
+import (
+	"fmt"
+	"time"
+)
+
+// MicrosPerDollar converts dollars to the integer micro-dollars that
+// prices and commissions are logged in. Money is never a float in the log.
+const MicrosPerDollar = 1_000_000
+
+const (
+	// tradingIDBytesMax bounds a signal, order, strategy, or fill ID.
+	tradingIDBytesMax = 128
+
+	// symbolBytesMax bounds a symbol (T3).
+	symbolBytesMax = 10
+
+	// orderQuantityMax bounds an order's quantity. It keeps quantity times
+	// price well inside int64 micro-dollars.
+	orderQuantityMax = 1_000_000_000
+
+	// priceMicrosMax bounds a price: one million dollars a share.
+	priceMicrosMax = 1_000_000 * MicrosPerDollar
+
+	// reasonBytesMax bounds a cancellation or rejection reason.
+	reasonBytesMax = 1024
+)
+
+// SignalDirection is what a signal recommends (T42).
+type SignalDirection string
+
+const (
+	SignalDirectionBuy  SignalDirection = "buy"
+	SignalDirectionSell SignalDirection = "sell"
+	SignalDirectionHold SignalDirection = "hold"
+)
+
+// IsValid reports whether d is one of the T42 directions.
+func (d SignalDirection) IsValid() bool {
+	switch d {
+	case SignalDirectionBuy, SignalDirectionSell, SignalDirectionHold:
+		return true
+	}
+	return false
+}
+
+// OrderSide is the side of an order (T14).
+type OrderSide string
+
+const (
+	OrderSideBuy        OrderSide = "buy"
+	OrderSideSell       OrderSide = "sell"
+	OrderSideSellShort  OrderSide = "sell_short"
+	OrderSideBuyToCover OrderSide = "buy_to_cover"
+)
+
+// IsValid reports whether s is one of the T14 sides.
+func (s OrderSide) IsValid() bool {
+	switch s {
+	case OrderSideBuy, OrderSideSell, OrderSideSellShort, OrderSideBuyToCover:
+		return true
+	}
+	return false
+}
+
+// OrderType is how an order is priced (T15).
+type OrderType string
+
+const (
+	OrderTypeMarket    OrderType = "market"
+	OrderTypeLimit     OrderType = "limit"
+	OrderTypeStop      OrderType = "stop"
+	OrderTypeStopLimit OrderType = "stop_limit"
+)
+
+// IsValid reports whether t is one of the T15 types.
+func (t OrderType) IsValid() bool {
+	switch t {
+	case OrderTypeMarket, OrderTypeLimit, OrderTypeStop, OrderTypeStopLimit:
+		return true
+	}
+	return false
+}
+
+// TimeInForce is how long an order stays working (T17).
+type TimeInForce string
+
+const (
+	TimeInForceDay TimeInForce = "day"
+	TimeInForceGTC TimeInForce = "gtc"
+	TimeInForceIOC TimeInForce = "ioc"
+	TimeInForceFOK TimeInForce = "fok"
+)
+
+// IsValid reports whether t is one of the T17 values.
+func (t TimeInForce) IsValid() bool {
+	switch t {
+	case TimeInForceDay, TimeInForceGTC, TimeInForceIOC, TimeInForceFOK:
+		return true
+	}
+	return false
+}
+
+// Signal is a strategy's recommendation, as recorded in signal.generated.
+type Signal struct {
+	SignalID   string          `json:"signal_id"`
+	StrategyID string          `json:"strategy_id"`
+	Symbol     string          `json:"symbol"`
+	Direction  SignalDirection `json:"direction"`
+
+	// Confidence is in [0, 1] (T43).
+	Confidence float64 `json:"confidence"`
+}
+
+// Order is an order as submitted, as recorded in order.submitted.
+// Every later order event refers to it by OrderID.
+type Order struct {
+	OrderID    string    `json:"order_id"`
+	StrategyID string    `json:"strategy_id"`
+	Symbol     string    `json:"symbol"`
+	Side       OrderSide `json:"side"`
+	OrderType  OrderType `json:"order_type"`
+
+	// Quantity is a whole number of shares.
+	Quantity int64 `json:"quantity"`
+
+	// LimitPriceMicros is set for limit and stop_limit orders only, and
+	// StopPriceMicros for stop and stop_limit orders only (T15).
+	LimitPriceMicros int64 `json:"limit_price_micros,omitempty"`
+	StopPriceMicros  int64 `json:"stop_price_micros,omitempty"`
+
+	TimeInForce TimeInForce `json:"time_in_force"`
+
+	// SignalID is the signal the order acts on, if any.
+	SignalID string `json:"signal_id,omitempty"`
+}
+
+// OrderAck records the broker accepting an order, in order.acknowledged.
+type OrderAck struct {
+	OrderID       string `json:"order_id"`
+	BrokerOrderID string `json:"broker_order_id"`
+}
+
+// Fill records one execution against an order, in order.filled (T63).
+// An order may fill in parts; each part is its own fill.
+type Fill struct {
+	OrderID          string    `json:"order_id"`
+	FillID           string    `json:"fill_id"`
+	Quantity         int64     `json:"fill_quantity"`
+	PriceMicros      int64     `json:"fill_price_micros"`
+	CommissionMicros int64     `json:"commission_micros"`
+	FillTime         time.Time `json:"fill_time"`
+}
+
+// OrderCancel records an order cancelled before it filled completely,
+// in order.cancelled.
+type OrderCancel struct {
+	OrderID string `json:"order_id"`
+	Reason  string `json:"reason"`
+}
+
+// OrderReject records the broker or risk checks refusing an order, in
+// order.rejected.
+type OrderReject struct {
+	OrderID string `json:"order_id"`
+	Reason  string `json:"reason"`
+}
+
+// validate checks a signal before it is logged.
+func (s Signal) validate() error {
+	if err := validateTradingID("signal ID", s.SignalID); err != nil {
+		return err
+	}
+	if err := validateTradingID("strategy ID", s.StrategyID); err != nil {
+		return err
+	}
+	if err := validateSymbol(s.Symbol); err != nil {
+		return err
+	}
+	if !s.Direction.IsValid() {
+		return fmt.Errorf("invalid signal direction %q (T42)", s.Direction)
+	}
+	if !s.hasValidConfidence() {
+		return fmt.Errorf("confidence must be in [0, 1], got %v (T43)", s.Confidence)
+	}
+	return nil
+}
+
+// hasValidConfidence reports whether the confidence is in [0, 1]. NaN is not.
+func (s Signal) hasValidConfidence() bool {
+	return s.Confidence >= 0 && s.Confidence <= 1
+}
+
+// validate checks an order before it is logged.
+// Strategy limits on quantity and lot size are checked by the strategy
+// before it submits; here the order only has to be well-formed.
+func (o Order) validate() error {
+	if err := validateTradingID("order ID", o.OrderID); err != nil {
+		return err
+	}
+	if err := validateTradingID("strategy ID", o.StrategyID); err != nil {
+		return err
+	}
+	if err := validateSymbol(o.Symbol); err != nil {
+		return err
+	}
+	if !o.Side.IsValid() {
+		return fmt.Errorf("invalid order side %q (T14)", o.Side)
+	}
+	if !o.OrderType.IsValid() {
+		return fmt.Errorf("invalid order type %q (T15)", o.OrderType)
+	}
+	if !o.hasPricesForType() {
+		return fmt.Errorf("%s order has limit price %d and stop price %d micros (T15)",
+			o.OrderType, o.LimitPriceMicros, o.StopPriceMicros)
+	}
+	if o.Quantity <= 0 || o.Quantity > orderQuantityMax {
+		return fmt.Errorf("quantity must be in [1, %d], got %d (T16)", orderQuantityMax, o.Quantity)
+	}
+	if !o.TimeInForce.IsValid() {
+		return fmt.Errorf("invalid time in force %q (T17)", o.TimeInForce)
+	}
+	if o.SignalID != "" {
+		return validateTradingID("signal ID", o.SignalID)
+	}
+	return nil
+}
+
+// hasPricesForType reports whether the order carries exactly the prices
+// its type needs (T15), each in (0, priceMicrosMax].
+func (o Order) hasPricesForType() bool {
+	wantLimit := o.OrderType == OrderTypeLimit || o.OrderType == OrderTypeStopLimit
+	wantStop := o.OrderType == OrderTypeStop || o.OrderType == OrderTypeStopLimit
+	return isPriceSet(o.LimitPriceMicros, wantLimit) && isPriceSet(o.StopPriceMicros, wantStop)
+}
+
+// isPriceSet reports whether a price is valid when wanted and zero when not.
+func isPriceSet(priceMicros int64, want bool) bool {
+	if !want {
+		return priceMicros == 0
+	}
+	return priceMicros > 0 && priceMicros <= priceMicrosMax
+}
+
+// validate checks an acknowledgement before it is logged.
+func (a OrderAck) validate() error {
+	if err := validateTradingID("order ID", a.OrderID); err != nil {
+		return err
+	}
+	return validateTradingID("broker order ID", a.BrokerOrderID)
+}
+
+// validate checks a fill before it is logged.
+func (f Fill) validate() error {
+	if err := validateTradingID("order ID", f.OrderID); err != nil {
+		return err
+	}
+	if err := validateTradingID("fill ID", f.FillID); err != nil {
+		return err
+	}
+	if f.Quantity <= 0 || f.Quantity > orderQuantityMax {
+		return fmt.Errorf("fill quantity must be in [1, %d], got %d (T63)", orderQuantityMax, f.Quantity)
+	}
+	if f.PriceMicros <= 0 || f.PriceMicros > priceMicrosMax {
+		return fmt.Errorf("fill price must be in [1, %d] micros, got %d (T63)", priceMicrosMax, f.PriceMicros)
+	}
+	if f.CommissionMicros < 0 {
+		return fmt.Errorf("commission must not be negative, got %d micros (T63)", f.CommissionMicros)
+	}
+	if f.FillTime.IsZero() {
+		return fmt.Errorf("fill time must be set (T63)")
+	}
+	return nil
+}
+
+// validate checks a cancellation before it is logged.
+func (c OrderCancel) validate() error {
+	if err := validateTradingID("order ID", c.OrderID); err != nil {
+		return err
+	}
+	return validateReason(c.Reason)
+}
+
+// validate checks a rejection before it is logged.
+func (r OrderReject) validate() error {
+	if err := validateTradingID("order ID", r.OrderID); err != nil {
+		return err
+	}
+	return validateReason(r.Reason)
+}
+
+// validateTradingID checks an ID that links trading events.
+func validateTradingID(field string, id string) error {
+	if id == "" || len(id) > tradingIDBytesMax {
+		return fmt.Errorf("%s must be 1 to %d bytes", field, tradingIDBytesMax)
+	}
+	return nil
+}
+
+// validateSymbol enforces T3: 1 to 10 uppercase letters, digits, or
+// hyphens, e.g. BRK-B.
+func validateSymbol(symbol string) error {
+	if symbol == "" || len(symbol) > symbolBytesMax {
+		return fmt.Errorf("symbol must be 1 to %d characters, got %q (T3)", symbolBytesMax, symbol)
+	}
+	for _, c := range []byte(symbol) {
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') && c != '-' {
+			return fmt.Errorf("symbol must be uppercase letters, digits, or hyphens, got %q (T3)", symbol)
+		}
+	}
+	return nil
+}
+
+// validateReason checks a cancellation or rejection reason.
+func validateReason(reason string) error {
+	if reason == "" || len(reason) > reasonBytesMax {
+		return fmt.Errorf("reason must be 1 to %d bytes", reasonBytesMax)
+	}
+	return nil
+}
@@ -0,0 +1,243 @@
+package runtime
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testStrategyID is the strategy every test order and signal belongs to.
+const testStrategyID = "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+
+// testSignal returns a valid buy signal for AAPL.
+func testSignal(signalID string) Signal {
+	return Signal{
+		SignalID:   signalID,
+		StrategyID: testStrategyID,
+		Symbol:     "AAPL",
+		Direction:  SignalDirectionBuy,
+		Confidence: 0.8,
+	}
+}
+
+// testOrder returns a valid limit order to buy 10 AAPL at $189.25.
+func testOrder(orderID string) Order {
+	return Order{
+		OrderID:          orderID,
+		StrategyID:       testStrategyID,
+		Symbol:           "AAPL",
+		Side:             OrderSideBuy,
+		OrderType:        OrderTypeLimit,
+		Quantity:         10,
+		LimitPriceMicros: 189_250_000,
+		TimeInForce:      TimeInForceDay,
+	}
+}
+
+// testFill returns a valid fill of quantity shares of testOrder.
+func testFill(orderID string, fillID string, quantity int64) Fill {
+	return Fill{
+		OrderID:          orderID,
+		FillID:           fillID,
+		Quantity:         quantity,
+		PriceMicros:      189_240_000,
+		CommissionMicros: 1_000_000,
+		FillTime:         testTime,
+	}
+}
+
+// TestEventLog_TradingEvents verifies every trading event round-trips
+// through the log with its payload at the top level of the line.
+func TestEventLog_TradingEvents(t *testing.T) {
+	workspaceRoot := t.TempDir()
+	runID := RunID("run-trading")
+	log, err := OpenEventLog(runID, workspaceRoot, testLogOptions())
+	require.NoError(t, err)
+
+	order := testOrder("order-1")
+	order.SignalID = "signal-1"
+	stop := testOrder("order-2")
+	stop.OrderType = OrderTypeStopLimit
+	stop.StopPriceMicros = 185_000_000
+	market := testOrder("order-3")
+	market.OrderType = OrderTypeMarket
+	market.LimitPriceMicros = 0
+	fill := testFill("order-1", "fill-1", 10)
+	fill.FillTime = testTime.In(time.FixedZone("EST", -5*60*60))
+
+	require.NoError(t, log.AppendRunStarted(runID, workspaceRoot))
+	require.NoError(t, log.AppendStepStarted(runID, "s1", PhaseDataIngestion))
+	require.NoError(t, log.AppendStepFinished(runID, "s1", PhaseDataIngestion))
+	require.NoError(t, log.AppendStepStarted(runID, "s2", PhaseSignalGeneration))
+	require.NoError(t, log.AppendSignalGenerated(runID, "s2", testSignal("signal-1")))
+	require.NoError(t, log.AppendStepFinished(runID, "s2", PhaseSignalGeneration))
+	require.NoError(t, log.AppendStepStarted(runID, "s3", PhaseRiskValidation))
+	require.NoError(t, log.AppendStepFinished(runID, "s3", PhaseRiskValidation))
+	require.NoError(t, log.AppendStepStarted(runID, "s4", PhaseOrderExecution))
+	require.NoError(t, log.AppendOrderSubmitted(runID, "s4", order))
+	require.NoError(t, log.AppendOrderAcknowledged(runID, "s4", OrderAck{OrderID: "order-1", BrokerOrderID: "B-1"}))
+	require.NoError(t, log.AppendOrderFilled(runID, "s4", fill))
+	require.NoError(t, log.AppendOrderSubmitted(runID, "s4", stop))
+	require.NoError(t, log.AppendOrderCancelled(runID, "s4", OrderCancel{OrderID: "order-2", Reason: "expired"}))
+	require.NoError(t, log.AppendOrderSubmitted(runID, "s4", market))
+	require.NoError(t, log.AppendOrderRejected(runID, "s4", OrderReject{OrderID: "order-3", Reason: "halted"}))
+	require.NoError(t, log.Close())
+
+	lines := readLogLines(t, log.Path())
+	assert.Contains(t, lines[9], `"order_id":"order-1","strategy_id":"`+testStrategyID+`","symbol":"AAPL"`)
+	assert.Contains(t, lines[9], `"limit_price_micros":189250000`)
+	assert.NotContains(t, lines[9], `"stop_price_micros"`)
+	assert.Contains(t, lines[11], `"fill_quantity":10,"fill_price_micros":189240000,"commission_micros":1000000`)
+
+	events, err := ReadEvents(runID, workspaceRoot)
+	require.NoError(t, err)
+	require.Len(t, events, 16)
+	assert.Empty(t, ValidateEvents(events, ReplayModeInProgress))
+
+	assert.Equal(t, FormatSignalGenerated(5, testTime, runID, "s2", testSignal("signal-1")), events[4])
+	assert.Equal(t, FormatOrderSubmitted(10, testTime, runID, "s4", order), events[9])
+	assert.Equal(t, FormatOrderAcknowledged(11, testTime, runID, "s4",
+		OrderAck{OrderID: "order-1", BrokerOrderID: "B-1"}), events[10])
+	assert.Equal(t, FormatOrderFilled(12, testTime, runID, "s4", testFill("order-1", "fill-1", 10)), events[11])
+	assert.Equal(t, stop, events[12].(OrderSubmittedEvent).Order)
+	assert.Equal(t, "expired", events[13].(OrderCancelledEvent).Reason)
+	assert.Equal(t, market, events[14].(OrderSubmittedEvent).Order)
+	assert.Equal(t, "halted", events[15].(OrderRejectedEvent).Reason)
+}
+
+// TestEventLog_RejectsInvalidTradingPayloads verifies signals, orders,
+// and order updates that break T3, T14 to T17, T42, T43, or T63 are
+// refused before anything is written.
+func TestEventLog_RejectsInvalidTradingPayloads(t *testing.T) {
+	workspaceRoot := t.TempDir()
+	runID := RunID("run-trading-invalid")
+	log, err := OpenEventLog(runID, workspaceRoot, testLogOptions())
+	require.NoError(t, err)
+	defer log.Close()
+
+	signals := map[string]func(*Signal){
+		"no_signal_id":        func(s *Signal) { s.SignalID = "" },
+		"no_strategy_id":      func(s *Signal) { s.StrategyID = "" },
+		"lowercase_symbol":    func(s *Signal) { s.Symbol = "aapl" },
+		"bad_direction":       func(s *Signal) { s.Direction = "short" },
+		"negative_confidence": func(s *Signal) { s.Confidence = -0.1 },
+		"excess_confidence":   func(s *Signal) { s.Confidence = 1.5 },
+	}
+	for name, mutate := range signals {
+		signal := testSignal("signal-1")
+		mutate(&signal)
+		assert.Error(t, log.AppendSignalGenerated(runID, "s1", signal), name)
+	}
+
+	orders := map[string]func(*Order){
+		"no_order_id":          func(o *Order) { o.OrderID = "" },
+		"long_order_id":        func(o *Order) { o.OrderID = strings.Repeat("x", tradingIDBytesMax+1) },
+		"no_strategy_id":       func(o *Order) { o.StrategyID = "" },
+		"long_symbol":          func(o *Order) { o.Symbol = "ABCDEFGHIJK" },
+		"symbol_punctuation":   func(o *Order) { o.Symbol = "BRK.B" },
+		"bad_side":             func(o *Order) { o.Side = "short" },
+		"bad_type":             func(o *Order) { o.OrderType = "trailing_stop" },
+		"limit_without_price":  func(o *Order) { o.LimitPriceMicros = 0 },
+		"limit_negative_price": func(o *Order) { o.LimitPriceMicros = -1 },
+		"limit_with_stop":      func(o *Order) { o.StopPriceMicros = 185_000_000 },
+		"market_with_price":    func(o *Order) { o.OrderType = OrderTypeMarket },
+		"stop_without_price":   func(o *Order) { o.OrderType, o.LimitPriceMicros = OrderTypeStop, 0 },
+		"stop_limit_one_price": func(o *Order) { o.OrderType = OrderTypeStopLimit },
+		"zero_quantity":        func(o *Order) { o.Quantity = 0 },
+		"negative_quantity":    func(o *Order) { o.Quantity = -10 },
+		"excess_quantity":      func(o *Order) { o.Quantity = orderQuantityMax + 1 },
+		"bad_time_in_force":    func(o *Order) { o.TimeInForce = "gtd" },
+		"long_signal_id":       func(o *Order) { o.SignalID = strings.Repeat("x", tradingIDBytesMax+1) },
+	}
+	for name, mutate := range orders {
+		order := testOrder("order-1")
+		mutate(&order)
+		assert.Error(t, log.AppendOrderSubmitted(runID, "s1", order), name)
+	}
+
+	fills := map[string]func(*Fill){
+		"no_order_id":         func(f *Fill) { f.OrderID = "" },
+		"no_fill_id":          func(f *Fill) { f.FillID = "" },
+		"zero_quantity":       func(f *Fill) { f.Quantity = 0 },
+		"zero_price":          func(f *Fill) { f.PriceMicros = 0 },
+		"excess_price":        func(f *Fill) { f.PriceMicros = priceMicrosMax + 1 },
+		"negative_commission": func(f *Fill) { f.CommissionMicros = -1 },
+		"no_fill_time":        func(f *Fill) { f.FillTime = time.Time{} },
+	}
+	for name, mutate := range fills {
+		fill := testFill("order-1", "fill-1", 10)
+		mutate(&fill)
+		assert.Error(t, log.AppendOrderFilled(runID, "s1", fill), name)
+	}
+
+	assert.Error(t, log.AppendOrderAcknowledged(runID, "s1", OrderAck{OrderID: "order-1"}))
+	assert.Error(t, log.AppendOrderCancelled(runID, "s1", OrderCancel{OrderID: "order-1"}))
+	assert.Error(t, log.AppendOrderRejected(runID, "s1", OrderReject{Reason: "halted"}))
+
+	info, err := os.Stat(log.Path())
+	require.NoError(t, err)
+	assert.Zero(t, info.Size())
+}
+
+// TestEventLog_RejectsBadTradingEventIDs verifies a trading event with an
+// empty step or another run's ID is refused, not left to panic the writer.
+func TestEventLog_RejectsBadTradingEventIDs(t *testing.T) {
+	workspaceRoot := t.TempDir()
+	runID := RunID("run-trading-ids")
+	log, err := OpenEventLog(runID, workspaceRoot, testLogOptions())
+	require.NoError(t, err)
+	defer log.Close()
+
+	appends := map[string]func(runID RunID, stepID string) error{
+		"signal_generated": func(runID RunID, stepID string) error {
+			return log.AppendSignalGenerated(runID, stepID, testSignal("signal-1"))
+		},
+		"order_submitted": func(runID RunID, stepID string) error {
+			return log.AppendOrderSubmitted(runID, stepID, testOrder("order-1"))
+		},
+		"order_acknowledged": func(runID RunID, stepID string) error {
+			return log.AppendOrderAcknowledged(runID, stepID, OrderAck{OrderID: "order-1", BrokerOrderID: "B-1"})
+		},
+		"order_filled": func(runID RunID, stepID string) error {
+			return log.AppendOrderFilled(runID, stepID, testFill("order-1", "fill-1", 10))
+		},
+		"order_cancelled": func(runID RunID, stepID string) error {
+			return log.AppendOrderCancelled(runID, stepID, OrderCancel{OrderID: "order-1", Reason: "expired"})
+		},
+		"order_rejected": func(runID RunID, stepID string) error {
+			return log.AppendOrderRejected(runID, stepID, OrderReject{OrderID: "order-1", Reason: "halted"})
+		},
+	}
+	for name, appendEvent := range appends {
+		assert.ErrorContains(t, appendEvent(runID, ""), "stepID must not be empty", name)
+		assert.ErrorContains(t, appendEvent("", "s1"), "log is for run", name)
+		assert.ErrorContains(t, appendEvent("run-other", "s1"), "log is for run", name)
+	}
+
+	info, err := os.Stat(log.Path())
+	require.NoError(t, err)
+	assert.Zero(t, info.Size())
+}
+
+// TestFormatter_OrderSubmittedAssertsFieldRules verifies the formatter
+// refuses orders that break T14, T15, or T16, even if they bypass
+// validation.
+func TestFormatter_OrderSubmittedAssertsFieldRules(t *testing.T) {
+	runID := RunID("test-run")
+	orders := map[string]func(*Order){
+		"bad_side":            func(o *Order) { o.Side = "short" },
+		"bad_type":            func(o *Order) { o.OrderType = "trailing_stop" },
+		"limit_without_price": func(o *Order) { o.LimitPriceMicros = 0 },
+		"zero_quantity":       func(o *Order) { o.Quantity = 0 },
+	}
+	for name, mutate := range orders {
+		order := testOrder("order-1")
+		mutate(&order)
+		assert.Panics(t, func() { FormatOrderSubmitted(1, testTime, runID, "s1", order) }, name)
+	}
+	assert.NotPanics(t, func() { FormatOrderSubmitted(1, testTime, runID, "s1", testOrder("order-1")) })
+}
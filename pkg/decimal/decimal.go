@@ -0,0 +1,269 @@
+// Package decimal provides the fixed-point numbers money is computed in.
+//
+// A float64 cannot hold most prices exactly, and the error it introduces
+// depends on the order of operations, so replaying the same fills could
+// produce different P&L. A Decimal is an integer count of millionths:
+// addition is exact, and every rounding is explicit and deterministic.
+package decimal
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"strconv"
+	"strings"
+
+	"aiplatform/pkg/assert"
+)
+
+// Places is the number of decimal places a Decimal holds.
+const Places = 6
+
+// unitsPerOne is the number of units in 1.
+const unitsPerOne = 1_000_000
+
+// ErrOverflow is returned when a result does not fit in a Decimal.
+var ErrOverflow = errors.New("decimal overflow")
+
+// Decimal is a fixed-point number with six decimal places. It is stored as
+// an int64 count of millionths, so it matches the micro-dollar amounts in
+// the event log one to one. The zero value is 0.
+//
+// math.MinInt64 units is never a valid Decimal, so every Decimal can be
+// negated.
+type Decimal struct {
+	units int64
+}
+
+// Zero is the Decimal 0.
+var Zero = Decimal{}
+
+// FromMicros returns the Decimal holding micros millionths, e.g. a
+// micro-dollar amount from the event log.
+func FromMicros(micros int64) Decimal {
+	assert.Is_true(micros != math.MinInt64, "micros must be negatable")
+	return Decimal{units: micros}
+}
+
+// FromInt returns n as a Decimal.
+func FromInt(n int64) (Decimal, error) {
+	return FromMicros(unitsPerOne).MulInt(n)
+}
+
+// Parse reads a decimal string such as "189.25" or "-0.5". More than six
+// decimal places is an error rather than a silent rounding.
+func Parse(s string) (Decimal, error) {
+	digits, negative := strings.CutPrefix(s, "-")
+	whole, fraction, hasPoint := strings.Cut(digits, ".")
+	if whole == "" || (hasPoint && fraction == "") || len(fraction) > Places ||
+		!isDigits(whole) || !isDigits(fraction) {
+		return Zero, fmt.Errorf("invalid decimal %q", s)
+	}
+
+	wholeUnits, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return Zero, fmt.Errorf("%w: %q", ErrOverflow, s)
+	}
+	fractionUnits := int64(0)
+	if fraction != "" {
+		padded := fraction + strings.Repeat("0", Places-len(fraction))
+		fractionUnits, err = strconv.ParseInt(padded, 10, 64)
+		assert.No_err(err, "fraction digits must parse")
+	}
+
+	d, err := FromInt(wholeUnits)
+	if err == nil {
+		d, err = d.Add(FromMicros(fractionUnits))
+	}
+	if err != nil {
+		return Zero, fmt.Errorf("%w: %q", ErrOverflow, s)
+	}
+	if negative {
+		return d.Neg(), nil
+	}
+	return d, nil
+}
+
+// isDigits reports whether s holds only ASCII digits.
+func isDigits(s string) bool {
+	for _, c := range []byte(s) {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Micros returns d as an integer count of millionths.
+func (d Decimal) Micros() int64 {
+	return d.units
+}
+
+// Add returns d + e.
+func (d Decimal) Add(e Decimal) (Decimal, error) {
+	sum := d.units + e.units
+	// Overflow happened iff both operands have the same sign and the sum's
+	// sign differs.
+	if (d.units >= 0) == (e.units >= 0) && (sum >= 0) != (d.units >= 0) || sum == math.MinInt64 {
+		return Zero, fmt.Errorf("%w: %s + %s", ErrOverflow, d, e)
+	}
+	return Decimal{units: sum}, nil
+}
+
+// Sub returns d - e.
+func (d Decimal) Sub(e Decimal) (Decimal, error) {
+	return d.Add(e.Neg())
+}
+
+// MulInt returns d × n.
+func (d Decimal) MulInt(n int64) (Decimal, error) {
+	if n == math.MinInt64 {
+		return Zero, fmt.Errorf("%w: %s × %d", ErrOverflow, d, n)
+	}
+	hi, lo := bits.Mul64(abs(d.units), abs(n))
+	if hi != 0 || lo > math.MaxInt64 {
+		return Zero, fmt.Errorf("%w: %s × %d", ErrOverflow, d, n)
+	}
+	product := int64(lo)
+	if (d.units < 0) != (n < 0) {
+		product = -product
+	}
+	return Decimal{units: product}, nil
+}
+
+// DivInt returns d ÷ n, rounded to six places, half away from zero.
+// n must not be zero.
+func (d Decimal) DivInt(n int64) Decimal {
+	assert.Is_true(n != 0, "divisor must not be zero")
+	assert.Is_true(n != math.MinInt64, "divisor must be negatable")
+
+	quotient, remainder := abs(d.units)/abs(n), abs(d.units)%abs(n)
+	if remainder >= abs(n)-remainder {
+		quotient++
+	}
+	if (d.units < 0) != (n < 0) {
+		return Decimal{units: -int64(quotient)}
+	}
+	return Decimal{units: int64(quotient)}
+}
+
+// MulDiv returns d × n ÷ m, rounded to six places, half away from zero.
+// The product is kept to 128 bits, so the result is only refused when it
+// does not fit itself. m must not be zero.
+func (d Decimal) MulDiv(n int64, m int64) (Decimal, error) {
+	assert.Is_true(m != 0, "divisor must not be zero")
+	assert.Is_true(m != math.MinInt64, "divisor must be negatable")
+
+	hi, lo := bits.Mul64(abs(d.units), abs(n))
+	if hi >= abs(m) {
+		return Zero, fmt.Errorf("%w: %s × %d ÷ %d", ErrOverflow, d, n, m)
+	}
+	quotient, remainder := bits.Div64(hi, lo, abs(m))
+	if remainder >= abs(m)-remainder && quotient <= math.MaxInt64 {
+		quotient++
+	}
+	if quotient > math.MaxInt64 {
+		return Zero, fmt.Errorf("%w: %s × %d ÷ %d", ErrOverflow, d, n, m)
+	}
+	if (d.units < 0) != ((n < 0) != (m < 0)) {
+		return Decimal{units: -int64(quotient)}, nil
+	}
+	return Decimal{units: int64(quotient)}, nil
+}
+
+// Round returns d rounded to places decimal places, half away from zero.
+// places must be in [0, 6]. Rounding a value within half a step of the
+// largest Decimal away from zero overflows.
+func (d Decimal) Round(places int) (Decimal, error) {
+	assert.Is_true(places >= 0 && places <= Places, "places must be in [0, 6]")
+
+	step := int64(1)
+	for i := places; i < Places; i++ {
+		step *= 10
+	}
+	rounded, err := d.DivInt(step).MulInt(step)
+	if err != nil {
+		return Zero, fmt.Errorf("round %s to %d places: %w", d, places, err)
+	}
+	return rounded, nil
+}
+
+// Neg returns -d.
+func (d Decimal) Neg() Decimal {
+	return Decimal{units: -d.units}
+}
+
+// Abs returns |d|.
+func (d Decimal) Abs() Decimal {
+	return Decimal{units: int64(abs(d.units))}
+}
+
+// Sign returns -1, 0, or 1 as d is negative, zero, or positive.
+func (d Decimal) Sign() int {
+	switch {
+	case d.units < 0:
+		return -1
+	case d.units > 0:
+		return 1
+	}
+	return 0
+}
+
+// IsZero reports whether d is 0.
+func (d Decimal) IsZero() bool {
+	return d.units == 0
+}
+
+// Cmp returns -1, 0, or 1 as d is less than, equal to, or greater than e.
+func (d Decimal) Cmp(e Decimal) int {
+	switch {
+	case d.units < e.units:
+		return -1
+	case d.units > e.units:
+		return 1
+	}
+	return 0
+}
+
+// String formats d without trailing zeros, e.g. "189.25", "-3", "0.000001".
+func (d Decimal) String() string {
+	sign := ""
+	if d.units < 0 {
+		sign = "-"
+	}
+	units := abs(d.units)
+	whole, fraction := units/unitsPerOne, units%unitsPerOne
+	if fraction == 0 {
+		return fmt.Sprintf("%s%d", sign, whole)
+	}
+	digits := strings.TrimRight(fmt.Sprintf("%06d", fraction), "0")
+	return fmt.Sprintf("%s%d.%s", sign, whole, digits)
+}
+
+// MarshalJSON encodes d as a JSON string, so no reader parses it as a float.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(d.String())), nil
+}
+
+// UnmarshalJSON decodes a JSON string written by MarshalJSON.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	s, err := strconv.Unquote(string(data))
+	if err != nil {
+		return fmt.Errorf("decimal must be a JSON string, got %s", data)
+	}
+	parsed, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// abs returns |n| as a uint64. n must not be math.MinInt64.
+func abs(n int64) uint64 {
+	if n < 0 {
+		return uint64(-n)
+	}
+	return uint64(n)
+}
@@ -0,0 +1,226 @@
+package decimal
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+// mustParse parses s or fails the test.
+func mustParse(t *testing.T, s string) Decimal {
+	t.Helper()
+	d, err := Parse(s)
+	if err != nil {
+		t.Fatalf("Parse(%q) failed: %v", s, err)
+	}
+	return d
+}
+
+func TestParse_RoundTripsThroughString(t *testing.T) {
+	tests := map[string]string{
+		"0":          "0",
+		"189.25":     "189.25",
+		"189.250000": "189.25",
+		"-0.5":       "-0.5",
+		"0.000001":   "0.000001",
+		"-12":        "-12",
+		"007.10":     "7.1",
+	}
+	for in, want := range tests {
+		if got := mustParse(t, in).String(); got != want {
+			t.Errorf("Parse(%q).String() = %q, want %q", in, got, want)
+		}
+	}
+	if got := mustParse(t, "189.25").Micros(); got != 189_250_000 {
+		t.Errorf("Expected 189250000 micros, got %d", got)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	invalid := []string{"", "-", ".5", "5.", "1.2345678", "1e3", "+1", "1,000", " 1", "--1"}
+	for _, in := range invalid {
+		if _, err := Parse(in); err == nil {
+			t.Errorf("Expected error parsing %q", in)
+		}
+	}
+	if _, err := Parse("9223372036854.775808"); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Expected overflow, got: %v", err)
+	}
+	if _, err := Parse("99999999999999999999"); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Expected overflow, got: %v", err)
+	}
+}
+
+func TestAdd_IsExact(t *testing.T) {
+	// 0.1 + 0.2 is not 0.3 in float64.
+	sum, err := mustParse(t, "0.1").Add(mustParse(t, "0.2"))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if sum != mustParse(t, "0.3") {
+		t.Errorf("Expected 0.3, got %s", sum)
+	}
+	diff, err := mustParse(t, "1").Sub(mustParse(t, "2.5"))
+	if err != nil || diff != mustParse(t, "-1.5") {
+		t.Errorf("Expected -1.5, got %s (%v)", diff, err)
+	}
+}
+
+func TestArithmetic_Overflow(t *testing.T) {
+	max := FromMicros(math.MaxInt64)
+	if _, err := max.Add(FromMicros(1)); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Expected overflow adding to max, got: %v", err)
+	}
+	if _, err := max.Neg().Sub(FromMicros(1)); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Expected overflow subtracting from -max, got: %v", err)
+	}
+	if _, err := max.MulInt(2); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Expected overflow multiplying max, got: %v", err)
+	}
+	if _, err := FromMicros(1).MulInt(math.MinInt64); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Expected overflow multiplying by MinInt64, got: %v", err)
+	}
+	if _, err := FromInt(math.MaxInt64 / 1_000_000 * 2); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Expected overflow from FromInt, got: %v", err)
+	}
+}
+
+func TestMulInt(t *testing.T) {
+	product, err := mustParse(t, "189.24").MulInt(-10)
+	if err != nil || product != mustParse(t, "-1892.4") {
+		t.Errorf("Expected -1892.4, got %s (%v)", product, err)
+	}
+}
+
+func TestDivInt_RoundsHalfAwayFromZero(t *testing.T) {
+	tests := []struct {
+		d    string
+		n    int64
+		want string
+	}{
+		{"10", 4, "2.5"},
+		{"1", 3, "0.333333"},
+		{"2", 3, "0.666667"},
+		{"0.000001", 2, "0.000001"},
+		{"-0.000001", 2, "-0.000001"},
+		{"0.000001", 3, "0"},
+		{"-1", 3, "-0.333333"},
+		{"1", -3, "-0.333333"},
+	}
+	for _, tt := range tests {
+		if got := mustParse(t, tt.d).DivInt(tt.n); got != mustParse(t, tt.want) {
+			t.Errorf("%s / %d = %s, want %s", tt.d, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestRound(t *testing.T) {
+	tests := []struct {
+		d      string
+		places int
+		want   string
+	}{
+		{"1.005", 2, "1.01"},
+		{"-1.005", 2, "-1.01"},
+		{"1.004999", 2, "1"},
+		{"2.5", 0, "3"},
+		{"0.123456", 6, "0.123456"},
+	}
+	for _, tt := range tests {
+		got, err := mustParse(t, tt.d).Round(tt.places)
+		if err != nil || got != mustParse(t, tt.want) {
+			t.Errorf("Round(%s, %d) = %s (%v), want %s", tt.d, tt.places, got, err, tt.want)
+		}
+	}
+}
+
+func TestRound_Overflow(t *testing.T) {
+	largest := FromMicros(math.MaxInt64) // 9223372036854.775807
+	if _, err := largest.Round(2); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Expected ErrOverflow rounding %s up, got %v", largest, err)
+	}
+	if _, err := largest.Neg().Round(2); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Expected ErrOverflow rounding %s down, got %v", largest.Neg(), err)
+	}
+
+	// Rounding toward zero at the boundary always fits.
+	got, err := FromMicros(math.MaxInt64 - 5_000).Round(2)
+	if err != nil || got != mustParse(t, "9223372036854.77") {
+		t.Errorf("Expected 9223372036854.77, got %s (%v)", got, err)
+	}
+	if got, err := largest.Round(6); err != nil || got != largest {
+		t.Errorf("Expected %s, got %s (%v)", largest, got, err)
+	}
+}
+
+func TestMulDiv(t *testing.T) {
+	tests := []struct {
+		d    string
+		n    int64
+		m    int64
+		want string
+	}{
+		{"10", 1, 3, "3.333333"},
+		{"10", 2, 3, "6.666667"},
+		{"-10", 2, 3, "-6.666667"},
+		{"10", -2, 3, "-6.666667"},
+		{"10", 2, -3, "-6.666667"},
+		{"-10", -2, -3, "-6.666667"},
+		{"0.000001", 1, 2, "0.000001"},
+		{"0", 7, 3, "0"},
+	}
+	for _, tt := range tests {
+		got, err := mustParse(t, tt.d).MulDiv(tt.n, tt.m)
+		if err != nil || got != mustParse(t, tt.want) {
+			t.Errorf("%s × %d ÷ %d = %s (%v), want %s", tt.d, tt.n, tt.m, got, err, tt.want)
+		}
+	}
+
+	// The product may exceed int64 as long as the result fits.
+	basis := mustParse(t, "9000000000000")
+	got, err := basis.MulDiv(999_999, 1_000_000)
+	if err != nil || got != mustParse(t, "8999991000000") {
+		t.Errorf("Expected 8999991000000, got %s (%v)", got, err)
+	}
+	if _, err := basis.MulDiv(2, 1); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Expected ErrOverflow, got %v", err)
+	}
+	_, err = FromMicros(math.MaxInt64).MulDiv(math.MaxInt64, math.MaxInt64-1)
+	if !errors.Is(err, ErrOverflow) {
+		t.Errorf("Expected ErrOverflow, got %v", err)
+	}
+}
+
+func TestSignAndCmp(t *testing.T) {
+	a, b := mustParse(t, "-1.5"), mustParse(t, "2")
+	if a.Sign() != -1 || b.Sign() != 1 || Zero.Sign() != 0 || !Zero.IsZero() {
+		t.Error("Unexpected sign")
+	}
+	if a.Cmp(b) != -1 || b.Cmp(a) != 1 || a.Cmp(a) != 0 {
+		t.Error("Unexpected comparison")
+	}
+	if a.Abs() != mustParse(t, "1.5") {
+		t.Errorf("Expected 1.5, got %s", a.Abs())
+	}
+}
+
+func TestJSON_IsAString(t *testing.T) {
+	data, err := json.Marshal(struct {
+		PnL Decimal `json:"pnl"`
+	}{mustParse(t, "-0.1")})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if string(data) != `{"pnl":"-0.1"}` {
+		t.Errorf("Unexpected JSON %s", data)
+	}
+
+	var d Decimal
+	if err := json.Unmarshal([]byte(`"12.34"`), &d); err != nil || d != mustParse(t, "12.34") {
+		t.Errorf("Expected 12.34, got %s (%v)", d, err)
+	}
+	if err := json.Unmarshal([]byte(`12.34`), &d); err == nil {
+		t.Error("Expected error for a JSON number")
+	}
+}